@@ -2,25 +2,34 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"time"
+	_ "time/tzdata" // часовые пояса городов нужны и в образе без tzdata
 	"weather-api/config"
 	"weather-api/internal/adapters/postgres"
 	"weather-api/internal/adapters/redis"
 	"weather-api/internal/adapters/telegram"
+	"weather-api/internal/adapters/upstream_budget"
 	"weather-api/internal/adapters/weather_cache"
 	"weather-api/internal/adapters/weather_client"
 	"weather-api/internal/controllers"
+	graphqlController "weather-api/internal/controllers/graphql_weather_controller"
+	grpcController "weather-api/internal/controllers/grpc_weather_controller"
 	httpController "weather-api/internal/controllers/http_weather_controller"
 	telegramController "weather-api/internal/controllers/telegram"
+	"weather-api/internal/dto"
+	"weather-api/internal/middleware"
 	"weather-api/internal/redis_cache"
 	"weather-api/internal/usecase"
+	"weather-api/pkg/lifecycle"
 	"weather-api/pkg/logger"
 	"weather-api/pkg/metrics"
 	"weather-api/pkg/postgresql"
+	"weather-api/pkg/tracing"
 
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/joho/godotenv"
@@ -30,21 +39,33 @@ import (
 func main() {
 	// Загрузка .env
 	if err := godotenv.Load(); err != nil {
-		tempLogger := logger.NewLogger("info")
+		tempLogger := logger.NewLogger("info", os.Getenv("LOG_FORMAT"))
 		tempLogger.Warn("No .env file found, relying on environment variables", "err", err)
 	}
 
 	// Загрузка конфига
 	cfg, err := config.LoadConfig()
 	if err != nil {
-		tempLogger := logger.NewLogger("info")
+		tempLogger := logger.NewLogger("info", os.Getenv("LOG_FORMAT"))
 		tempLogger.Fatal("load config failed", "err", err)
 	}
 
 	// Создаем логгер
-	log := logger.NewLogger(cfg.LogLevel)
+	log := logger.NewLogger(cfg.LogLevel, cfg.LogFormat)
 	slog.SetDefault(log.Logger)
 
+	// Трассировка OpenTelemetry
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Exporter:    cfg.Tracing.Exporter,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		SampleRatio: &cfg.Tracing.SampleRatio,
+		ServiceName: "weather-api",
+	})
+	if err != nil {
+		log.Fatal("failed to initialize tracing", "error", err)
+	}
+
 	// Инициализация метрик Prometheus
 	appMetrics := metrics.NewMetrics()
 
@@ -60,7 +81,6 @@ func main() {
 	if err != nil {
 		log.Fatal("failed to initialize postgres", "error", err)
 	}
-	defer db.Close()
 	log.Info("successfully connected to postgres")
 
 	// Redis
@@ -76,6 +96,18 @@ func main() {
 	}
 	log.Info("redis connected", "response", pong)
 
+	// Жизненный цикл приложения: остановка по сигналу и закрытие соединений
+	app := lifecycle.New(lifecycle.Options{ShutdownTimeout: cfg.Server.ShutdownTimeout})
+	// Ресурсы закрываются в обратном порядке: трассы отправляются последними,
+	// чтобы в них попала остановка
+	app.OnClose("tracing", func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		return shutdownTracing(ctx)
+	})
+	app.OnClose("postgres", db.Close)
+	app.OnClose("redis", redisClient.Close)
+
 	// Репозиторий городов (PostgreSQL)
 	pgCityRepo := postgres.NewCityRepository(postgres.CityRepositoryOptions{DB: db.DB})
 
@@ -85,45 +117,268 @@ func main() {
 	// Погодный клиент
 	weatherClient := weather_client.NewClient(weather_client.ClientOptions{URL: cfg.WeatherAPI.URL})
 
+	// Суточный лимит запросов к провайдеру погоды
+	upstreamBudget := upstream_budget.NewBudget(upstream_budget.BudgetOptions{
+		Provider:          "open-meteo",
+		WeatherRepository: weatherClient,
+		Counter:           redis_cache.NewUpstreamBudgetRedis(redisClient),
+		DailyLimit:        cfg.WeatherAPI.DailyLimit,
+		Reserved:          cfg.WeatherAPI.Reserved,
+		StaleOnlyRatio:    cfg.WeatherAPI.StaleOnlyRatio,
+		Metrics:           appMetrics,
+	})
+
 	// Кэширующий прокси для погоды
-	weatherRepository := weather_cache.NewWeatherCache(redisClient, weatherClient, appMetrics)
+	weatherRepository := weather_cache.NewWeatherCache(redisClient, upstreamBudget, appMetrics, upstreamBudget)
 
 	// UseCase
 	weatherUsecase := usecase.NewWeatherUseCase(usecase.WeatherUseCaseOptions{
+		WeatherRepository:   weatherRepository,
+		CityRepository:      cityRepository,
+		CityBatchRepository: cityRepository,
+		WeatherBatchCache:   weatherRepository,
+		WeatherCacheTTL:     weatherRepository,
+		CityCacheTTL:        cityRepository,
+	})
+
+	// Подписки на ежедневную сводку
+	subscriptionRepository := postgres.NewSubscriptionRepository(postgres.SubscriptionRepositoryOptions{DB: db.DB})
+	subscriptionUsecase := usecase.NewSubscriptionUseCase(usecase.SubscriptionUseCaseOptions{
+		SubscriptionRepository: subscriptionRepository,
+		CityRepository:         cityRepository,
+	})
+
+	// Настройки чатов
+	preferencesRepository := postgres.NewPreferencesRepository(postgres.PreferencesRepositoryOptions{DB: db.DB})
+	preferencesUsecase := usecase.NewPreferencesUseCase(usecase.PreferencesUseCaseOptions{
+		PreferencesRepository: preferencesRepository,
+		CityRepository:        cityRepository,
+	})
+
+	// Администрирование бота
+	chatRepository := postgres.NewChatRepository(postgres.ChatRepositoryOptions{DB: db.DB})
+	adminUsecase := usecase.NewAdminUseCase(usecase.AdminUseCaseOptions{
+		CityRepository:    cityRepository,
+		WeatherRepository: weatherRepository,
+		ChatRepository:    chatRepository,
+		CityCache:         cityRepository,
+		WeatherCache:      weatherRepository,
+		Metrics:           appMetrics,
+	})
+
+	// Карточки погоды
+	cardUsecase := usecase.NewCardUseCase(usecase.CardUseCaseOptions{
 		WeatherRepository: weatherRepository,
 		CityRepository:    cityRepository,
+		CardCache:         redis_cache.NewCardCacheRedis(redisClient, appMetrics),
 	})
 
-	// HTTP контроллер
-	weatherController := controllers.NewWeatherController(controllers.WeatherControllerOptions{
+	// Наблюдение за погодой для потоков изменений
+	weatherWatcher := usecase.NewWeatherWatcher(usecase.WeatherWatcherOptions{
 		WeatherUseCase: weatherUsecase,
+		Interval:       cfg.Server.StreamInterval,
+		AlertsInterval: cfg.Server.StreamAlertsInterval,
 	})
 
-	// HTTP маршруты
-	router := httpController.SetupRoutes(weatherController, appMetrics)
+	// Ключи доступа к HTTP API
+	apiKeyUsecase := usecase.NewAPIKeyUseCase(usecase.APIKeyUseCaseOptions{
+		APIKeyRepository: postgres.NewAPIKeyRepository(postgres.APIKeyRepositoryOptions{DB: db.DB}),
+		UsageCounter:     redis_cache.NewAPIKeyUsageRedis(redisClient),
+		CacheTTL:         cfg.Server.APIKeyCacheTTL,
+	})
+	var apiKeys middleware.APIKeyAuthenticator
+	if cfg.Server.APIKeyAuth {
+		apiKeys = apiKeyUsecase
+	} else {
+		log.Warn("api key authentication is disabled")
+	}
+
+	// Проверки зависимостей для /readyz. Без провайдера погоды и Telegram
+	// сервис продолжает работать с кэшем, поэтому они некритичны.
+	healthUsecase := usecase.NewHealthUseCase(usecase.HealthUseCaseOptions{
+		CacheTTL: cfg.Server.HealthCacheTTL,
+		Stopping: app.Stopping(),
+	})
+	healthUsecase.Register(usecase.HealthCheck{Name: "postgres", Checker: usecase.HealthCheckFunc(db.PingContext), Critical: true})
+	healthUsecase.Register(usecase.HealthCheck{Name: "redis", Checker: usecase.HealthCheckFunc(func(ctx context.Context) error {
+		_, err := redisClient.Ping(ctx)
+		return err
+	}), Critical: true})
+	healthUsecase.Register(usecase.HealthCheck{Name: "weather_api", Checker: usecase.HealthCheckFunc(weatherClient.Probe), CacheTTL: time.Minute})
+
+	// HTTP контроллер
+	weatherController := controllers.NewWeatherController(controllers.WeatherControllerOptions{
+		WeatherUseCase:  weatherUsecase,
+		CardUseCase:     cardUsecase,
+		WeatherWatcher:  weatherWatcher,
+		StreamHeartbeat: cfg.Server.StreamHeartbeat,
+		Metrics:         appMetrics,
+
+		WebSocketIdleTimeout:      cfg.Server.WebSocketIdleTimeout,
+		WebSocketMaxSubscriptions: cfg.Server.WebSocketMaxSubscriptions,
+		WebSocketSubscribeRate:    cfg.Server.WebSocketSubscribeRate,
+		APIKeyUseCase:             apiKeyUsecase,
+		AdminUseCase:              adminUsecase,
+		HealthUseCase:             healthUsecase,
+	})
 
 	// Telegram бот
-	bot, err := telegram.NewBot(cfg.Telegram.Token)
+	bot, err := telegram.NewBot(telegram.BotOptions{
+		Token:          cfg.Telegram.Token,
+		APIEndpoint:    cfg.Telegram.APIEndpoint,
+		Mode:           cfg.Telegram.Mode,
+		WebhookURL:     cfg.Telegram.WebhookURL,
+		WebhookSecret:  cfg.Telegram.WebhookSecret,
+		GlobalSendRate: cfg.Telegram.SendRate,
+		ChatSendRate:   cfg.Telegram.ChatSendRate,
+	})
 	if err != nil {
 		log.Error("failed to create telegram bot", "error", err)
 		os.Exit(1)
 	}
+	healthUsecase.Register(usecase.HealthCheck{Name: "telegram", Checker: usecase.HealthCheckFunc(bot.Ping), CacheTTL: time.Minute})
+
+	// В режиме webhook обновления приходят на тот же HTTP сервер
+	var telegramWebhook http.Handler
+	if cfg.Telegram.Mode == telegram.ModeWebhook {
+		telegramWebhook = bot.WebhookHandler()
+	}
+
+	// Ограничение частоты запросов, общее для HTTP и gRPC
+	rateLimiter := redis_cache.NewRateLimiterRedis(redisClient)
+	rateLimit := dto.RateLimit{
+		Rate:  cfg.Server.RateLimitRPS,
+		Burst: cfg.Server.RateLimitBurst,
+	}
+	ipRateLimit := dto.RateLimit{
+		Rate:  cfg.Server.RateLimitIPRPS,
+		Burst: cfg.Server.RateLimitIPBurst,
+	}
+
+	// HTTP маршруты
+	router := httpController.SetupRoutes(weatherController, appMetrics, httpController.RoutesOptions{
+		V1DeprecatedAt: cfg.Server.V1DeprecatedAt,
+		V1Sunset:       cfg.Server.V1Sunset,
+		GraphQL: graphqlController.NewHandler(graphqlController.HandlerOptions{
+			WeatherUseCase: weatherUsecase,
+			MaxDepth:       cfg.Server.GraphQLMaxDepth,
+			MaxComplexity:  cfg.Server.GraphQLMaxComplexity,
+		}),
+		APIKeys:             apiKeys,
+		AdminToken:          cfg.Server.AdminToken,
+		RateLimiter:         rateLimiter,
+		RateLimit:           rateLimit,
+		IPRateLimit:         ipRateLimit,
+		TrustForwardedFor:   cfg.Server.RateLimitTrustForwardedFor,
+		TelegramWebhook:     telegramWebhook,
+		TelegramWebhookPath: cfg.Telegram.WebhookPath,
+	})
+
+	// gRPC сервер для внутренних сервисов
+	grpcServer := grpcController.NewServer(grpcController.ServerOptions{
+		WeatherUseCase: weatherUsecase,
+		Metrics:        appMetrics,
+		APIKeys:        apiKeys,
+		RateLimiter:    rateLimiter,
+		RateLimit:      rateLimit,
+		IPRateLimit:    ipRateLimit,
+		Stopping:       app.Stopping(),
+	})
 
 	// Telegram контроллер
-	tgController := telegramController.NewTelegramController(bot, weatherUsecase)
+	tgController := telegramController.NewTelegramController(telegramController.TelegramControllerOptions{
+		Bot:                 bot,
+		WeatherUseCase:      weatherUsecase,
+		SubscriptionUseCase: subscriptionUsecase,
+		PreferencesUseCase:  preferencesUsecase,
+		AdminUseCase:        adminUsecase,
+		CardUseCase:         cardUsecase,
+		Admins:              cfg.Telegram.Admins,
+		Metrics:             appMetrics,
+		Workers:             cfg.Telegram.Workers,
+		QueueSize:           cfg.Telegram.QueueSize,
+	})
 
-	// Запуск Telegram контроллера
-	go func() {
-		ctx := context.Background()
-		if err := tgController.Start(ctx); err != nil {
-			log.Error("telegram controller failed", "error", err)
+	// Планировщик ежедневных сводок
+	digestScheduler := telegramController.NewDigestScheduler(telegramController.DigestSchedulerOptions{
+		Bot:                 bot,
+		WeatherUseCase:      weatherUsecase,
+		SubscriptionUseCase: subscriptionUsecase,
+		PreferencesUseCase:  preferencesUsecase,
+		Interval:            cfg.Telegram.DigestInterval,
+	})
+
+	// Сначала /readyz начинает отвечать 503, и балансировщик успевает убрать
+	// экземпляр, пока сервер еще принимает запросы
+	app.OnStop("readiness delay", func(ctx context.Context) error {
+		select {
+		case <-time.After(cfg.Server.ShutdownDelay):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
 		}
-	}()
+	})
+
+	// Бот перестает принимать обновления до остановки HTTP сервера, чтобы webhook
+	// сразу отвечал 503 и не ждал места в очереди. Принятые обновления дообрабатываются,
+	// а отправка сообщений прерывается только после завершения контроллера.
+	app.OnStop("telegram bot", func(context.Context) error {
+		bot.StopReceiving()
+		return nil
+	})
+	app.OnClose("telegram bot", func() error {
+		bot.Close()
+		return nil
+	})
 
 	// Запуск HTTP сервера
-	log.Info("server start", "port", cfg.Server.Port)
-	if err := http.ListenAndServe(":"+cfg.Server.Port, router); err != nil {
-		log.Error("server failed", "error", err)
+	httpServer := &http.Server{
+		Addr:    ":" + cfg.Server.Port,
+		Handler: router,
+	}
+	app.Go("http server", func(context.Context) error {
+		log.Info("server start", "port", cfg.Server.Port)
+		if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	})
+	app.OnStop("http server", func(ctx context.Context) error {
+		// Потоки SSE и WebSocket закрываются первыми, иначе Shutdown их не дождется
+		return errors.Join(weatherController.CloseStreams(ctx), httpServer.Shutdown(ctx))
+	})
+
+	// Запуск gRPC сервера
+	grpcListener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+	if err != nil {
+		log.Error("failed to listen grpc port", "port", cfg.Server.GRPCPort, "error", err)
+		os.Exit(1)
+	}
+	app.Go("grpc server", func(context.Context) error {
+		log.Info("grpc server start", "port", cfg.Server.GRPCPort)
+		return grpcServer.Serve(grpcListener)
+	})
+	app.OnStop("grpc server", func(ctx context.Context) error {
+		stopped := make(chan struct{})
+		go func() {
+			grpcServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+			return nil
+		case <-ctx.Done():
+			grpcServer.Stop()
+			return ctx.Err()
+		}
+	})
+
+	// Запуск Telegram контроллера и планировщика сводок
+	app.Go("telegram controller", tgController.Start)
+	app.Go("digest scheduler", digestScheduler.Run)
+
+	if err := app.Run(); err != nil {
+		log.Error("shutdown finished with errors", "error", err)
 		os.Exit(1)
 	}
 }
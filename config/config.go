@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -12,7 +13,9 @@ type Config struct {
 	Server     *Server     `envPrefix:"SERVER_"`
 	Telegram   *Telegram   `envPrefix:"TELEGRAM_"`
 	Redis      *Redis      `envPrefix:"REDIS_"`
-	LogLevel   string      `env:"LOG_LEVEL"` // уровень логирования
+	Tracing    *Tracing    `envPrefix:"TRACING_"`
+	LogLevel   string      `env:"LOG_LEVEL"`                    // уровень логирования
+	LogFormat  string      `env:"LOG_FORMAT" envDefault:"text"` // формат логов: text или json
 }
 
 type Postgres struct {
@@ -31,14 +34,87 @@ type Redis struct {
 
 type WeatherAPI struct {
 	URL string `env:"URL"`
+	// DailyLimit суточный лимит запросов к провайдеру, 0 без ограничения
+	DailyLimit int64 `env:"DAILY_LIMIT" envDefault:"10000"`
+	// Reserved часть лимита только для плановых задач, например утренней сводки
+	Reserved int64 `env:"RESERVED" envDefault:"500"`
+	// StaleOnlyRatio доля лимита, после которой обычные запросы обслуживаются только из кэша
+	StaleOnlyRatio float64 `env:"STALE_ONLY_RATIO" envDefault:"0.9"`
 }
 
 type Server struct {
 	Port string `env:"PORT"`
+	// GRPCPort порт gRPC сервера для внутренних сервисов
+	GRPCPort string `env:"GRPC_PORT" envDefault:"50051"`
+	// V1DeprecatedAt и V1Sunset даты объявления устаревания и отключения API v1 в формате RFC 3339
+	V1DeprecatedAt time.Time `env:"V1_DEPRECATED_AT" envDefault:"2026-11-01T00:00:00Z"`
+	V1Sunset       time.Time `env:"V1_SUNSET" envDefault:"2027-06-30T00:00:00Z"`
+	// GraphQLMaxDepth и GraphQLMaxComplexity ограничивают запросы к /graphql
+	GraphQLMaxDepth      int `env:"GRAPHQL_MAX_DEPTH" envDefault:"5"`
+	GraphQLMaxComplexity int `env:"GRAPHQL_MAX_COMPLEXITY" envDefault:"300"`
+	// StreamInterval как часто перечитывать погоду для потоков, StreamHeartbeat как часто слать heartbeat
+	StreamInterval  time.Duration `env:"STREAM_INTERVAL" envDefault:"30s"`
+	StreamHeartbeat time.Duration `env:"STREAM_HEARTBEAT" envDefault:"15s"`
+	// StreamAlertsInterval как часто пересчитывать предупреждения для подписчиков
+	StreamAlertsInterval time.Duration `env:"STREAM_ALERTS_INTERVAL" envDefault:"15m"`
+	// WebSocketIdleTimeout закрывает соединение без сообщений и pong от клиента,
+	// WebSocketMaxSubscriptions ограничивает подписки на одно соединение
+	// WebSocketSubscribeRate ограничивает число новых подписок в секунду на одно соединение
+	WebSocketIdleTimeout      time.Duration `env:"WS_IDLE_TIMEOUT" envDefault:"60s"`
+	WebSocketMaxSubscriptions int           `env:"WS_MAX_SUBSCRIPTIONS" envDefault:"20"`
+	WebSocketSubscribeRate    float64       `env:"WS_SUBSCRIBE_RATE" envDefault:"1"`
+	// APIKeyAuth требовать ключ доступа для /api и /graphql. APIKeyCacheTTL сколько
+	// ключ хранится в памяти, столько отозванный ключ может работать на других экземплярах.
+	APIKeyAuth     bool          `env:"API_KEY_AUTH" envDefault:"true"`
+	APIKeyCacheTTL time.Duration `env:"API_KEY_CACHE_TTL" envDefault:"1m"`
+	// AdminToken токен для /admin, если пустой, выпуск и отзыв ключей через API отключены
+	AdminToken string `env:"ADMIN_TOKEN"`
+	// RateLimitRPS и RateLimitBurst корзина токенов на ключ или адрес клиента, 0 отключает ограничение.
+	// RateLimitTrustForwardedFor включать только за своим прокси.
+	RateLimitRPS               float64 `env:"RATE_LIMIT_RPS" envDefault:"5"`
+	RateLimitBurst             int     `env:"RATE_LIMIT_BURST" envDefault:"20"`
+	RateLimitTrustForwardedFor bool    `env:"RATE_LIMIT_TRUST_FORWARDED_FOR" envDefault:"false"`
+	// RateLimitIPRPS и RateLimitIPBurst корзина на адрес клиента до проверки ключа,
+	// ограничивает перебор ключей. 0 отключает ограничение.
+	RateLimitIPRPS   float64 `env:"RATE_LIMIT_IP_RPS" envDefault:"20"`
+	RateLimitIPBurst int     `env:"RATE_LIMIT_IP_BURST" envDefault:"60"`
+	// ShutdownTimeout сколько ждать завершения запросов и фоновых задач при остановке
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" envDefault:"30s"`
+	// ShutdownDelay сколько после сигнала остановки /readyz отвечает 503 до остановки HTTP сервера
+	ShutdownDelay time.Duration `env:"SHUTDOWN_DELAY" envDefault:"5s"`
+	// HealthCacheTTL сколько хранить результаты проверок зависимостей для /readyz
+	HealthCacheTTL time.Duration `env:"HEALTH_CACHE_TTL" envDefault:"5s"`
 }
 
 type Telegram struct {
 	Token string `env:"TOKEN"`
+	// APIEndpoint шаблон адреса Bot API, можно направить на фейковый сервер для тестов
+	APIEndpoint string `env:"API_ENDPOINT"`
+	// Mode режим получения обновлений: polling или webhook
+	Mode          string `env:"MODE" envDefault:"polling"`
+	WebhookURL    string `env:"WEBHOOK_URL"`
+	WebhookPath   string `env:"WEBHOOK_PATH" envDefault:"/telegram/webhook"`
+	WebhookSecret string `env:"WEBHOOK_SECRET"`
+	// Workers задает пул обработчиков обновлений, QueueSize предел очереди одного чата
+	Workers   int `env:"WORKERS" envDefault:"8"`
+	QueueSize int `env:"QUEUE_SIZE" envDefault:"100"`
+	// Лимиты исходящих сообщений: всего в секунду и в один чат в секунду
+	SendRate     float64 `env:"SEND_RATE" envDefault:"30"`
+	ChatSendRate float64 `env:"CHAT_SEND_RATE" envDefault:"1"`
+	// Admins идентификаторы администраторов бота через запятую
+	Admins []int64 `env:"ADMINS" envSeparator:","`
+	// DigestInterval как часто планировщик проверяет подписки на ежедневную сводку
+	DigestInterval time.Duration `env:"DIGEST_INTERVAL" envDefault:"1m"`
+}
+
+type Tracing struct {
+	// Exporter куда отправлять трассы: none, otlp или stdout (печать в stderr)
+	Exporter string `env:"EXPORTER" envDefault:"none"`
+	// Endpoint адрес коллектора OTLP/gRPC, по умолчанию из OTEL_EXPORTER_OTLP_ENDPOINT
+	Endpoint string `env:"ENDPOINT"`
+	Insecure bool   `env:"INSECURE" envDefault:"false"`
+	// SampleRatio доля записываемых трасс от 0 до 1, 0 отключает запись новых трасс
+	SampleRatio float64 `env:"SAMPLE_RATIO" envDefault:"1"`
 }
 
 func LoadConfig() (*Config, error) {
@@ -47,6 +123,8 @@ func LoadConfig() (*Config, error) {
 	config.WeatherAPI = new(WeatherAPI)
 	config.Server = new(Server)
 	config.Telegram = new(Telegram)
+	config.Redis = new(Redis)
+	config.Tracing = new(Tracing)
 
 	if err := env.Parse(config); err != nil {
 		return nil, fmt.Errorf("env.Parse: %v", err)
@@ -67,6 +145,14 @@ func LoadConfig() (*Config, error) {
 	if config.Telegram.Token == "" {
 		return nil, fmt.Errorf("TELEGRAM_TOKEN is required")
 	}
+	if config.Telegram.Mode == "webhook" {
+		if config.Telegram.WebhookURL == "" {
+			return nil, fmt.Errorf("TELEGRAM_WEBHOOK_URL is required in webhook mode")
+		}
+		if config.Telegram.WebhookSecret == "" {
+			return nil, fmt.Errorf("TELEGRAM_WEBHOOK_SECRET is required in webhook mode")
+		}
+	}
 
 	return config, nil
 }
@@ -0,0 +1,26 @@
+package config
+
+import "testing"
+
+// TestLoadConfigRedis проверяет, что секция Redis создается и читается из окружения
+func TestLoadConfigRedis(t *testing.T) {
+	t.Setenv("WEATHER_API_URL", "http://weather.test")
+	t.Setenv("SERVER_PORT", "8080")
+	t.Setenv("POSTGRES_HOST", "localhost")
+	t.Setenv("POSTGRES_DB", "weather")
+	t.Setenv("TELEGRAM_TOKEN", "token")
+	t.Setenv("REDIS_HOST", "redis.test")
+	t.Setenv("REDIS_PORT", "6380")
+	t.Setenv("REDIS_TTL", "120")
+
+	config, err := LoadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.Redis == nil {
+		t.Fatal("Redis config is nil")
+	}
+	if config.Redis.Host != "redis.test" || config.Redis.Port != "6380" || config.Redis.TTL != 120 {
+		t.Errorf("Redis = %+v", *config.Redis)
+	}
+}
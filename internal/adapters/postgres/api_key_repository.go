@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Убедимся, что APIKeyRepository реализует интерфейс repository.APIKeyRepository
+var _ repository.APIKeyRepository = (*APIKeyRepository)(nil)
+
+type APIKeyRepository struct {
+	db *sqlx.DB
+}
+
+type APIKeyRepositoryOptions struct {
+	DB *sqlx.DB
+}
+
+func NewAPIKeyRepository(options APIKeyRepositoryOptions) *APIKeyRepository {
+	return &APIKeyRepository{db: options.DB}
+}
+
+// CreateAPIKey сохраняет новый ключ
+func (r *APIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.CreateAPIKey", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		INSERT INTO api_keys (id, key_hash, owner, scopes, daily_quota, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err = r.db.ExecContext(ctx, query,
+		key.ID,
+		key.KeyHash,
+		key.Owner,
+		pq.Array(key.Scopes),
+		key.DailyQuota,
+		key.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKey получает ключ по идентификатору
+func (r *APIKeyRepository) GetAPIKey(ctx context.Context, id string) (_ *models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.GetAPIKey", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, key_hash, owner, scopes, daily_quota, created_at, revoked_at
+		FROM api_keys
+		WHERE id = $1`
+
+	key, err := scanAPIKey(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("api key %q: %w", id, models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает все ключи, новые первыми
+func (r *APIKeyRepository) ListAPIKeys(ctx context.Context) (_ []models.APIKey, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.ListAPIKeys", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT id, key_hash, owner, scopes, daily_quota, created_at, revoked_at
+		FROM api_keys
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []models.APIKey
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает ключ
+func (r *APIKeyRepository) RevokeAPIKey(ctx context.Context, id string) (err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyRepository.RevokeAPIKey", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, now()) WHERE id = $1`
+
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("rows affected error: %w", err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("api key %q: %w", id, models.ErrNotFound)
+	}
+
+	return nil
+}
+
+// scanAPIKey читает строку api_keys из sql.Row или sql.Rows
+func scanAPIKey(row interface{ Scan(dest ...any) error }) (*models.APIKey, error) {
+	var key models.APIKey
+	var revokedAt sql.NullTime
+	err := row.Scan(
+		&key.ID,
+		&key.KeyHash,
+		&key.Owner,
+		pq.Array(&key.Scopes),
+		&key.DailyQuota,
+		&key.CreatedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return &key, nil
+}
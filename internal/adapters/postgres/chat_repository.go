@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Убедимся, что ChatRepository реализует интерфейс repository.ChatRepository
+var _ repository.ChatRepository = (*ChatRepository)(nil)
+
+type ChatRepository struct {
+	db *sqlx.DB
+}
+
+type ChatRepositoryOptions struct {
+	DB *sqlx.DB
+}
+
+func NewChatRepository(options ChatRepositoryOptions) *ChatRepository {
+	return &ChatRepository{db: options.DB}
+}
+
+// Touch регистрирует чат или обновляет время его последней активности
+func (r *ChatRepository) Touch(ctx context.Context, chatID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "ChatRepository.Touch", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		INSERT INTO telegram_chats (chat_id) VALUES ($1)
+		ON CONFLICT (chat_id) DO UPDATE SET last_seen_at = now()`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// Count возвращает число известных чатов
+func (r *ChatRepository) Count(ctx context.Context) (_ int, err error) {
+	ctx, span := tracer.Start(ctx, "ChatRepository.Count", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `SELECT count(*) FROM telegram_chats`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query).Scan(&count); err != nil {
+		return 0, fmt.Errorf("query error: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListIDs возвращает идентификаторы всех известных чатов
+func (r *ChatRepository) ListIDs(ctx context.Context) (_ []int64, err error) {
+	ctx, span := tracer.Start(ctx, "ChatRepository.ListIDs", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `SELECT chat_id FROM telegram_chats ORDER BY chat_id`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return ids, nil
+}
@@ -7,12 +7,18 @@ import (
 	"fmt"
 	"weather-api/internal/models"
 	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 // Убедимся, что CityRepository реализует интерфейс repository.CityRepository
 var _ repository.CityRepository = (*CityRepository)(nil)
+var _ repository.CityBatchRepository = (*CityRepository)(nil)
+
+// uniqueViolation код ошибки PostgreSQL при нарушении уникальности
+const uniqueViolation = "23505"
 
 type CityRepository struct {
 	db *sqlx.DB
@@ -27,20 +33,25 @@ func NewCityRepository(options CityRepositoryOptions) *CityRepository {
 }
 
 // GetCityByName получает город по имени из PostgreSQL
-func (r *CityRepository) GetCityByName(ctx context.Context, name string) (*models.City, error) {
+func (r *CityRepository) GetCityByName(ctx context.Context, name string) (_ *models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepository.GetCityByName", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
 	// Реализация запроса к PostgreSQL
-	query := `SELECT name, latitude, longitude, country FROM cities WHERE name = $1`
+	query := `SELECT id, name, latitude, longitude, country, timezone FROM cities WHERE name = $1`
 
 	var city models.City
-	err := r.db.QueryRowContext(ctx, query, name).Scan(
+	err = r.db.QueryRowContext(ctx, query, name).Scan(
+		&city.ID,
 		&city.Name,
 		&city.Latitude,
 		&city.Longitude,
 		&city.Country,
+		&city.Timezone,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, fmt.Errorf("city not found: %s", name)
+			return nil, fmt.Errorf("city %q: %w", name, models.ErrNotFound)
 		}
 		return nil, fmt.Errorf("query error: %w", err)
 	}
@@ -49,24 +60,47 @@ func (r *CityRepository) GetCityByName(ctx context.Context, name string) (*model
 }
 
 // GetAllCities получает все города из PostgreSQL
-func (r *CityRepository) GetAllCities(ctx context.Context) ([]models.City, error) {
+func (r *CityRepository) GetAllCities(ctx context.Context) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepository.GetAllCities", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
 	// Реализация запроса к PostgreSQL
-	query := `SELECT name, latitude, longitude, country FROM cities`
+	query := `SELECT id, name, latitude, longitude, country, timezone FROM cities`
 
 	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("query error: %w", err)
 	}
+	return scanCities(rows)
+}
+
+// GetCitiesByNames получает города по списку имен одним запросом
+func (r *CityRepository) GetCitiesByNames(ctx context.Context, names []string) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepository.GetCitiesByNames", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `SELECT id, name, latitude, longitude, country, timezone FROM cities WHERE name = ANY($1)`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(names))
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	return scanCities(rows)
+}
+
+func scanCities(rows *sql.Rows) ([]models.City, error) {
 	defer rows.Close()
 
 	var cities []models.City
 	for rows.Next() {
 		var city models.City
 		if err := rows.Scan(
+			&city.ID,
 			&city.Name,
 			&city.Latitude,
 			&city.Longitude,
 			&city.Country,
+			&city.Timezone,
 		); err != nil {
 			return nil, fmt.Errorf("scan error: %w", err)
 		}
@@ -79,3 +113,28 @@ func (r *CityRepository) GetAllCities(ctx context.Context) ([]models.City, error
 
 	return cities, nil
 }
+
+// CreateCity добавляет город в PostgreSQL
+func (r *CityRepository) CreateCity(ctx context.Context, city models.City) (err error) {
+	ctx, span := tracer.Start(ctx, "CityRepository.CreateCity", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `INSERT INTO cities (name, latitude, longitude, country, timezone) VALUES ($1, $2, $3, $4, $5)`
+
+	_, err = r.db.ExecContext(ctx, query,
+		city.Name,
+		city.Latitude,
+		city.Longitude,
+		city.Country,
+		city.Timezone,
+	)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return fmt.Errorf("city %q: %w", city.Name, models.ErrAlreadyExists)
+		}
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// Убедимся, что PreferencesRepository реализует интерфейс repository.PreferencesRepository
+var _ repository.PreferencesRepository = (*PreferencesRepository)(nil)
+
+type PreferencesRepository struct {
+	db *sqlx.DB
+}
+
+type PreferencesRepositoryOptions struct {
+	DB *sqlx.DB
+}
+
+func NewPreferencesRepository(options PreferencesRepositoryOptions) *PreferencesRepository {
+	return &PreferencesRepository{db: options.DB}
+}
+
+// Get получает настройки чата из PostgreSQL
+func (r *PreferencesRepository) Get(ctx context.Context, chatID int64) (_ *models.ChatPreferences, err error) {
+	ctx, span := tracer.Start(ctx, "PreferencesRepository.Get", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		SELECT language, units, home_city, favourite_cities
+		FROM chat_preferences
+		WHERE chat_id = $1`
+
+	preferences := models.ChatPreferences{ChatID: chatID}
+	var homeCity sql.NullString
+	err = r.db.QueryRowContext(ctx, query, chatID).Scan(
+		&preferences.Language,
+		&preferences.Units,
+		&homeCity,
+		pq.Array(&preferences.FavouriteCities),
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.DefaultChatPreferences(chatID), nil
+		}
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	preferences.HomeCity = homeCity.String
+
+	return &preferences, nil
+}
+
+// Save сохраняет настройки чата целиком
+func (r *PreferencesRepository) Save(ctx context.Context, preferences models.ChatPreferences) (err error) {
+	ctx, span := tracer.Start(ctx, "PreferencesRepository.Save", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		INSERT INTO chat_preferences (chat_id, language, units, home_city, favourite_cities, updated_at)
+		VALUES ($1, $2, $3, $4, $5, now())
+		ON CONFLICT (chat_id) DO UPDATE
+		SET language = EXCLUDED.language,
+		    units = EXCLUDED.units,
+		    home_city = EXCLUDED.home_city,
+		    favourite_cities = EXCLUDED.favourite_cities,
+		    updated_at = EXCLUDED.updated_at`
+
+	homeCity := sql.NullString{String: preferences.HomeCity, Valid: preferences.HomeCity != ""}
+	favourites := preferences.FavouriteCities
+	if favourites == nil {
+		favourites = []string{}
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		preferences.ChatID,
+		preferences.Language,
+		preferences.Units,
+		homeCity,
+		pq.Array(favourites),
+	)
+	if err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
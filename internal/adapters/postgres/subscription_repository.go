@@ -0,0 +1,194 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Убедимся, что SubscriptionRepository реализует интерфейс repository.SubscriptionRepository
+var _ repository.SubscriptionRepository = (*SubscriptionRepository)(nil)
+
+type SubscriptionRepository struct {
+	db *sqlx.DB
+}
+
+type SubscriptionRepositoryOptions struct {
+	DB *sqlx.DB
+}
+
+func NewSubscriptionRepository(options SubscriptionRepositoryOptions) *SubscriptionRepository {
+	return &SubscriptionRepository{db: options.DB}
+}
+
+// Upsert создает подписку чата или заменяет существующую
+func (r *SubscriptionRepository) Upsert(ctx context.Context, subscription models.Subscription) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.Upsert", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		INSERT INTO subscriptions (chat_id, city_name, send_time, last_sent_on)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (chat_id) DO UPDATE
+		SET city_name = EXCLUDED.city_name,
+		    send_time = EXCLUDED.send_time,
+		    last_sent_on = EXCLUDED.last_sent_on,
+		    failed_attempts = 0,
+		    retry_at = NULL,
+		    disabled_at = NULL`
+
+	var lastSentOn sql.NullTime
+	if subscription.LastSentOn != nil {
+		lastSentOn = sql.NullTime{Time: *subscription.LastSentOn, Valid: true}
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		subscription.ChatID,
+		subscription.CityName,
+		subscription.SendTime,
+		lastSentOn,
+	)
+	if err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// Delete удаляет подписку чата
+func (r *SubscriptionRepository) Delete(ctx context.Context, chatID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.Delete", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `DELETE FROM subscriptions WHERE chat_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue помечает сегодняшнюю сводку отправленной для всех включенных подписок, у которых
+// наступило локальное время отправки и время повтора. Время и дата считаются в часовом поясе города.
+// Конкурентный UPDATE перепроверяет условие после блокировки строки, поэтому при
+// нескольких репликах каждую подписку забирает ровно одна из них.
+func (r *SubscriptionRepository) ClaimDue(ctx context.Context) (_ []models.Subscription, err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.ClaimDue", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE subscriptions s
+		SET last_sent_on = (now() AT TIME ZONE c.timezone)::date,
+		    retry_at = NULL
+		FROM cities c
+		WHERE c.name = s.city_name
+		  AND s.disabled_at IS NULL
+		  AND (s.retry_at IS NULL OR s.retry_at <= now())
+		  AND (now() AT TIME ZONE c.timezone)::time >= s.send_time
+		  AND (s.last_sent_on IS NULL OR s.last_sent_on < (now() AT TIME ZONE c.timezone)::date)
+		RETURNING s.chat_id, s.city_name, to_char(s.send_time, 'HH24:MI'), c.timezone, s.last_sent_on, s.failed_attempts`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query error: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []models.Subscription
+	for rows.Next() {
+		var subscription models.Subscription
+		var lastSentOn sql.NullTime
+		if err := rows.Scan(
+			&subscription.ChatID,
+			&subscription.CityName,
+			&subscription.SendTime,
+			&subscription.Timezone,
+			&lastSentOn,
+			&subscription.FailedAttempts,
+		); err != nil {
+			return nil, fmt.Errorf("scan error: %w", err)
+		}
+		if lastSentOn.Valid {
+			subscription.LastSentOn = &lastSentOn.Time
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return subscriptions, nil
+}
+
+// Release откатывает отметку об отправке за sentOn на предыдущий день и откладывает
+// следующую попытку до retryAt
+func (r *SubscriptionRepository) Release(ctx context.Context, chatID int64, sentOn time.Time, retryAt time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.Release", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE subscriptions
+		SET last_sent_on = last_sent_on - 1,
+		    failed_attempts = failed_attempts + 1,
+		    retry_at = $3
+		WHERE chat_id = $1 AND last_sent_on = $2::date`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID, sentOn.Format("2006-01-02"), retryAt); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// Unclaim откатывает отметку об отправке за sentOn на предыдущий день, не трогая счетчик попыток
+func (r *SubscriptionRepository) Unclaim(ctx context.Context, chatID int64, sentOn time.Time) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.Unclaim", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `
+		UPDATE subscriptions
+		SET last_sent_on = last_sent_on - 1
+		WHERE chat_id = $1 AND last_sent_on = $2::date`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID, sentOn.Format("2006-01-02")); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// ResetAttempts обнуляет счетчик неудачных попыток
+func (r *SubscriptionRepository) ResetAttempts(ctx context.Context, chatID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.ResetAttempts", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE subscriptions SET failed_attempts = 0, retry_at = NULL WHERE chat_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
+
+// Disable отключает подписку чата
+func (r *SubscriptionRepository) Disable(ctx context.Context, chatID int64) (err error) {
+	ctx, span := tracer.Start(ctx, "SubscriptionRepository.Disable", spanOptions...)
+	defer func() { tracing.End(span, err) }()
+
+	query := `UPDATE subscriptions SET disabled_at = now() WHERE chat_id = $1`
+
+	if _, err := r.db.ExecContext(ctx, query, chatID); err != nil {
+		return fmt.Errorf("exec error: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package postgres
+
+import (
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("weather-api/internal/adapters/postgres")
+
+// spanOptions общие параметры span запросов к PostgreSQL
+var spanOptions = []trace.SpanStartOption{
+	trace.WithSpanKind(trace.SpanKindClient),
+	trace.WithAttributes(semconv.DBSystemPostgreSQL),
+}
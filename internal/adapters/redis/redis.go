@@ -7,6 +7,9 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrNil ключ не найден
+var ErrNil = redis.Nil
+
 type Client struct {
 	client *redis.Client
 	ttl    time.Duration
@@ -24,6 +27,11 @@ func NewClient(addr string, ttlSeconds int) *Client {
 	}
 }
 
+// Close закрывает соединения с Redis
+func (c *Client) Close() error {
+	return c.client.Close()
+}
+
 func (c *Client) Ping(ctx context.Context) (string, error) {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
@@ -42,10 +50,10 @@ func (c *Client) Get(ctx context.Context, key string) (string, error) {
 	return c.client.Get(ctx, key).Result()
 }
 
-func (c *Client) Del(ctx context.Context, key string) error {
+func (c *Client) Del(ctx context.Context, keys ...string) error {
 	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
 	defer cancel()
-	return c.client.Del(ctx, key).Err()
+	return c.client.Del(ctx, keys...).Err()
 }
 
 func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
@@ -53,3 +61,55 @@ func (c *Client) TTL(ctx context.Context, key string) (time.Duration, error) {
 	defer cancel()
 	return c.client.TTL(ctx, key).Result()
 }
+
+// MGet получает несколько ключей одним запросом. Возвращает только найденные ключи.
+func (c *Client) MGet(ctx context.Context, keys ...string) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]string, len(values))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			found[keys[i]] = s
+		}
+	}
+	return found, nil
+}
+
+// Incr увеличивает счетчик и при первом увеличении задает ему срок жизни ttl
+func (c *Client) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	pipe := c.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	pipe.ExpireNX(ctx, key, ttl)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+// Script Lua-скрипт, выполняется через EVALSHA с откатом на EVAL
+type Script = redis.Script
+
+// NewScript создает скрипт из исходного текста
+func NewScript(src string) *Script {
+	return redis.NewScript(src)
+}
+
+// RunScript атомарно выполняет скрипт
+func (c *Client) RunScript(ctx context.Context, script *Script, keys []string, args ...any) (any, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return script.Run(ctx, c.client, keys, args...).Result()
+}
+
+// SetWithTTL сохраняет значение с собственным сроком жизни вместо TTL клиента
+func (c *Client) SetWithTTL(ctx context.Context, key string, value any, ttl time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
@@ -1,31 +1,112 @@
 package telegram
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"sync"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
+// Режимы получения обновлений
+const (
+	ModePolling = "polling"
+	ModeWebhook = "webhook"
+)
+
+// webhookQueueSize размер буфера обновлений, принятых через webhook
+const webhookQueueSize = 100
+
+// ErrChatUnavailable чат больше не принимает сообщения бота: пользователь заблокировал
+// бота или чата не существует. Повторять отправку бесполезно.
+var ErrChatUnavailable = errors.New("telegram chat is unavailable")
+
 type Bot struct {
 	botAPI  *tgbotapi.BotAPI
+	options BotOptions
 	updates tgbotapi.UpdatesChannel
+	// webhookUpdates очередь обновлений, принятых через webhook
+	webhookUpdates chan tgbotapi.Update
+	limiter        *sendLimiter
+	// ctx отменяется при закрытии бота и прерывает ожидание лимитов отправки
+	ctx    context.Context
+	cancel context.CancelFunc
+	// done закрывается, когда бот перестает принимать обновления
+	done chan struct{}
+	stop sync.Once
+	// webhookMu не дает закрыть очередь webhook во время записи в нее
+	webhookMu sync.RWMutex
 }
 
-func NewBot(token string) (*Bot, error) {
-	botAPI, err := tgbotapi.NewBotAPI(token)
+type BotOptions struct {
+	Token string
+	// APIEndpoint шаблон адреса Bot API, по умолчанию https://api.telegram.org/bot%s/%s.
+	// Позволяет направить бота на локальный фейковый сервер Telegram.
+	APIEndpoint string
+	// Mode режим получения обновлений: polling (по умолчанию) или webhook
+	Mode string
+	// WebhookURL публичный адрес, на который Telegram будет присылать обновления
+	WebhookURL string
+	// WebhookSecret значение заголовка X-Telegram-Bot-Api-Secret-Token
+	WebhookSecret string
+	// GlobalSendRate максимум исходящих сообщений в секунду, по умолчанию 30
+	GlobalSendRate float64
+	// ChatSendRate максимум сообщений в секунду в один чат, по умолчанию 1
+	ChatSendRate float64
+	// ChatSendBurst сколько сообщений подряд можно отправить в чат без ожидания, по умолчанию 3
+	ChatSendBurst int
+}
+
+func NewBot(options BotOptions) (*Bot, error) {
+	if options.APIEndpoint == "" {
+		options.APIEndpoint = tgbotapi.APIEndpoint
+	}
+	if options.Mode == "" {
+		options.Mode = ModePolling
+	}
+
+	botAPI, err := tgbotapi.NewBotAPIWithAPIEndpoint(options.Token, options.APIEndpoint)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create bot: %w", err)
 	}
 	botAPI.Debug = false
 
-	updateConfig := tgbotapi.NewUpdate(0)
-	updateConfig.Timeout = 60
-	updates := botAPI.GetUpdatesChan(updateConfig)
-
-	return &Bot{
+	ctx, cancel := context.WithCancel(context.Background())
+	bot := &Bot{
 		botAPI:  botAPI,
-		updates: updates,
-	}, nil
+		options: options,
+		limiter: newSendLimiter(options.GlobalSendRate, options.ChatSendRate, options.ChatSendBurst),
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+
+	switch options.Mode {
+	case ModePolling:
+		// Убираем webhook, иначе getUpdates вернет ошибку конфликта
+		if _, err := botAPI.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+			cancel()
+			return nil, fmt.Errorf("failed to delete webhook: %w", err)
+		}
+		updateConfig := tgbotapi.NewUpdate(0)
+		updateConfig.Timeout = 60
+		bot.updates = botAPI.GetUpdatesChan(updateConfig)
+	case ModeWebhook:
+		if err := bot.setWebhook(); err != nil {
+			cancel()
+			return nil, err
+		}
+		bot.webhookUpdates = make(chan tgbotapi.Update, webhookQueueSize)
+		bot.updates = bot.webhookUpdates
+	default:
+		cancel()
+		return nil, fmt.Errorf("unknown telegram mode: %q", options.Mode)
+	}
+
+	return bot, nil
 }
 
 func (b *Bot) Updates() tgbotapi.UpdatesChannel {
@@ -37,13 +118,127 @@ func (b *Bot) SendMessage(chatID int64, text string, replyMarkup interface{}) er
 	if replyMarkup != nil {
 		msg.ReplyMarkup = replyMarkup
 	}
+	if err := b.limiter.Wait(b.ctx, chatID); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
 	_, err := b.botAPI.Send(msg)
 	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		return fmt.Errorf("failed to send message: %w", classifySendError(err))
+	}
+	return nil
+}
+
+// SendPhoto отправляет PNG изображение с подписью
+func (b *Bot) SendPhoto(chatID int64, photo []byte, caption string, replyMarkup interface{}) error {
+	msg := tgbotapi.NewPhoto(chatID, tgbotapi.FileBytes{Name: "weather.png", Bytes: photo})
+	msg.Caption = caption
+	if replyMarkup != nil {
+		msg.ReplyMarkup = replyMarkup
+	}
+	if err := b.limiter.Wait(b.ctx, chatID); err != nil {
+		return fmt.Errorf("failed to send photo: %w", err)
+	}
+	if _, err := b.botAPI.Send(msg); err != nil {
+		return fmt.Errorf("failed to send photo: %w", classifySendError(err))
 	}
 	return nil
 }
 
-func (b *Bot) Stop() {
-	b.botAPI.StopReceivingUpdates()
+// classifySendError отмечает ошибки Bot API, после которых писать в чат бесполезно:
+// 403 (бот заблокирован, удален из группы) и "chat not found"
+func classifySendError(err error) error {
+	var apiErr *tgbotapi.Error
+	if errors.As(err, &apiErr) && (apiErr.Code == http.StatusForbidden || strings.Contains(apiErr.Message, "chat not found")) {
+		return fmt.Errorf("%w: %w", ErrChatUnavailable, err)
+	}
+	return err
+}
+
+// EditMessage заменяет текст и inline-клавиатуру ранее отправленного сообщения.
+// Для сообщений, отправленных через inline-режим, передается inlineMessageID.
+func (b *Bot) EditMessage(chatID int64, messageID int, inlineMessageID string, text string, replyMarkup *tgbotapi.InlineKeyboardMarkup) error {
+	edit := tgbotapi.EditMessageTextConfig{
+		BaseEdit: tgbotapi.BaseEdit{
+			ChatID:          chatID,
+			MessageID:       messageID,
+			InlineMessageID: inlineMessageID,
+			ReplyMarkup:     replyMarkup,
+		},
+		Text: text,
+	}
+	if err := b.limiter.Wait(b.ctx, chatID); err != nil {
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	if _, err := b.botAPI.Request(edit); err != nil {
+		// Повторное нажатие "Обновить" без изменений в погоде не является ошибкой
+		if strings.Contains(err.Error(), "message is not modified") {
+			return nil
+		}
+		return fmt.Errorf("failed to edit message: %w", err)
+	}
+	return nil
+}
+
+func (b *Bot) AnswerCallbackQuery(callbackQueryID string, text string) error {
+	if _, err := b.botAPI.Request(tgbotapi.NewCallback(callbackQueryID, text)); err != nil {
+		return fmt.Errorf("failed to answer callback query: %w", err)
+	}
+	return nil
+}
+
+func (b *Bot) AnswerInlineQuery(inlineQueryID string, results []interface{}) error {
+	answer := tgbotapi.InlineConfig{
+		InlineQueryID: inlineQueryID,
+		Results:       results,
+		CacheTime:     60,
+	}
+	if err := b.limiter.Wait(b.ctx, 0); err != nil {
+		return fmt.Errorf("failed to answer inline query: %w", err)
+	}
+	if _, err := b.botAPI.Request(answer); err != nil {
+		return fmt.Errorf("failed to answer inline query: %w", err)
+	}
+	return nil
+}
+
+// StopReceiving прекращает прием обновлений и закрывает канал Updates.
+// Отправка сообщений продолжает работать, чтобы обработчики успели ответить
+// на уже принятые обновления.
+func (b *Bot) StopReceiving() {
+	b.stop.Do(func() {
+		close(b.done)
+		switch b.options.Mode {
+		case ModePolling:
+			b.botAPI.StopReceivingUpdates()
+		case ModeWebhook:
+			b.webhookMu.Lock()
+			close(b.webhookUpdates)
+			b.webhookMu.Unlock()
+		}
+	})
+}
+
+// Close прекращает прием обновлений и прерывает отправки, ждущие лимитов.
+// Вызывается после завершения обработчиков обновлений.
+func (b *Bot) Close() {
+	b.StopReceiving()
+	b.cancel()
+}
+
+// Ping проверяет связь с Bot API запросом getMe
+func (b *Bot) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := b.botAPI.GetMe()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to get bot info: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Ограничения Bot API: около 30 сообщений в секунду всего и около одного в секунду в чат
+const (
+	defaultGlobalSendRate = 30
+	defaultChatSendRate   = 1
+	defaultChatSendBurst  = 3
+)
+
+// chatLimiterIdleTTL через сколько неиспользуемый лимитер чата удаляется
+const chatLimiterIdleTTL = 5 * time.Minute
+
+// sendLimiter ограничивает частоту исходящих сообщений глобально и по каждому чату
+type sendLimiter struct {
+	global    *rate.Limiter
+	chatRate  rate.Limit
+	chatBurst int
+
+	mu        sync.Mutex
+	chats     map[int64]*chatLimiter
+	lastPrune time.Time
+}
+
+type chatLimiter struct {
+	limiter  *rate.Limiter
+	lastUsed time.Time
+}
+
+func newSendLimiter(globalRate, chatRate float64, chatBurst int) *sendLimiter {
+	if globalRate <= 0 {
+		globalRate = defaultGlobalSendRate
+	}
+	if chatRate <= 0 {
+		chatRate = defaultChatSendRate
+	}
+	if chatBurst <= 0 {
+		chatBurst = defaultChatSendBurst
+	}
+	return &sendLimiter{
+		global:    rate.NewLimiter(rate.Limit(globalRate), int(globalRate)),
+		chatRate:  rate.Limit(chatRate),
+		chatBurst: chatBurst,
+		chats:     make(map[int64]*chatLimiter),
+		lastPrune: time.Now(),
+	}
+}
+
+// Wait блокируется, пока отправка в чат не уложится в лимиты, или до отмены контекста.
+// chatID == 0 означает сообщение без чата, например ответ на inline-запрос.
+func (l *sendLimiter) Wait(ctx context.Context, chatID int64) error {
+	if chatID != 0 {
+		if err := l.chat(chatID).Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return l.global.Wait(ctx)
+}
+
+func (l *sendLimiter) chat(chatID int64) *rate.Limiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(l.lastPrune) > chatLimiterIdleTTL {
+		for id, chat := range l.chats {
+			if now.Sub(chat.lastUsed) > chatLimiterIdleTTL {
+				delete(l.chats, id)
+			}
+		}
+		l.lastPrune = now
+	}
+
+	chat, ok := l.chats[chatID]
+	if !ok {
+		chat = &chatLimiter{limiter: rate.NewLimiter(l.chatRate, l.chatBurst)}
+		l.chats[chatID] = chat
+	}
+	chat.lastUsed = now
+	return chat.limiter
+}
@@ -0,0 +1,70 @@
+package telegram
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// secretTokenHeader заголовок, в котором Telegram передает секрет webhook
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// setWebhook регистрирует адрес webhook вместе с секретом в Bot API
+func (b *Bot) setWebhook() error {
+	if b.options.WebhookURL == "" {
+		return fmt.Errorf("webhook url is required in webhook mode")
+	}
+	if b.options.WebhookSecret == "" {
+		return fmt.Errorf("webhook secret is required in webhook mode")
+	}
+
+	params := tgbotapi.Params{
+		"url":          b.options.WebhookURL,
+		"secret_token": b.options.WebhookSecret,
+	}
+	if _, err := b.botAPI.MakeRequest("setWebhook", params); err != nil {
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+	return nil
+}
+
+// WebhookHandler принимает обновления от Telegram и передает их в канал Updates.
+// Запросы без верного секрета отклоняются.
+func (b *Bot) WebhookHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := r.Header.Get(secretTokenHeader)
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(b.options.WebhookSecret)) != 1 {
+			http.Error(w, "Invalid secret token", http.StatusUnauthorized)
+			return
+		}
+
+		update, err := b.botAPI.HandleUpdate(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "failed to decode telegram update", "error", err)
+			http.Error(w, "Invalid update", http.StatusBadRequest)
+			return
+		}
+
+		b.webhookMu.RLock()
+		defer b.webhookMu.RUnlock()
+		select {
+		case <-b.done:
+			http.Error(w, "Bot is stopping", http.StatusServiceUnavailable)
+			return
+		default:
+		}
+
+		// Пока обновление не принято, Telegram будет повторять доставку
+		select {
+		case b.webhookUpdates <- *update:
+			w.WriteHeader(http.StatusOK)
+		case <-b.done:
+			http.Error(w, "Bot is stopping", http.StatusServiceUnavailable)
+		case <-r.Context().Done():
+			http.Error(w, "Request cancelled", http.StatusServiceUnavailable)
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package telegram
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeBotAPI фейковый сервер Bot API, запоминающий параметры setWebhook
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	webhook map[string]string
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/getMe"):
+		w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`))
+	case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		f.webhook = map[string]string{
+			"url":          r.PostForm.Get("url"),
+			"secret_token": r.PostForm.Get("secret_token"),
+		}
+		f.mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	default:
+		w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+	}
+}
+
+func newWebhookBot(t *testing.T) (*Bot, *fakeBotAPI) {
+	t.Helper()
+
+	fake := &fakeBotAPI{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	bot, err := NewBot(BotOptions{
+		Token:         "token",
+		APIEndpoint:   server.URL + "/bot%s/%s",
+		Mode:          ModeWebhook,
+		WebhookURL:    "https://example.com/telegram/webhook",
+		WebhookSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bot.Close)
+	return bot, fake
+}
+
+// TestWebhookRegistered проверяет, что в режиме webhook бот регистрирует адрес и секрет
+func TestWebhookRegistered(t *testing.T) {
+	_, fake := newWebhookBot(t)
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.webhook == nil {
+		t.Fatal("setWebhook was not called")
+	}
+	if got := fake.webhook["url"]; got != "https://example.com/telegram/webhook" {
+		t.Errorf("url = %q", got)
+	}
+	if got := fake.webhook["secret_token"]; got != "secret" {
+		t.Errorf("secret_token = %q", got)
+	}
+}
+
+// TestWebhookHandler проверяет проверку секрета и передачу обновления в канал Updates
+func TestWebhookHandler(t *testing.T) {
+	bot, _ := newWebhookBot(t)
+	handler := bot.WebhookHandler()
+
+	update := map[string]any{
+		"update_id": 42,
+		"message": map[string]any{
+			"message_id": 7,
+			"date":       0,
+			"chat":       map[string]any{"id": 100, "type": "private"},
+			"text":       "/start",
+		},
+	}
+	body, err := json.Marshal(update)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		want   int
+	}{
+		{name: "no secret", secret: "", want: http.StatusUnauthorized},
+		{name: "wrong secret", secret: "wrong", want: http.StatusUnauthorized},
+		{name: "valid secret", secret: "secret", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.secret != "" {
+				req.Header.Set(secretTokenHeader, tt.secret)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+
+	select {
+	case got := <-bot.Updates():
+		if got.UpdateID != 42 || got.Message == nil || got.Message.Text != "/start" || got.Message.Chat.ID != 100 {
+			t.Errorf("unexpected update: %+v", got)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("update was not delivered")
+	}
+
+	select {
+	case got := <-bot.Updates():
+		t.Errorf("unexpected extra update: %+v", got)
+	default:
+	}
+}
+
+// TestWebhookStopReceiving проверяет, что после StopReceiving webhook отклоняет
+// обновления и канал Updates закрывается, а отправка остается доступной до Close
+func TestWebhookStopReceiving(t *testing.T) {
+	bot, _ := newWebhookBot(t)
+	handler := bot.WebhookHandler()
+
+	bot.StopReceiving()
+
+	req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", strings.NewReader(`{"update_id":1}`))
+	req.Header.Set(secretTokenHeader, "secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+
+	select {
+	case _, ok := <-bot.Updates():
+		if ok {
+			t.Error("unexpected update after StopReceiving")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("updates channel was not closed")
+	}
+
+	if err := bot.ctx.Err(); err != nil {
+		t.Errorf("send context error = %v before Close", err)
+	}
+	bot.Close()
+	if bot.ctx.Err() == nil {
+		t.Error("send context is not cancelled after Close")
+	}
+}
@@ -0,0 +1,170 @@
+package upstream_budget
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/pkg/metrics"
+)
+
+// Проверка, что тип реализует интерфейсы
+var _ repository.WeatherRepository = (*Budget)(nil)
+var _ repository.UpstreamBudget = (*Budget)(nil)
+
+const (
+	// defaultStaleOnlyRatio с какой доли лимита включается режим только кэша
+	defaultStaleOnlyRatio = 0.9
+	// refreshInterval как часто перечитывать счетчик, чтобы видеть запросы других экземпляров
+	refreshInterval = 10 * time.Second
+)
+
+type BudgetOptions struct {
+	// Provider имя внешнего сервиса в ключах счетчика и метриках
+	Provider          string
+	WeatherRepository repository.WeatherRepository
+	Counter           repository.UpstreamBudgetCounter
+	// DailyLimit сколько запросов в сутки по UTC разрешает провайдер, 0 без ограничения
+	DailyLimit int64
+	// Reserved часть лимита, которую могут тратить только плановые задачи
+	Reserved int64
+	// StaleOnlyRatio доля лимита, после которой обычные запросы обслуживаются
+	// только из кэша, по умолчанию 0.9
+	StaleOnlyRatio float64
+	Metrics        *metrics.Metrics
+}
+
+// Budget следит за суточным лимитом запросов к провайдеру погоды. Стоит перед
+// клиентом API погоды: запрос уходит к провайдеру, только если на него хватает лимита.
+type Budget struct {
+	options   BudgetOptions
+	threshold int64
+	staleOnly atomic.Bool
+
+	mu          sync.Mutex
+	refreshedAt time.Time
+}
+
+func NewBudget(options BudgetOptions) *Budget {
+	if options.WeatherRepository == nil {
+		panic("weather repository must not be nil")
+	}
+	if options.Counter == nil {
+		panic("upstream budget counter must not be nil")
+	}
+	if options.StaleOnlyRatio <= 0 || options.StaleOnlyRatio > 1 {
+		options.StaleOnlyRatio = defaultStaleOnlyRatio
+	}
+	if options.Reserved < 0 || options.Reserved > options.DailyLimit {
+		options.Reserved = 0
+	}
+
+	b := &Budget{
+		options: options,
+		// Режим только кэша включается не позже, чем обычным запросам станет недоступен лимит
+		threshold: min(int64(float64(options.DailyLimit)*options.StaleOnlyRatio), options.DailyLimit-options.Reserved),
+	}
+	if options.Metrics != nil {
+		options.Metrics.UpstreamBudgetLimit.WithLabelValues(options.Provider).Set(float64(options.DailyLimit))
+	}
+	return b
+}
+
+func (b *Budget) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+	if err := b.reserve(ctx); err != nil {
+		return nil, err
+	}
+	return b.options.WeatherRepository.WeatherToday(ctx, params)
+}
+
+func (b *Budget) WeatherForecast(ctx context.Context, params models.ForecastParams) (*models.ForecastResult, error) {
+	if err := b.reserve(ctx); err != nil {
+		return nil, err
+	}
+	return b.options.WeatherRepository.WeatherForecast(ctx, params)
+}
+
+// reserve учитывает запрос к провайдеру. Сначала запрос списывается с клиента,
+// если он уже сделал другие запросы к провайдеру в том же запросе к API.
+// Обычным запросам недоступен запас для плановых задач. Если счетчик
+// недоступен, запрос пропускается.
+func (b *Budget) reserve(ctx context.Context) error {
+	if err := models.ChargeUpstreamCall(ctx); err != nil {
+		return err
+	}
+	if b.options.DailyLimit <= 0 {
+		return nil
+	}
+
+	limit, priority := b.options.DailyLimit-b.options.Reserved, "interactive"
+	if models.IsScheduledPriority(ctx) {
+		limit, priority = b.options.DailyLimit, "scheduled"
+	}
+
+	reserved, used, err := b.options.Counter.ReserveUpstreamCall(ctx, b.options.Provider, time.Now(), limit)
+	if err != nil {
+		slog.WarnContext(ctx, "upstream budget counter failed", "provider", b.options.Provider, "error", err)
+		return nil
+	}
+	b.observe(used)
+
+	if !reserved {
+		if b.options.Metrics != nil {
+			b.options.Metrics.UpstreamBudgetDenied.WithLabelValues(b.options.Provider, priority).Inc()
+		}
+		return fmt.Errorf("provider %s: %w", b.options.Provider, models.ErrUpstreamBudgetExhausted)
+	}
+	return nil
+}
+
+// StaleOnly сообщает, что лимит почти исчерпан. Плановым задачам режим не мешает,
+// для них держится запас лимита.
+func (b *Budget) StaleOnly(ctx context.Context) bool {
+	if b.options.DailyLimit <= 0 || models.IsScheduledPriority(ctx) {
+		return false
+	}
+
+	// Счетчик перечитывается одним запросом, остальные не ждут и видят прошлое значение
+	b.mu.Lock()
+	refresh := time.Since(b.refreshedAt) >= refreshInterval
+	if refresh {
+		b.refreshedAt = time.Now()
+	}
+	b.mu.Unlock()
+
+	if refresh {
+		used, err := b.options.Counter.UpstreamCalls(ctx, b.options.Provider, time.Now())
+		if err != nil {
+			slog.WarnContext(ctx, "upstream budget counter failed", "provider", b.options.Provider, "error", err)
+		} else {
+			b.observe(used)
+		}
+	}
+	return b.staleOnly.Load()
+}
+
+// observe обновляет режим и метрики по числу запросов за день
+func (b *Budget) observe(used int64) {
+	staleOnly := used >= b.threshold
+	if b.staleOnly.Swap(staleOnly) != staleOnly {
+		if staleOnly {
+			slog.Warn("upstream budget nearly exhausted, serving cached weather only",
+				"provider", b.options.Provider, "used", used, "limit", b.options.DailyLimit)
+		} else {
+			slog.Info("upstream budget available again", "provider", b.options.Provider, "used", used)
+		}
+	}
+
+	if b.options.Metrics != nil {
+		b.options.Metrics.UpstreamBudgetUsed.WithLabelValues(b.options.Provider).Set(float64(used))
+		mode := 0.0
+		if staleOnly {
+			mode = 1
+		}
+		b.options.Metrics.UpstreamStaleOnly.WithLabelValues(b.options.Provider).Set(mode)
+	}
+}
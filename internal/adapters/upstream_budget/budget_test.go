@@ -0,0 +1,171 @@
+package upstream_budget
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"weather-api/internal/models"
+)
+
+// fakeCounter счетчик запросов за день в памяти
+type fakeCounter struct {
+	used   int64
+	err    error
+	limits []int64
+}
+
+func (f *fakeCounter) ReserveUpstreamCall(ctx context.Context, provider string, day time.Time, limit int64) (bool, int64, error) {
+	f.limits = append(f.limits, limit)
+	if f.err != nil {
+		return false, 0, f.err
+	}
+	if f.used >= limit {
+		return false, f.used, nil
+	}
+	f.used++
+	return true, f.used, nil
+}
+
+func (f *fakeCounter) UpstreamCalls(ctx context.Context, provider string, day time.Time) (int64, error) {
+	return f.used, f.err
+}
+
+// fakeWeatherRepository считает запросы, дошедшие до провайдера
+type fakeWeatherRepository struct {
+	calls int
+}
+
+func (f *fakeWeatherRepository) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+	f.calls++
+	return &models.WeatherResult{}, nil
+}
+
+func (f *fakeWeatherRepository) WeatherForecast(ctx context.Context, params models.ForecastParams) (*models.ForecastResult, error) {
+	f.calls++
+	return &models.ForecastResult{}, nil
+}
+
+func newTestBudget(counter *fakeCounter, dailyLimit, reserved int64, ratio float64) (*Budget, *fakeWeatherRepository) {
+	repo := &fakeWeatherRepository{}
+	return NewBudget(BudgetOptions{
+		Provider:          "test",
+		WeatherRepository: repo,
+		Counter:           counter,
+		DailyLimit:        dailyLimit,
+		Reserved:          reserved,
+		StaleOnlyRatio:    ratio,
+	}), repo
+}
+
+// TestBudgetThreshold проверяет порог режима только кэша: min(ratio×limit, limit−reserved)
+func TestBudgetThreshold(t *testing.T) {
+	tests := []struct {
+		name       string
+		dailyLimit int64
+		reserved   int64
+		ratio      float64
+		want       int64
+	}{
+		{name: "ratio below reserve", dailyLimit: 1000, reserved: 50, ratio: 0.9, want: 900},
+		{name: "reserve below ratio", dailyLimit: 1000, reserved: 200, ratio: 0.9, want: 800},
+		{name: "default ratio", dailyLimit: 1000, want: 900},
+		{name: "invalid ratio uses default", dailyLimit: 1000, ratio: 1.5, want: 900},
+		{name: "full ratio", dailyLimit: 1000, ratio: 1, want: 1000},
+		{name: "reserve larger than limit is ignored", dailyLimit: 100, reserved: 500, ratio: 1, want: 100},
+		{name: "negative reserve is ignored", dailyLimit: 100, reserved: -5, ratio: 1, want: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBudget(&fakeCounter{}, tt.dailyLimit, tt.reserved, tt.ratio)
+			if b.threshold != tt.want {
+				t.Errorf("threshold = %d, want %d", b.threshold, tt.want)
+			}
+		})
+	}
+}
+
+// TestBudgetReserve проверяет, что обычным запросам недоступен запас,
+// а плановые задачи могут его тратить
+func TestBudgetReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		used      int64
+		scheduled bool
+		wantLimit int64
+		wantErr   bool
+	}{
+		{name: "interactive below limit", used: 10, wantLimit: 80},
+		{name: "interactive hits reserve", used: 80, wantLimit: 80, wantErr: true},
+		{name: "scheduled uses reserve", used: 80, scheduled: true, wantLimit: 100},
+		{name: "scheduled hits daily limit", used: 100, scheduled: true, wantLimit: 100, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter := &fakeCounter{used: tt.used}
+			b, repo := newTestBudget(counter, 100, 20, 0.9)
+			ctx := context.Background()
+			if tt.scheduled {
+				ctx = models.WithScheduledPriority(ctx)
+			}
+
+			_, err := b.WeatherToday(ctx, models.WeatherTodayParams{})
+
+			if len(counter.limits) != 1 || counter.limits[0] != tt.wantLimit {
+				t.Errorf("limits = %v, want [%d]", counter.limits, tt.wantLimit)
+			}
+			if tt.wantErr {
+				if !errors.Is(err, models.ErrUpstreamBudgetExhausted) {
+					t.Fatalf("error = %v, want %v", err, models.ErrUpstreamBudgetExhausted)
+				}
+				if repo.calls != 0 {
+					t.Errorf("provider called %d times after budget exhausted", repo.calls)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if repo.calls != 1 {
+				t.Errorf("provider called %d times, want 1", repo.calls)
+			}
+		})
+	}
+}
+
+// TestBudgetCounterUnavailable проверяет, что без счетчика запросы не блокируются
+func TestBudgetCounterUnavailable(t *testing.T) {
+	b, repo := newTestBudget(&fakeCounter{err: errors.New("redis is down")}, 100, 20, 0.9)
+	if _, err := b.WeatherForecast(context.Background(), models.ForecastParams{}); err != nil {
+		t.Fatal(err)
+	}
+	if repo.calls != 1 {
+		t.Errorf("provider called %d times, want 1", repo.calls)
+	}
+}
+
+// TestBudgetStaleOnly проверяет включение режима только кэша для обычных запросов
+func TestBudgetStaleOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		used      int64
+		scheduled bool
+		want      bool
+	}{
+		{name: "below threshold", used: 79, want: false},
+		{name: "at threshold", used: 80, want: true},
+		{name: "scheduled ignores mode", used: 95, scheduled: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, _ := newTestBudget(&fakeCounter{used: tt.used}, 100, 20, 0.9)
+			ctx := context.Background()
+			if tt.scheduled {
+				ctx = models.WithScheduledPriority(ctx)
+			}
+			if got := b.StaleOnly(ctx); got != tt.want {
+				t.Errorf("StaleOnly = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
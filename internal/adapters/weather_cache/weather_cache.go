@@ -3,39 +3,77 @@ package weather_cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 	"weather-api/internal/adapters/redis"
 	"weather-api/internal/models"
 	"weather-api/internal/repository"
 	"weather-api/pkg/metrics"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+var tracer = otel.Tracer("weather-api/internal/adapters/weather_cache")
+
 // Проверка, что тип реализует интерфейс
 var _ repository.WeatherRepository = (*WeatherCache)(nil)
+var _ repository.WeatherCacheInvalidator = (*WeatherCache)(nil)
+var _ repository.WeatherBatchCache = (*WeatherCache)(nil)
+var _ repository.WeatherCacheTTL = (*WeatherCache)(nil)
+
+const (
+	// maxForecastDays максимальная длина прогноза Open-Meteo в днях
+	maxForecastDays = 16
+	// staleTTL сколько хранится копия данных для режима экономии лимита провайдера
+	staleTTL = 24 * time.Hour
+)
+
+func weatherCacheKey(lat, lon float64) string {
+	return fmt.Sprintf("weather:lat:%f:lon:%f", lat, lon)
+}
+
+func forecastCacheKey(lat, lon float64, days int) string {
+	return fmt.Sprintf("forecast:lat:%f:lon:%f:days:%d", lat, lon, days)
+}
+
+// staleCacheKey копия записи, которая живет дольше самой записи
+func staleCacheKey(key string) string {
+	return "stale:" + key
+}
 
-// WeatherCache - кэширующий прокси для погодных данных
+// WeatherCache - кэширующий прокси для погодных данных.
+// Когда лимит запросов к провайдеру почти исчерпан, отдает только сохраненные
+// данные, в том числе устаревшие, и не ходит к провайдеру.
 type WeatherCache struct {
 	redisClient *redis.Client
 	weatherRepo repository.WeatherRepository
 	metrics     *metrics.Metrics
+	budget      repository.UpstreamBudget
 }
 
-// NewWeatherCache создает новый кэш для погоды
-func NewWeatherCache(redisClient *redis.Client, weatherRepo repository.WeatherRepository, metrics *metrics.Metrics) *WeatherCache {
+// NewWeatherCache создает новый кэш для погоды. budget необязателен.
+func NewWeatherCache(redisClient *redis.Client, weatherRepo repository.WeatherRepository, metrics *metrics.Metrics, budget repository.UpstreamBudget) *WeatherCache {
 	return &WeatherCache{
 		redisClient: redisClient,
 		weatherRepo: weatherRepo,
 		metrics:     metrics,
+		budget:      budget,
 	}
 }
 
 // WeatherToday получает погоду на сегодня с кэшированием
-func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (_ *models.WeatherResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherCache.WeatherToday")
+	defer func() { tracing.End(span, err) }()
+
 	start := time.Now()
 
 	// 1. Проверяем кэш
-	cacheKey := fmt.Sprintf("weather:lat:%f:lon:%f", params.Lat, params.Lon)
+	cacheKey := weatherCacheKey(params.Lat, params.Lon)
 	cachedData, err := c.redisClient.Get(ctx, cacheKey)
 
 	// Если нашли в кэше - возвращаем
@@ -46,6 +84,7 @@ func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTo
 			if c.metrics != nil {
 				c.metrics.CacheHits.WithLabelValues("weather").Inc()
 			}
+			span.SetAttributes(cacheResult("hit"))
 			return &result, nil
 		}
 	}
@@ -55,7 +94,17 @@ func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTo
 		c.metrics.CacheMisses.WithLabelValues("weather").Inc()
 	}
 
+	// Лимит провайдера почти исчерпан: только устаревшие данные
+	if c.staleOnly(ctx) {
+		var result models.WeatherResult
+		if c.loadStale(ctx, cacheKey, "weather", &result) {
+			return &result, nil
+		}
+		return nil, models.ErrUpstreamBudgetExhausted
+	}
+
 	// Если нет в кэше - идем в API через оригинальный репозиторий
+	span.SetAttributes(cacheResult("miss"))
 	apiStart := time.Now()
 	result, err := c.weatherRepo.WeatherToday(ctx, params)
 	apiDuration := time.Since(apiStart).Seconds()
@@ -71,13 +120,17 @@ func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTo
 	}
 
 	if err != nil {
+		var stale models.WeatherResult
+		if errors.Is(err, models.ErrUpstreamBudgetExhausted) && c.loadStale(ctx, cacheKey, "weather", &stale) {
+			return &stale, nil
+		}
 		return nil, err
 	}
 
 	// Сохраняем в Redis
 	resultJSON, err := json.Marshal(result)
 	if err == nil {
-		_ = c.redisClient.Set(ctx, cacheKey, resultJSON)
+		c.save(ctx, cacheKey, resultJSON)
 	}
 
 	// Общее время выполнения метода
@@ -87,3 +140,172 @@ func (c *WeatherCache) WeatherToday(ctx context.Context, params models.WeatherTo
 
 	return result, nil
 }
+
+// WeatherForecast получает прогноз погоды с кэшированием
+func (c *WeatherCache) WeatherForecast(ctx context.Context, params models.ForecastParams) (_ *models.ForecastResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherCache.WeatherForecast")
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+
+	// 1. Проверяем кэш
+	cacheKey := forecastCacheKey(params.Lat, params.Lon, params.Days)
+	cachedData, err := c.redisClient.Get(ctx, cacheKey)
+
+	// Если нашли в кэше - возвращаем
+	if err == nil {
+		var result models.ForecastResult
+		if err := json.Unmarshal([]byte(cachedData), &result); err == nil {
+			if c.metrics != nil {
+				c.metrics.CacheHits.WithLabelValues("forecast").Inc()
+			}
+			span.SetAttributes(cacheResult("hit"))
+			return &result, nil
+		}
+	}
+
+	if c.metrics != nil {
+		c.metrics.CacheMisses.WithLabelValues("forecast").Inc()
+	}
+
+	// Лимит провайдера почти исчерпан: только устаревшие данные
+	if c.staleOnly(ctx) {
+		var result models.ForecastResult
+		if c.loadStale(ctx, cacheKey, "forecast", &result) {
+			return &result, nil
+		}
+		return nil, models.ErrUpstreamBudgetExhausted
+	}
+
+	// Если нет в кэше - идем в API через оригинальный репозиторий
+	span.SetAttributes(cacheResult("miss"))
+	apiStart := time.Now()
+	result, err := c.weatherRepo.WeatherForecast(ctx, params)
+	apiDuration := time.Since(apiStart).Seconds()
+
+	if c.metrics != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		c.metrics.WeatherRequestsTotal.WithLabelValues(status).Inc()
+		c.metrics.WeatherRequestDuration.WithLabelValues().Observe(apiDuration)
+	}
+
+	if err != nil {
+		var stale models.ForecastResult
+		if errors.Is(err, models.ErrUpstreamBudgetExhausted) && c.loadStale(ctx, cacheKey, "forecast", &stale) {
+			return &stale, nil
+		}
+		return nil, err
+	}
+
+	// Сохраняем в Redis
+	resultJSON, err := json.Marshal(result)
+	if err == nil {
+		c.save(ctx, cacheKey, resultJSON)
+	}
+
+	if c.metrics != nil {
+		c.metrics.HttpRequestDuration.WithLabelValues("WeatherForecast", "internal").Observe(time.Since(start).Seconds())
+	}
+
+	return result, nil
+}
+
+// cacheResult атрибут span: hit, stale или miss
+func cacheResult(result string) attribute.KeyValue {
+	return attribute.String("cache.result", result)
+}
+
+// staleOnly проверяет, что к провайдеру ходить нельзя
+func (c *WeatherCache) staleOnly(ctx context.Context) bool {
+	return c.budget != nil && c.budget.StaleOnly(ctx)
+}
+
+// save сохраняет запись и ее долгоживущую копию для режима экономии лимита
+func (c *WeatherCache) save(ctx context.Context, key string, data []byte) {
+	_ = c.redisClient.Set(ctx, key, data)
+	if c.budget != nil {
+		_ = c.redisClient.SetWithTTL(ctx, staleCacheKey(key), data, staleTTL)
+	}
+}
+
+// loadStale читает устаревшую копию записи
+func (c *WeatherCache) loadStale(ctx context.Context, key, kind string, dest any) bool {
+	data, err := c.redisClient.Get(ctx, staleCacheKey(key))
+	if err != nil || json.Unmarshal([]byte(data), dest) != nil {
+		return false
+	}
+	if c.metrics != nil {
+		c.metrics.CacheHits.WithLabelValues(kind + "_stale").Inc()
+	}
+	trace.SpanFromContext(ctx).SetAttributes(cacheResult("stale"))
+	return true
+}
+
+// InvalidateLocation удаляет из кэша текущую погоду и прогнозы для координат
+func (c *WeatherCache) InvalidateLocation(ctx context.Context, lat, lon float64) (err error) {
+	ctx, span := tracer.Start(ctx, "WeatherCache.InvalidateLocation")
+	defer func() { tracing.End(span, err) }()
+
+	keys := []string{weatherCacheKey(lat, lon)}
+	for days := 1; days <= maxForecastDays; days++ {
+		keys = append(keys, forecastCacheKey(lat, lon, days))
+	}
+	for _, key := range keys[:len(keys):len(keys)] {
+		keys = append(keys, staleCacheKey(key))
+	}
+	return c.redisClient.Del(ctx, keys...)
+}
+
+// CachedWeatherToday читает погоду для нескольких точек одним запросом MGET.
+// В API погоды не ходит: для точек без записи в кэше возвращается nil.
+func (c *WeatherCache) CachedWeatherToday(ctx context.Context, params []models.WeatherTodayParams) (_ []*models.WeatherResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherCache.CachedWeatherToday")
+	defer func() { tracing.End(span, err) }()
+
+	results := make([]*models.WeatherResult, len(params))
+	if len(params) == 0 {
+		return results, nil
+	}
+
+	keys := make([]string, len(params))
+	for i, p := range params {
+		keys[i] = weatherCacheKey(p.Lat, p.Lon)
+	}
+	cached, err := c.redisClient.MGet(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, key := range keys {
+		data, ok := cached[key]
+		if !ok {
+			continue
+		}
+		var result models.WeatherResult
+		if err := json.Unmarshal([]byte(data), &result); err != nil {
+			continue
+		}
+		results[i] = &result
+		if c.metrics != nil {
+			c.metrics.CacheHits.WithLabelValues("weather").Inc()
+		}
+	}
+
+	return results, nil
+}
+
+// WeatherTodayTTL возвращает оставшееся время жизни погоды для координат в кэше
+func (c *WeatherCache) WeatherTodayTTL(ctx context.Context, params models.WeatherTodayParams) (_ time.Duration, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherCache.WeatherTodayTTL")
+	defer func() { tracing.End(span, err) }()
+
+	ttl, err := c.redisClient.TTL(ctx, weatherCacheKey(params.Lat, params.Lon))
+	if err != nil {
+		return 0, err
+	}
+	// Redis возвращает отрицательные значения для отсутствующих ключей и ключей без срока
+	return max(ttl, 0), nil
+}
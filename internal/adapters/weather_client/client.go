@@ -10,6 +10,12 @@ import (
 	"time"
 	"weather-api/internal/models"
 	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var _ repository.WeatherRepository = (*Client)(nil)
@@ -18,8 +24,11 @@ var (
 	ErrStatusWeatherAPI = fmt.Errorf("error response from weather api")
 )
 
+var tracer = otel.Tracer("weather-api/internal/adapters/weather_client")
+
 type Client struct {
-	options ClientOptions
+	options    ClientOptions
+	httpClient *http.Client
 }
 
 type ClientOptions struct {
@@ -30,50 +39,207 @@ type ClientOptions struct {
 func NewClient(options ClientOptions) *Client {
 	return &Client{
 		options: options,
+		// Транспорт передает провайдеру контекст трассы в заголовке traceparent
+		httpClient: &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		},
 	}
 }
 
-func (c *Client) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+func (c *Client) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (_ *models.WeatherResult, err error) {
+	ctx, span := tracer.Start(ctx, "weather_client.WeatherToday", trace.WithAttributes(
+		attribute.Float64("weather.lat", params.Lat),
+		attribute.Float64("weather.lon", params.Lon),
+	))
+	defer func() { tracing.End(span, err) }()
+
 	url := c.options.URL + fmt.Sprintf("/v1/forecast?latitude=%f&longitude=%f&current_weather=true", params.Lat, params.Lon)
 
 	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		slog.Error("failed to create request", "err", err)
+		slog.ErrorContext(ctx, "failed to create request", "err", err)
 		err = fmt.Errorf("http.NewRequestWithContext(...): %w", err)
 		return nil, err
 	}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	rsp, err := client.Do(request)
+	rsp, err := c.httpClient.Do(request)
 	if err != nil {
-		slog.Error("failed to perform request", "err", err)
-		err = fmt.Errorf("http.Do(...): %w", err)
+		slog.ErrorContext(ctx, "failed to perform request", "err", err)
+		err = fmt.Errorf("http.Do(...): %w: %w", models.ErrUpstreamUnavailable, err)
 		return nil, err
 	}
 	defer rsp.Body.Close()
 
 	body, err := io.ReadAll(rsp.Body)
 	if err != nil {
-		slog.Error("failed to read response body", "err", err)
-		err = fmt.Errorf("io.ReadAll(...): %w", err)
+		slog.ErrorContext(ctx, "failed to read response body", "err", err)
+		err = fmt.Errorf("io.ReadAll(...): %w: %w", models.ErrUpstreamUnavailable, err)
 		return nil, err
 	}
 
 	if rsp.StatusCode != http.StatusOK {
-		slog.Error("weather api returned non-OK status", "status", rsp.StatusCode, "body", string(body))
-		return nil, fmt.Errorf("%w: %s", ErrStatusWeatherAPI, body)
+		slog.ErrorContext(ctx, "weather api returned non-OK status", "status", rsp.StatusCode, "body", string(body))
+		return nil, statusError(rsp.StatusCode, body)
 	}
 
 	var result models.WeatherResult
 	err = json.Unmarshal(body, &result)
 	if err != nil {
-		slog.Error("failed to unmarshal response", "err", err)
-		err = fmt.Errorf("json.Unmarshal(...): %w", err)
+		slog.ErrorContext(ctx, "failed to unmarshal response", "err", err)
+		err = fmt.Errorf("json.Unmarshal(...): %w: %w", models.ErrUpstreamBadResponse, err)
 		return nil, err
 	}
 
 	return &result, nil
 }
+
+// statusError переводит код ответа API погоды в ошибку предметной области.
+// Ошибка 400 означает, что API отверг параметры запроса, например координаты.
+func statusError(statusCode int, body []byte) error {
+	var domainErr error
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		domainErr = models.ErrRateLimited
+	case statusCode == http.StatusBadRequest:
+		domainErr = models.ErrInvalidInput
+	default:
+		domainErr = models.ErrUpstreamUnavailable
+	}
+	return fmt.Errorf("%w: %w: status %d: %s", domainErr, ErrStatusWeatherAPI, statusCode, body)
+}
+
+// forecastResponse ответ Open-Meteo для прогноза по часам и по дням
+type forecastResponse struct {
+	Timezone         string `json:"timezone"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+	Hourly           struct {
+		Time        []string  `json:"time"`
+		Temperature []float64 `json:"temperature_2m"`
+		WeatherCode []int     `json:"weathercode"`
+	} `json:"hourly"`
+	Daily struct {
+		Time           []string  `json:"time"`
+		WeatherCode    []int     `json:"weathercode"`
+		TemperatureMax []float64 `json:"temperature_2m_max"`
+		TemperatureMin []float64 `json:"temperature_2m_min"`
+		Precipitation  []float64 `json:"precipitation_sum"`
+	} `json:"daily"`
+}
+
+func (c *Client) WeatherForecast(ctx context.Context, params models.ForecastParams) (_ *models.ForecastResult, err error) {
+	ctx, span := tracer.Start(ctx, "weather_client.WeatherForecast", trace.WithAttributes(
+		attribute.Float64("weather.lat", params.Lat),
+		attribute.Float64("weather.lon", params.Lon),
+		attribute.Int("weather.days", params.Days),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	url := c.options.URL + fmt.Sprintf(
+		"/v1/forecast?latitude=%f&longitude=%f&hourly=temperature_2m,weathercode"+
+			"&daily=weathercode,temperature_2m_max,temperature_2m_min,precipitation_sum&timezone=auto&forecast_days=%d",
+		params.Lat, params.Lon, params.Days,
+	)
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create request", "err", err)
+		err = fmt.Errorf("http.NewRequestWithContext(...): %w", err)
+		return nil, err
+	}
+
+	rsp, err := c.httpClient.Do(request)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to perform request", "err", err)
+		err = fmt.Errorf("http.Do(...): %w: %w", models.ErrUpstreamUnavailable, err)
+		return nil, err
+	}
+	defer rsp.Body.Close()
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to read response body", "err", err)
+		err = fmt.Errorf("io.ReadAll(...): %w: %w", models.ErrUpstreamUnavailable, err)
+		return nil, err
+	}
+
+	if rsp.StatusCode != http.StatusOK {
+		slog.ErrorContext(ctx, "weather api returned non-OK status", "status", rsp.StatusCode, "body", string(body))
+		return nil, statusError(rsp.StatusCode, body)
+	}
+
+	var response forecastResponse
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to unmarshal response", "err", err)
+		err = fmt.Errorf("json.Unmarshal(...): %w: %w", models.ErrUpstreamBadResponse, err)
+		return nil, err
+	}
+
+	return response.toModel()
+}
+
+// toModel переводит колонки Open-Meteo в список записей прогноза
+func (r *forecastResponse) toModel() (*models.ForecastResult, error) {
+	location := time.FixedZone(r.Timezone, r.UTCOffsetSeconds)
+	result := &models.ForecastResult{
+		Timezone:         r.Timezone,
+		UTCOffsetSeconds: r.UTCOffsetSeconds,
+	}
+
+	for i, value := range r.Hourly.Time {
+		if i >= len(r.Hourly.Temperature) || i >= len(r.Hourly.WeatherCode) {
+			break
+		}
+		t, err := time.ParseInLocation("2006-01-02T15:04", value, location)
+		if err != nil {
+			return nil, fmt.Errorf("time.ParseInLocation(...): %w: %w", models.ErrUpstreamBadResponse, err)
+		}
+		result.Hourly = append(result.Hourly, models.HourlyForecast{
+			Time:        t,
+			Temperature: r.Hourly.Temperature[i],
+			WeatherCode: r.Hourly.WeatherCode[i],
+		})
+	}
+
+	for i, value := range r.Daily.Time {
+		if i >= len(r.Daily.WeatherCode) || i >= len(r.Daily.TemperatureMax) ||
+			i >= len(r.Daily.TemperatureMin) || i >= len(r.Daily.Precipitation) {
+			break
+		}
+		date, err := time.ParseInLocation("2006-01-02", value, location)
+		if err != nil {
+			return nil, fmt.Errorf("time.ParseInLocation(...): %w: %w", models.ErrUpstreamBadResponse, err)
+		}
+		result.Daily = append(result.Daily, models.DailyForecast{
+			Date:           date,
+			TemperatureMax: r.Daily.TemperatureMax[i],
+			TemperatureMin: r.Daily.TemperatureMin[i],
+			Precipitation:  r.Daily.Precipitation[i],
+			WeatherCode:    r.Daily.WeatherCode[i],
+		})
+	}
+
+	return result, nil
+}
+
+// Probe проверяет, что API погоды доступен. Запрос идет к корню сервиса, а не
+// к прогнозу, чтобы проверки не расходовали суточный лимит провайдера.
+func (c *Client) Probe(ctx context.Context) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodHead, c.options.URL+"/", nil)
+	if err != nil {
+		return fmt.Errorf("http.NewRequestWithContext(...): %w", err)
+	}
+
+	rsp, err := c.httpClient.Do(request)
+	if err != nil {
+		return fmt.Errorf("http.Do(...): %w: %w", models.ErrUpstreamUnavailable, err)
+	}
+	rsp.Body.Close()
+
+	// Любой ответ кроме ошибки сервера значит, что провайдер отвечает
+	if rsp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("%w: status %d", ErrStatusWeatherAPI, rsp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// maxAdminBodyBytes ограничивает размер тела запросов администраторов
+const maxAdminBodyBytes = 16 << 10
+
+// IssueAPIKey выпускает ключ доступа. Ключ целиком есть только в этом ответе.
+func (c *WeatherController) IssueAPIKey(w http.ResponseWriter, r *http.Request) {
+	var request dto.IssueAPIKeyRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes)).Decode(&request); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+
+	// Ключ виден только в ответе, поэтому формат проверяем до выпуска
+	if !checkAcceptable[dto.APIKey](w, r) {
+		return
+	}
+
+	key, raw, err := c.apiKeyUseCase.Issue(r.Context(), request)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to issue api key", "owner", request.Owner, "error", err)
+		writeError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "api key issued", "key_id", key.ID, "owner", key.Owner, "scopes", key.Scopes)
+
+	response := toAPIKey(key)
+	response.Key = raw
+	writeResponseStatus(w, r, http.StatusCreated, response)
+}
+
+// ListAPIKeys отдает все ключи без секретов
+func (c *WeatherController) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := c.apiKeyUseCase.List(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to list api keys", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	response := make([]dto.APIKey, 0, len(keys))
+	for i := range keys {
+		response = append(response, toAPIKey(&keys[i]))
+	}
+	writeResponse(w, r, response)
+}
+
+// RevokeAPIKey отзывает ключ доступа
+func (c *WeatherController) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	if err := c.apiKeyUseCase.Revoke(r.Context(), id); err != nil {
+		slog.ErrorContext(r.Context(), "Failed to revoke api key", "key_id", id, "error", err)
+		writeError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "api key revoked", "key_id", id)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func toAPIKey(key *models.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID,
+		Owner:      key.Owner,
+		Scopes:     key.Scopes,
+		DailyQuota: key.DailyQuota,
+		CreatedAt:  key.CreatedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"weather-api/internal/dto"
+)
+
+// maxBatchBodyBytes ограничивает размер тела пакетного запроса
+const maxBatchBodyBytes = 64 << 10
+
+// PostWeatherBatchV2 получает погоду для нескольких точек в формате API v2
+func (c *WeatherController) PostWeatherBatchV2(w http.ResponseWriter, r *http.Request) {
+	results, ok := c.weatherBatch(w, r)
+	if !ok {
+		return
+	}
+
+	items := make([]dto.WeatherBatchItemV2, 0, len(results))
+	for _, result := range results {
+		item := dto.WeatherBatchItemV2{WeatherBatchItem: result.Item}
+		if result.Err != nil {
+			problem := problemFor(r, result.Err)
+			item.Error = &problem
+		} else {
+			weather := toWeatherResultV2(result.Report)
+			item.Weather = &weather
+		}
+		items = append(items, item)
+	}
+
+	writeV2(w, r, items)
+}
+
+// weatherBatch разбирает тело запроса и получает погоду. При ошибке пакета
+// целиком сам отправляет ответ и возвращает false.
+func (c *WeatherController) weatherBatch(w http.ResponseWriter, r *http.Request) ([]dto.WeatherBatchResult, bool) {
+	var request dto.WeatherBatchRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBatchBodyBytes)).Decode(&request); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return nil, false
+	}
+
+	results, err := c.weatherUseCase.GetWeatherBatch(r.Context(), request.Items)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get weather batch", "items", len(request.Items), "error", err)
+		writeError(w, r, err)
+		return nil, false
+	}
+
+	return results, true
+}
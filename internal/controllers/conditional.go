@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"weather-api/internal/middleware"
+)
+
+// writeCached отправляет ответ в согласованном с клиентом формате с заголовками
+// для кэширования на клиенте: ETag по хэшу тела, Last-Modified по времени наблюдения и Cache-Control по
+// оставшемуся сроку жизни записи в Redis (private для запросов с API ключом).
+// На совпавшие If-None-Match и If-Modified-Since отвечает 304, иначе всегда 200
+// с полным телом: частичные ответы по Range не поддерживаются.
+func writeCached(w http.ResponseWriter, r *http.Request, v any, lastModified time.Time, maxAge time.Duration) {
+	body, contentType, ok := encodeResponse(w, r, v)
+	if !ok {
+		return
+	}
+
+	// Ответ на запрос с ключом не должен попадать в общие кэши: иначе прокси
+	// отдаст его без проверки ключа, квоты и лимита запросов
+	visibility := "public"
+	if _, ok := middleware.APIKeyFromContext(r.Context()); ok {
+		visibility = "private"
+	}
+
+	etag := cacheETag(visibility, body)
+	w.Header().Set("ETag", etag)
+	if !lastModified.IsZero() {
+		w.Header().Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if maxAge > 0 {
+		w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, int(maxAge.Seconds())))
+	} else {
+		w.Header().Set("Cache-Control", "no-cache")
+	}
+
+	if notModified(r, etag, lastModified) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body)
+	}
+}
+
+// cacheETag считает ETag по телу ответа и его видимости. Одинаковое тело
+// публичного и приватного ответа получает разные ETag, чтобы общий кэш не
+// подтвердил свою публичную копию по валидатору приватного ответа.
+func cacheETag(visibility string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(visibility))
+	h.Write([]byte{0})
+	h.Write(body)
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// notModified проверяет условные заголовки запроса GET или HEAD.
+// If-None-Match сравнивается со слабым сравнением и, если он есть,
+// If-Modified-Since не учитывается.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || lastModified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	// В заголовке время с точностью до секунды
+	return !lastModified.Truncate(time.Second).After(t)
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"weather-api/internal/dto"
+)
+
+// TestWriteCachedConditional проверяет ответы 200 и 304 на условные запросы
+func TestWriteCachedConditional(t *testing.T) {
+	lastModified := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	value := dto.ResponseV2[dto.WeatherResultV2]{}
+
+	// ETag ответа узнаем из безусловного запроса
+	probe := httptest.NewRecorder()
+	writeCached(probe, httptest.NewRequest(http.MethodGet, "/api/v2/weather", nil), value, lastModified, time.Minute)
+	etag := probe.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("ETag is not set")
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    int
+	}{
+		{name: "unconditional", want: http.StatusOK},
+		{name: "matching etag", headers: map[string]string{"If-None-Match": etag}, want: http.StatusNotModified},
+		{name: "weak matching etag", headers: map[string]string{"If-None-Match": `"other", W/` + etag}, want: http.StatusNotModified},
+		{name: "any etag", headers: map[string]string{"If-None-Match": "*"}, want: http.StatusNotModified},
+		{name: "other etag", headers: map[string]string{"If-None-Match": `"other"`}, want: http.StatusOK},
+		{name: "etag wins over date", headers: map[string]string{"If-None-Match": `"other"`, "If-Modified-Since": lastModified.Format(http.TimeFormat)}, want: http.StatusOK},
+		{name: "not modified since", headers: map[string]string{"If-Modified-Since": lastModified.Format(http.TimeFormat)}, want: http.StatusNotModified},
+		{name: "modified since", headers: map[string]string{"If-Modified-Since": lastModified.Add(-time.Second).Format(http.TimeFormat)}, want: http.StatusOK},
+		{name: "invalid date", headers: map[string]string{"If-Modified-Since": "yesterday"}, want: http.StatusOK},
+		{name: "range is ignored", headers: map[string]string{"Range": "bytes=0-1"}, want: http.StatusOK},
+		{name: "head", method: http.MethodHead, want: http.StatusOK},
+		{name: "head matching etag", method: http.MethodHead, headers: map[string]string{"If-None-Match": etag}, want: http.StatusNotModified},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/api/v2/weather", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+
+			writeCached(w, r, value, lastModified, time.Minute)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Errorf("ETag = %q, want %q", got, etag)
+			}
+			if got := w.Header().Get("Cache-Control"); got != "public, max-age=60" {
+				t.Errorf("Cache-Control = %q", got)
+			}
+			if got := w.Header().Get("Last-Modified"); got != lastModified.Format(http.TimeFormat) {
+				t.Errorf("Last-Modified = %q", got)
+			}
+			if got := w.Header().Get("Accept-Ranges"); got != "" {
+				t.Errorf("Accept-Ranges = %q, want none", got)
+			}
+			wantBody := tt.want == http.StatusOK && method == http.MethodGet
+			if got := w.Body.Len() > 0; got != wantBody {
+				t.Errorf("body written = %v, want %v", got, wantBody)
+			}
+			if tt.want == http.StatusNotModified && w.Header().Get("Content-Type") != "" {
+				t.Errorf("Content-Type = %q on 304", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// TestCacheETagVisibility проверяет, что публичный и приватный ответ с одним
+// телом получают разные ETag
+func TestCacheETagVisibility(t *testing.T) {
+	body := []byte(`{"temperature":10}`)
+	public, private := cacheETag("public", body), cacheETag("private", body)
+	if public == private {
+		t.Errorf("public and private ETag are equal: %s", public)
+	}
+	if cacheETag("public", body) != public {
+		t.Error("ETag is not stable")
+	}
+}
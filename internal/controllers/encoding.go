@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// errUnsupportedType формат не умеет кодировать этот ответ
+var errUnsupportedType = errors.New("response type is not supported by the format")
+
+// encoder кодирует ответ в один из поддерживаемых форматов
+type encoder struct {
+	// format значение параметра format
+	format string
+	// mediaTypes типы для заголовка Accept, первый отправляется в Content-Type
+	mediaTypes []string
+	encode     func(v any) ([]byte, error)
+}
+
+func (e *encoder) contentType() string {
+	return e.mediaTypes[0]
+}
+
+// encoders реестр форматов ответа. Первый используется по умолчанию.
+var encoders = []*encoder{
+	{format: "json", mediaTypes: []string{"application/json"}, encode: encodeJSON},
+	{format: "xml", mediaTypes: []string{"application/xml", "text/xml"}, encode: encodeXML},
+	{format: "csv", mediaTypes: []string{"text/csv"}, encode: encodeCSV},
+	{format: "protobuf", mediaTypes: []string{"application/x-protobuf", "application/protobuf"}, encode: encodeProtobuf},
+}
+
+// negotiateEncoder выбирает формат по параметру format, а без него по заголовку Accept
+func negotiateEncoder(r *http.Request) (*encoder, bool) {
+	if format := r.URL.Query().Get("format"); format != "" {
+		for _, e := range encoders {
+			if e.format == format {
+				return e, true
+			}
+		}
+		return nil, false
+	}
+
+	accept := r.Header.Get("Accept")
+	if accept == "" {
+		return encoders[0], true
+	}
+	for _, mediaType := range parseAccept(accept) {
+		if mediaType == "*/*" || mediaType == "application/*" {
+			return encoders[0], true
+		}
+		for _, e := range encoders {
+			if slices.Contains(e.mediaTypes, mediaType) || mediaType == "text/*" && strings.HasPrefix(e.contentType(), "text/") {
+				return e, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// parseAccept возвращает типы из заголовка Accept по убыванию q, без q=0
+func parseAccept(accept string) []string {
+	type mediaRange struct {
+		mediaType string
+		q         float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(key) == "q" {
+				if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			ranges = append(ranges, mediaRange{mediaType, q})
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b mediaRange) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	mediaTypes := make([]string, len(ranges))
+	for i, r := range ranges {
+		mediaTypes[i] = r.mediaType
+	}
+	return mediaTypes
+}
+
+// encodeResponse кодирует ответ в согласованном с клиентом формате.
+// Если формат не поддерживается, сам отвечает 406 и возвращает false.
+func encodeResponse(w http.ResponseWriter, r *http.Request, v any) ([]byte, string, bool) {
+	w.Header().Add("Vary", "Accept")
+
+	e, ok := negotiateEncoder(r)
+	if !ok {
+		writeNotAcceptable(w, r)
+		return nil, "", false
+	}
+	body, err := e.encode(v)
+	if errors.Is(err, errUnsupportedType) {
+		writeNotAcceptable(w, r)
+		return nil, "", false
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return nil, "", false
+	}
+	return body, e.contentType(), true
+}
+
+// checkAcceptable проверяет до выполнения запроса, что ответ типа T можно отдать
+// в согласованном с клиентом формате. Нужна обработчикам с побочными эффектами:
+// иначе 406 придет уже после изменения данных. Если формат не подходит,
+// сама отвечает 406 и возвращает false.
+func checkAcceptable[T any](w http.ResponseWriter, r *http.Request) bool {
+	e, ok := negotiateEncoder(r)
+	if ok {
+		var zero T
+		_, err := e.encode(zero)
+		ok = !errors.Is(err, errUnsupportedType)
+	}
+	if !ok {
+		w.Header().Add("Vary", "Accept")
+		writeNotAcceptable(w, r)
+	}
+	return ok
+}
+
+// writeResponse отправляет ответ в согласованном с клиентом формате
+func writeResponse(w http.ResponseWriter, r *http.Request, v any) {
+	writeResponseStatus(w, r, http.StatusOK, v)
+}
+
+// writeResponseStatus отправляет ответ с заданным статусом в согласованном с клиентом формате
+func writeResponseStatus(w http.ResponseWriter, r *http.Request, status int, v any) {
+	body, contentType, ok := encodeResponse(w, r, v)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func writeNotAcceptable(w http.ResponseWriter, r *http.Request) {
+	formats := make([]string, len(encoders))
+	for i, e := range encoders {
+		formats[i] = e.contentType()
+	}
+	writeProblem(w, r, http.StatusNotAcceptable, "Not Acceptable",
+		"Supported response formats: "+strings.Join(formats, ", ")+".")
+}
+
+func encodeJSON(v any) ([]byte, error) {
+	var body bytes.Buffer
+	if err := json.NewEncoder(&body).Encode(v); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+// xmlItems корневой элемент для ответов-списков
+type xmlItems struct {
+	Items any `xml:"item"`
+}
+
+func encodeXML(v any) ([]byte, error) {
+	if reflect.ValueOf(v).Kind() == reflect.Slice {
+		v = xmlItems{Items: v}
+	}
+
+	var body bytes.Buffer
+	body.WriteString(xml.Header)
+	if err := xml.NewEncoder(&body).EncodeElement(v, xml.StartElement{Name: xml.Name{Local: "response"}}); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+func encodeProtobuf(v any) ([]byte, error) {
+	message, err := toProto(v)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(message)
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+)
+
+var (
+	cityCSVHeader      = []string{"name", "latitude", "longitude", "country", "timezone"}
+	weatherCSVHeader   = []string{"temperature", "weathercode", "weather_description"}
+	weatherV2CSVHeader = []string{"name", "country", "latitude", "longitude", "timezone", "observed_at",
+		"temperature", "wind_speed", "wind_direction", "weather_code", "weather_description"}
+	batchItemCSVHeader = []string{"city", "lat", "lon"}
+	errorCSVHeader     = []string{"error_status", "error_title"}
+)
+
+// encodeCSV кодирует ответ в CSV с заголовком. Вложенные структуры
+// разворачиваются в колонки, для пакета одна строка на точку.
+func encodeCSV(v any) ([]byte, error) {
+	var records [][]string
+	switch v := v.(type) {
+	case *dto.WeatherResult:
+		records = [][]string{weatherCSVHeader, weatherCSVRow(v)}
+	case []models.City:
+		records = [][]string{cityCSVHeader}
+		for _, city := range v {
+			records = append(records, []string{city.Name, formatFloat(city.Latitude), formatFloat(city.Longitude), city.Country, city.Timezone})
+		}
+	case dto.ResponseV2[dto.WeatherResultV2]:
+		records = [][]string{weatherV2CSVHeader, weatherV2CSVRow(&v.Data)}
+	case dto.ResponseV2[[]dto.CityV2]:
+		records = [][]string{cityCSVHeader}
+		for _, city := range v.Data {
+			records = append(records, []string{city.Name, formatFloat(city.Latitude), formatFloat(city.Longitude), city.Country, city.Timezone})
+		}
+	case dto.ResponseV2[[]dto.WeatherBatchItemV2]:
+		records = [][]string{concat(batchItemCSVHeader, weatherV2CSVHeader, errorCSVHeader)}
+		for _, item := range v.Data {
+			records = append(records, concat(batchItemCSVRow(item.WeatherBatchItem), weatherV2CSVRow(item.Weather), problemCSVRow(item.Error)))
+		}
+	default:
+		return nil, fmt.Errorf("%w: %T", errUnsupportedType, v)
+	}
+
+	var body bytes.Buffer
+	writer := csv.NewWriter(&body)
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return body.Bytes(), nil
+}
+
+func weatherCSVRow(weather *dto.WeatherResult) []string {
+	if weather == nil {
+		return make([]string, len(weatherCSVHeader))
+	}
+	current := weather.CurrentWeather
+	return []string{formatFloat(current.Temperature), strconv.Itoa(current.WeatherCode), current.WeatherDesc}
+}
+
+func weatherV2CSVRow(weather *dto.WeatherResultV2) []string {
+	if weather == nil {
+		return make([]string, len(weatherV2CSVHeader))
+	}
+	observedAt := ""
+	if weather.Current.ObservedAt != nil {
+		observedAt = weather.Current.ObservedAt.Format(time.RFC3339)
+	}
+	return []string{
+		weather.Location.Name,
+		weather.Location.Country,
+		formatFloat(weather.Location.Latitude),
+		formatFloat(weather.Location.Longitude),
+		weather.Location.Timezone,
+		observedAt,
+		formatFloat(weather.Current.Temperature),
+		formatFloat(weather.Current.WindSpeed),
+		formatFloat(weather.Current.WindDirection),
+		strconv.Itoa(weather.Current.WeatherCode),
+		weather.Current.WeatherDesc,
+	}
+}
+
+func batchItemCSVRow(item dto.WeatherBatchItem) []string {
+	row := []string{item.City, "", ""}
+	if item.Lat != nil {
+		row[1] = formatFloat(*item.Lat)
+	}
+	if item.Lon != nil {
+		row[2] = formatFloat(*item.Lon)
+	}
+	return row
+}
+
+func problemCSVRow(problem *dto.Problem) []string {
+	if problem == nil {
+		return make([]string, len(errorCSVHeader))
+	}
+	return []string{strconv.Itoa(problem.Status), problem.Title}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func concat(parts ...[]string) []string {
+	var result []string
+	for _, part := range parts {
+		result = append(result, part...)
+	}
+	return result
+}
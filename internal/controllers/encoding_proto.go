@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"fmt"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/pkg/pb/weatherv1"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// toProto переводит ответ в сообщение из proto/weather/v1/weather.proto
+func toProto(v any) (proto.Message, error) {
+	switch v := v.(type) {
+	case *dto.WeatherResult:
+		return weatherResultProto(v), nil
+	case []models.City:
+		list := &weatherv1.CityList{}
+		for _, city := range v {
+			list.Cities = append(list.Cities, cityProto(city.Name, city.Country, city.Latitude, city.Longitude, city.Timezone))
+		}
+		return list, nil
+	case dto.ResponseV2[dto.WeatherResultV2]:
+		return &weatherv1.WeatherResponseV2{ApiVersion: v.APIVersion, Data: weatherResultV2Proto(&v.Data)}, nil
+	case dto.ResponseV2[[]dto.CityV2]:
+		response := &weatherv1.CityListResponseV2{ApiVersion: v.APIVersion}
+		for _, city := range v.Data {
+			response.Data = append(response.Data, cityProto(city.Name, city.Country, city.Latitude, city.Longitude, city.Timezone))
+		}
+		return response, nil
+	case dto.ResponseV2[[]dto.WeatherBatchItemV2]:
+		response := &weatherv1.WeatherBatchResponseV2{ApiVersion: v.APIVersion}
+		for _, item := range v.Data {
+			response.Data = append(response.Data, &weatherv1.WeatherBatchResultV2{
+				Item:    batchItemProto(item.WeatherBatchItem),
+				Weather: weatherResultV2Proto(item.Weather),
+				Error:   problemProto(item.Error),
+			})
+		}
+		return response, nil
+	}
+	return nil, fmt.Errorf("%w: %T", errUnsupportedType, v)
+}
+
+func weatherResultProto(weather *dto.WeatherResult) *weatherv1.WeatherResult {
+	if weather == nil {
+		return nil
+	}
+	return &weatherv1.WeatherResult{
+		CurrentWeather: &weatherv1.CurrentWeather{
+			Temperature:        weather.CurrentWeather.Temperature,
+			WeatherCode:        int32(weather.CurrentWeather.WeatherCode),
+			WeatherDescription: weather.CurrentWeather.WeatherDesc,
+		},
+	}
+}
+
+func weatherResultV2Proto(weather *dto.WeatherResultV2) *weatherv1.WeatherResultV2 {
+	if weather == nil {
+		return nil
+	}
+	result := &weatherv1.WeatherResultV2{
+		Location: &weatherv1.Location{
+			Name:      weather.Location.Name,
+			Country:   weather.Location.Country,
+			Latitude:  weather.Location.Latitude,
+			Longitude: weather.Location.Longitude,
+			Timezone:  weather.Location.Timezone,
+		},
+		Units: &weatherv1.Units{
+			Temperature:   weather.Units.Temperature,
+			WindSpeed:     weather.Units.WindSpeed,
+			WindDirection: weather.Units.WindDirection,
+		},
+		Current: &weatherv1.CurrentWeatherV2{
+			Temperature:        weather.Current.Temperature,
+			WindSpeed:          weather.Current.WindSpeed,
+			WindDirection:      weather.Current.WindDirection,
+			WeatherCode:        int32(weather.Current.WeatherCode),
+			WeatherDescription: weather.Current.WeatherDesc,
+		},
+	}
+	if weather.Current.ObservedAt != nil {
+		result.Current.ObservedAt = timestamppb.New(*weather.Current.ObservedAt)
+	}
+	return result
+}
+
+func cityProto(name, country string, latitude, longitude float64, timezone string) *weatherv1.City {
+	return &weatherv1.City{
+		Name:      name,
+		Latitude:  latitude,
+		Longitude: longitude,
+		Country:   country,
+		Timezone:  timezone,
+	}
+}
+
+func batchItemProto(item dto.WeatherBatchItem) *weatherv1.WeatherBatchItem {
+	return &weatherv1.WeatherBatchItem{City: item.City, Lat: item.Lat, Lon: item.Lon}
+}
+
+func problemProto(problem *dto.Problem) *weatherv1.Problem {
+	if problem == nil {
+		return nil
+	}
+	return &weatherv1.Problem{
+		Type:     problem.Type,
+		Title:    problem.Title,
+		Status:   int32(problem.Status),
+		Detail:   problem.Detail,
+		Instance: problem.Instance,
+	}
+}
@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"weather-api/internal/dto"
+)
+
+// TestNegotiateEncoder проверяет выбор формата по параметру format и заголовку Accept
+func TestNegotiateEncoder(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{name: "no accept", want: "json", ok: true},
+		{name: "exact type", accept: "text/csv", want: "csv", ok: true},
+		{name: "alias type", accept: "text/xml", want: "xml", ok: true},
+		{name: "type is case insensitive", accept: "Application/XML", want: "xml", ok: true},
+		{name: "first listed wins on equal q", accept: "application/xml, text/csv", want: "xml", ok: true},
+		{name: "higher q wins", accept: "application/xml;q=0.5, text/csv;q=0.9", want: "csv", ok: true},
+		{name: "missing q means 1", accept: "application/xml;q=0.8, application/x-protobuf", want: "protobuf", ok: true},
+		{name: "q=0 excludes type", accept: "text/csv;q=0, application/xml;q=0.1", want: "xml", ok: true},
+		{name: "unsupported type is skipped", accept: "text/html, text/csv;q=0.5", want: "csv", ok: true},
+		{name: "any type", accept: "*/*", want: "json", ok: true},
+		{name: "any application type", accept: "application/*", want: "json", ok: true},
+		{name: "any text type", accept: "text/*", want: "csv", ok: true},
+		{name: "specific type before wildcard", accept: "*/*;q=0.1, application/xml", want: "xml", ok: true},
+		{name: "only unsupported types", accept: "text/html, image/png", ok: false},
+		{name: "only q=0", accept: "application/json;q=0", ok: false},
+		{name: "format overrides accept", format: "xml", accept: "text/csv", want: "xml", ok: true},
+		{name: "unknown format", format: "yaml", ok: false},
+		{name: "unknown format ignores accept", format: "yaml", accept: "*/*", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/api/v2/weather"
+			if tt.format != "" {
+				target += "?format=" + tt.format
+			}
+			r := httptest.NewRequest(http.MethodGet, target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			e, ok := negotiateEncoder(r)
+			if ok != tt.ok {
+				t.Fatalf("ok = %v, want %v", ok, tt.ok)
+			}
+			if ok && e.format != tt.want {
+				t.Errorf("format = %q, want %q", e.format, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteResponseNotAcceptable проверяет ответ 406, когда формат не согласован
+// или не умеет кодировать ответ
+func TestWriteResponseNotAcceptable(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		accept string
+		value  any
+		want   int
+	}{
+		{name: "supported", target: "/api/v2/weather", accept: "text/csv", value: dto.ResponseV2[dto.WeatherResultV2]{}, want: http.StatusOK},
+		{name: "unsupported accept", target: "/api/v2/weather", accept: "text/html", value: dto.ResponseV2[dto.WeatherResultV2]{}, want: http.StatusNotAcceptable},
+		{name: "unknown format", target: "/api/v2/weather?format=yaml", value: dto.ResponseV2[dto.WeatherResultV2]{}, want: http.StatusNotAcceptable},
+		{name: "type not supported by csv", target: "/api/v2/weather", accept: "text/csv", value: dto.APIKey{}, want: http.StatusNotAcceptable},
+		{name: "type not supported by protobuf", target: "/api/v2/weather?format=protobuf", value: dto.APIKey{}, want: http.StatusNotAcceptable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+
+			writeResponse(w, r, tt.value)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept" {
+				t.Errorf("Vary = %q, want Accept", got)
+			}
+			if tt.want == http.StatusNotAcceptable {
+				if got := w.Header().Get("Content-Type"); got != problemContentType {
+					t.Errorf("Content-Type = %q, want %q", got, problemContentType)
+				}
+			}
+		})
+	}
+}
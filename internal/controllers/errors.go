@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+)
+
+// problemContentType тип ответа с ошибкой по RFC 9457
+const problemContentType = "application/problem+json"
+
+// writeProblem отправляет ответ с ошибкой
+func writeProblem(w http.ResponseWriter, r *http.Request, status int, title, detail string) {
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(dto.Problem{
+		Type:     "about:blank",
+		Title:    title,
+		Status:   status,
+		Detail:   detail,
+		Instance: r.URL.Path,
+	})
+}
+
+// writeBadRequest отправляет 400 с описанием проблемы в параметрах запроса
+func writeBadRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	writeProblem(w, r, http.StatusBadRequest, "Bad Request", detail)
+}
+
+// writeError выбирает код ответа по ошибке предметной области.
+// Неизвестные ошибки отдаются как 500 без подробностей.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	problem := problemFor(r, err)
+	writeProblem(w, r, problem.Status, problem.Title, problem.Detail)
+}
+
+// problemFor описывает ошибку предметной области для клиента
+func problemFor(r *http.Request, err error) dto.Problem {
+	public, ok := models.PublicErrorFor(err)
+	if !ok {
+		slog.ErrorContext(r.Context(), "unexpected error", "path", r.URL.Path, "error", err)
+		public = models.InternalPublicError
+	}
+	return dto.Problem{Type: "about:blank", Title: public.Title, Status: public.Status, Detail: public.Detail}
+}
+
+// NotFound отвечает на запросы к неизвестным маршрутам
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusNotFound, "Not Found", "The requested resource was not found.")
+}
+
+// MethodNotAllowed отвечает на запросы с неподдерживаемым методом
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeProblem(w, r, http.StatusMethodNotAllowed, "Method Not Allowed", "The request method is not supported for this resource.")
+}
+
+// InvalidRequest отвечает 400 на запрос, не прошедший проверку по спецификации API
+func InvalidRequest(w http.ResponseWriter, r *http.Request, detail string) {
+	writeBadRequest(w, r, detail)
+}
+
+// AccessDenied отвечает на ошибку проверки ключа доступа или квоты
+func AccessDenied(w http.ResponseWriter, r *http.Request, err error) {
+	writeError(w, r, err)
+}
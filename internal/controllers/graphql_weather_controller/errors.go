@@ -0,0 +1,62 @@
+package graphql_weather_controller
+
+import (
+	"context"
+	"log/slog"
+	"weather-api/internal/models"
+
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// publicError ошибка, которую можно показать клиенту. Код и HTTP статус
+// попадают в extensions ответа GraphQL.
+type publicError struct {
+	message string
+	code    string
+	status  int
+}
+
+func (e *publicError) Error() string {
+	return e.message
+}
+
+// Extensions реализует gqlerrors.ExtendedError
+func (e *publicError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code, "status": e.status}
+}
+
+// toPublicError описывает ошибку предметной области для клиента.
+// Неизвестные ошибки отдаются без подробностей.
+func toPublicError(ctx context.Context, field string, err error) error {
+	public, ok := models.PublicErrorFor(err)
+	if !ok {
+		slog.ErrorContext(ctx, "unexpected error", "field", field, "error", err)
+		public = models.InternalPublicError
+	}
+	return &publicError{message: public.Detail, code: public.Code, status: public.Status}
+}
+
+// restoreExtensions возвращает extensions ошибкам из отложенных резолверов:
+// graphql-go оборачивает их дважды и теряет исходную ошибку при форматировании
+func restoreExtensions(errs []gqlerrors.FormattedError) {
+	for i := range errs {
+		if errs[i].Extensions != nil {
+			continue
+		}
+		err := errs[i].OriginalError()
+		for err != nil {
+			if extended, ok := err.(gqlerrors.ExtendedError); ok {
+				errs[i].Extensions = extended.Extensions()
+				break
+			}
+			switch e := err.(type) {
+			case *gqlerrors.Error:
+				err = e.OriginalError
+			case gqlerrors.FormattedError:
+				err = e.OriginalError()
+			default:
+				err = nil
+			}
+		}
+	}
+}
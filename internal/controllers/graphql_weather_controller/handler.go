@@ -0,0 +1,140 @@
+package graphql_weather_controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"weather-api/internal/usecase"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// maxRequestBodyBytes ограничивает размер тела запроса GraphQL
+const maxRequestBodyBytes = 64 << 10
+
+// Handler обрабатывает запросы GraphQL по HTTP: GET с параметрами query,
+// operationName и variables или POST с JSON телом
+type Handler struct {
+	schema        graphql.Schema
+	resolver      *resolver
+	maxDepth      int
+	maxComplexity int
+}
+
+// HandlerOptions параметры для создания обработчика GraphQL
+type HandlerOptions struct {
+	WeatherUseCase *usecase.WeatherUseCase
+	// MaxDepth и MaxComplexity ограничивают глубину и сложность запроса, 0 снимает ограничение
+	MaxDepth      int
+	MaxComplexity int
+}
+
+// NewHandler создает обработчик GraphQL
+func NewHandler(options HandlerOptions) *Handler {
+	if options.WeatherUseCase == nil {
+		panic("WeatherUseCase is required")
+	}
+
+	resolver := &resolver{weatherUseCase: options.WeatherUseCase}
+	schema, err := newSchema(resolver)
+	if err != nil {
+		panic(err)
+	}
+
+	return &Handler{
+		schema:        schema,
+		resolver:      resolver,
+		maxDepth:      options.MaxDepth,
+		maxComplexity: options.MaxComplexity,
+	}
+}
+
+// request запрос GraphQL
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := parseRequest(w, r)
+	if err != nil {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)})
+		return
+	}
+
+	status, result := h.execute(r.Context(), req)
+	writeResult(w, status, result)
+}
+
+// execute разбирает, проверяет и выполняет запрос. Ошибки до выполнения отдаются с кодом 400.
+func (h *Handler) execute(ctx context.Context, req *request) (int, *graphql.Result) {
+	document, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(req.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return http.StatusBadRequest, &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	if validation := graphql.ValidateDocument(&h.schema, document, nil); !validation.IsValid {
+		return http.StatusBadRequest, &graphql.Result{Errors: validation.Errors}
+	}
+
+	countCities := func() (int, error) {
+		cities, err := h.resolver.weatherUseCase.GetAllCities(ctx)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to count cities for query complexity", "error", err)
+		}
+		return len(cities), err
+	}
+	if err := checkLimits(document, req.OperationName, req.Variables, countCities, h.maxDepth, h.maxComplexity); err != nil {
+		formatted := gqlerrors.FormatError(err)
+		formatted.Extensions = err.Extensions()
+		return http.StatusBadRequest, &graphql.Result{Errors: []gqlerrors.FormattedError{formatted}}
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           document,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(ctx, loadersKey{}, h.resolver.newLoaders()),
+	})
+	restoreExtensions(result.Errors)
+	return http.StatusOK, result
+}
+
+func parseRequest(w http.ResponseWriter, r *http.Request) (*request, error) {
+	var req request
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, errors.New("variables must be a JSON object")
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBodyBytes)).Decode(&req); err != nil {
+			return nil, errors.New("request body must be a JSON object with a query field")
+		}
+	}
+
+	if req.Query == "" {
+		return nil, errors.New("query is required")
+	}
+	return &req, nil
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(result)
+}
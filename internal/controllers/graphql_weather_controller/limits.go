@@ -0,0 +1,152 @@
+package graphql_weather_controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Стоимость полей для ограничения сложности запроса. Поля, ради которых
+// идем к провайдеру погоды, стоят дороже остальных.
+const (
+	fieldCost    = 1
+	upstreamCost = 10
+	// defaultListSize сколько элементов считаем в cities без явного списка names,
+	// если число городов в справочнике узнать не удалось
+	defaultListSize = 20
+)
+
+// upstreamFields поля, значения которых получаются у провайдера погоды
+var upstreamFields = map[string]bool{
+	"weather":  true,
+	"forecast": true,
+	"alerts":   true,
+}
+
+// queryCost глубина и сложность операции
+type queryCost struct {
+	depth      int
+	complexity int
+}
+
+// checkLimits считает глубину и сложность операции и возвращает ошибку, если они превышены.
+// Служебные поля интроспекции не учитываются. countCities вызывается, только если в
+// запросе есть cities без names: такое поле вернет весь справочник.
+func checkLimits(document *ast.Document, operationName string, variables map[string]interface{}, countCities func() (int, error), maxDepth, maxComplexity int) *publicError {
+	operation, fragments := splitDocument(document, operationName)
+	if operation == nil {
+		// Отсутствующую операцию отклонит graphql.Execute
+		return nil
+	}
+
+	analyzer := costAnalyzer{fragments: fragments, variables: variables, countCities: countCities}
+	complexity := analyzer.selectionSet(operation.SelectionSet, 1)
+
+	if maxDepth > 0 && analyzer.maxDepth > maxDepth {
+		return &publicError{fmt.Sprintf("Query depth %d exceeds the limit of %d.", analyzer.maxDepth, maxDepth), "QUERY_TOO_DEEP", http.StatusBadRequest}
+	}
+	if maxComplexity > 0 && complexity > maxComplexity {
+		return &publicError{fmt.Sprintf("Query complexity %d exceeds the limit of %d.", complexity, maxComplexity), "QUERY_TOO_COMPLEX", http.StatusBadRequest}
+	}
+	return nil
+}
+
+// splitDocument находит выполняемую операцию и фрагменты документа
+func splitDocument(document *ast.Document, operationName string) (*ast.OperationDefinition, map[string]*ast.FragmentDefinition) {
+	var operation *ast.OperationDefinition
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		switch definition := definition.(type) {
+		case *ast.OperationDefinition:
+			name := ""
+			if definition.Name != nil {
+				name = definition.Name.Value
+			}
+			if operationName == "" || name == operationName {
+				operation = definition
+			}
+		case *ast.FragmentDefinition:
+			fragments[definition.Name.Value] = definition
+		}
+	}
+	return operation, fragments
+}
+
+type costAnalyzer struct {
+	fragments   map[string]*ast.FragmentDefinition
+	variables   map[string]interface{}
+	countCities func() (int, error)
+	// citiesCount число городов в справочнике, 0 пока не запрошено
+	citiesCount int
+	maxDepth    int
+}
+
+// selectionSet возвращает сложность набора полей, depth глубина его полей
+func (a *costAnalyzer) selectionSet(set *ast.SelectionSet, depth int) int {
+	if set == nil {
+		return 0
+	}
+
+	complexity := 0
+	for _, selection := range set.Selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(selection.Name.Value, "__") {
+				continue
+			}
+			a.maxDepth = max(a.maxDepth, depth)
+
+			cost := fieldCost
+			if upstreamFields[selection.Name.Value] {
+				cost = upstreamCost
+			}
+			children := a.selectionSet(selection.SelectionSet, depth+1)
+			complexity += (cost + children) * a.listSize(selection)
+		case *ast.InlineFragment:
+			complexity += a.selectionSet(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			// Циклы фрагментов отклоняются валидацией до подсчета
+			if fragment, ok := a.fragments[selection.Name.Value]; ok {
+				complexity += a.selectionSet(fragment.SelectionSet, depth)
+			}
+		}
+	}
+	return complexity
+}
+
+// listSize во сколько раз поле умножает стоимость вложенных полей
+func (a *costAnalyzer) listSize(field *ast.Field) int {
+	if field.Name.Value != "cities" {
+		return 1
+	}
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "names" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.ListValue:
+			return max(len(value.Values), 1)
+		case *ast.Variable:
+			if names, ok := a.variables[value.Name.Value].([]interface{}); ok {
+				return max(len(names), 1)
+			}
+		}
+	}
+	return a.allCities()
+}
+
+// allCities число городов в справочнике. Запрашивается один раз на операцию.
+func (a *costAnalyzer) allCities() int {
+	if a.citiesCount > 0 {
+		return a.citiesCount
+	}
+	a.citiesCount = defaultListSize
+	if a.countCities != nil {
+		if count, err := a.countCities(); err == nil {
+			a.citiesCount = max(count, 1)
+		}
+	}
+	return a.citiesCount
+}
@@ -0,0 +1,72 @@
+package graphql_weather_controller
+
+import (
+	"context"
+	"sync"
+)
+
+// loader собирает ключи, запрошенные резолверами одного уровня запроса, и
+// получает их одним вызовом fetch. Резолвер регистрирует ключ через load и
+// возвращает отложенную функцию: graphql-go вызывает такие функции только после
+// того, как разрешены все поля уровня, поэтому к первому вызову все ключи собраны.
+// Живет один запрос.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]loaderResult[V]
+}
+
+type loaderResult[V any] struct {
+	value V
+	found bool
+	err   error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{
+		fetch:   fetch,
+		results: make(map[K]loaderResult[V]),
+	}
+}
+
+// load регистрирует ключ и возвращает функцию, которая получит значение
+func (l *loader[K, V]) load(ctx context.Context, key K) func() loaderResult[V] {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() loaderResult[V] {
+		return l.get(ctx, key)
+	}
+}
+
+func (l *loader[K, V]) get(ctx context.Context, key K) loaderResult[V] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if result, ok := l.results[key]; ok {
+		return result
+	}
+
+	// Ключа еще нет: получаем все накопленные ключи одним вызовом
+	keys := make([]K, 0, len(l.pending))
+	seen := make(map[K]bool, len(l.pending))
+	for _, k := range l.pending {
+		if _, done := l.results[k]; !done && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	l.pending = nil
+
+	values, err := l.fetch(ctx, keys)
+	for _, k := range keys {
+		value, found := values[k]
+		l.results[k] = loaderResult[V]{value: value, found: found, err: err}
+	}
+	return l.results[key]
+}
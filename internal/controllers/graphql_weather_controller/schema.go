@@ -0,0 +1,344 @@
+package graphql_weather_controller
+
+import (
+	"context"
+	"fmt"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/usecase"
+
+	"github.com/graphql-go/graphql"
+)
+
+// defaultForecastDays длина прогноза, если аргумент days не указан
+const defaultForecastDays = 3
+
+// resolver резолверы схемы поверх usecase.WeatherUseCase
+type resolver struct {
+	weatherUseCase *usecase.WeatherUseCase
+}
+
+// requestLoaders загрузчики одного запроса, по ним резолверы объединяют обращения
+type requestLoaders struct {
+	cities  *loader[string, models.City]
+	weather *loader[dto.Location, dto.WeatherBatchResult]
+}
+
+type loadersKey struct{}
+
+func (r *resolver) newLoaders() *requestLoaders {
+	return &requestLoaders{
+		cities:  newLoader(r.fetchCities),
+		weather: newLoader(r.fetchWeather),
+	}
+}
+
+func loadersFrom(ctx context.Context) *requestLoaders {
+	return ctx.Value(loadersKey{}).(*requestLoaders)
+}
+
+// fetchCities получает города одним запросом к репозиторию
+func (r *resolver) fetchCities(ctx context.Context, names []string) (map[string]models.City, error) {
+	cities, err := r.weatherUseCase.GetCitiesByNames(ctx, names)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]models.City, len(cities))
+	for _, city := range cities {
+		result[city.Name] = city
+	}
+	return result, nil
+}
+
+// fetchWeather получает погоду для всех точек через пакетный запрос usecase
+func (r *resolver) fetchWeather(ctx context.Context, locations []dto.Location) (map[dto.Location]dto.WeatherBatchResult, error) {
+	result := make(map[dto.Location]dto.WeatherBatchResult, len(locations))
+	for start := 0; start < len(locations); start += usecase.MaxBatchItems {
+		chunk := locations[start:min(start+usecase.MaxBatchItems, len(locations))]
+
+		items := make([]dto.WeatherBatchItem, len(chunk))
+		for i := range chunk {
+			items[i] = dto.WeatherBatchItem{Lat: &chunk[i].Latitude, Lon: &chunk[i].Longitude}
+		}
+		batch, err := r.weatherUseCase.GetWeatherBatch(ctx, items)
+		if err != nil {
+			return nil, err
+		}
+		for i, item := range batch {
+			if item.Report != nil {
+				item.Report.Location = chunk[i]
+			}
+			result[chunk[i]] = item
+		}
+	}
+	return result, nil
+}
+
+// loadCity резолвит город через загрузчик, отсутствующий город отдается как null
+func loadCity(ctx context.Context, name string) func() (interface{}, error) {
+	thunk := loadersFrom(ctx).cities.load(ctx, name)
+	return func() (interface{}, error) {
+		result := thunk()
+		if result.err != nil {
+			return nil, toPublicError(ctx, "city", result.err)
+		}
+		if !result.found {
+			return nil, nil
+		}
+		return result.value, nil
+	}
+}
+
+// loadWeather резолвит текущую погоду в точке через загрузчик
+func loadWeather(ctx context.Context, location dto.Location) func() (interface{}, error) {
+	thunk := loadersFrom(ctx).weather.load(ctx, location)
+	return func() (interface{}, error) {
+		result := thunk()
+		if result.err != nil {
+			return nil, toPublicError(ctx, "weather", result.err)
+		}
+		if result.value.Err != nil {
+			return nil, toPublicError(ctx, "weather", result.value.Err)
+		}
+		return result.value.Report, nil
+	}
+}
+
+func (r *resolver) forecast(ctx context.Context, lat, lon float64, days int) (interface{}, error) {
+	forecast, err := r.weatherUseCase.GetForecast(ctx, dto.GetForecastParams{Lat: lat, Lon: lon, Days: days})
+	if err != nil {
+		return nil, toPublicError(ctx, "forecast", err)
+	}
+	return forecast, nil
+}
+
+func (r *resolver) alerts(ctx context.Context, lat, lon float64) (interface{}, error) {
+	alerts, err := r.weatherUseCase.GetAlerts(ctx, lat, lon)
+	if err != nil {
+		return nil, toPublicError(ctx, "alerts", err)
+	}
+	return alerts, nil
+}
+
+// sourceField поле, значение которого берется из родительского объекта типа S
+func sourceField[S any](fieldType graphql.Output, description string, get func(S) interface{}) *graphql.Field {
+	return &graphql.Field{
+		Type:        fieldType,
+		Description: description,
+		Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(S)), nil
+		},
+	}
+}
+
+var (
+	nonNullString   = graphql.NewNonNull(graphql.String)
+	nonNullFloat    = graphql.NewNonNull(graphql.Float)
+	nonNullInt      = graphql.NewNonNull(graphql.Int)
+	nonNullDateTime = graphql.NewNonNull(graphql.DateTime)
+)
+
+// coordinatesArgs аргументы lat и lon
+func coordinatesArgs() graphql.FieldConfigArgument {
+	return graphql.FieldConfigArgument{
+		"lat": &graphql.ArgumentConfig{Type: nonNullFloat},
+		"lon": &graphql.ArgumentConfig{Type: nonNullFloat},
+	}
+}
+
+func daysArg() *graphql.ArgumentConfig {
+	return &graphql.ArgumentConfig{
+		Type:         graphql.Int,
+		DefaultValue: defaultForecastDays,
+		Description:  fmt.Sprintf("Количество дней прогноза, от 1 до 16, по умолчанию %d", defaultForecastDays),
+	}
+}
+
+// newSchema описывает схему: города, текущая погода, прогнозы и предупреждения
+func newSchema(r *resolver) (graphql.Schema, error) {
+	alertType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Alert",
+		Description: "Предупреждение об опасной погоде, вычисленное по прогнозу",
+		Fields: graphql.Fields{
+			"event":       sourceField(nonNullString, "thunderstorm, heavy_precipitation, heat или frost", func(a dto.WeatherAlert) interface{} { return a.Event }),
+			"severity":    sourceField(nonNullString, "moderate или severe", func(a dto.WeatherAlert) interface{} { return a.Severity }),
+			"start":       sourceField(nonNullDateTime, "", func(a dto.WeatherAlert) interface{} { return a.Start }),
+			"end":         sourceField(nonNullDateTime, "", func(a dto.WeatherAlert) interface{} { return a.End }),
+			"description": sourceField(nonNullString, "", func(a dto.WeatherAlert) interface{} { return a.Description }),
+		},
+	})
+
+	hourlyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "HourlyForecast",
+		Fields: graphql.Fields{
+			"time":        sourceField(nonNullDateTime, "", func(h dto.HourlyForecast) interface{} { return h.Time }),
+			"temperature": sourceField(nonNullFloat, "°C", func(h dto.HourlyForecast) interface{} { return h.Temperature }),
+			"weatherCode": sourceField(nonNullInt, "Код погоды WMO", func(h dto.HourlyForecast) interface{} { return h.WeatherCode }),
+			"description": sourceField(nonNullString, "", func(h dto.HourlyForecast) interface{} { return h.WeatherDesc }),
+		},
+	})
+
+	dailyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "DailyForecast",
+		Fields: graphql.Fields{
+			"date":           sourceField(nonNullDateTime, "", func(d dto.DailyForecast) interface{} { return d.Date }),
+			"temperatureMax": sourceField(nonNullFloat, "°C", func(d dto.DailyForecast) interface{} { return d.TemperatureMax }),
+			"temperatureMin": sourceField(nonNullFloat, "°C", func(d dto.DailyForecast) interface{} { return d.TemperatureMin }),
+			"precipitation":  sourceField(nonNullFloat, "мм", func(d dto.DailyForecast) interface{} { return d.Precipitation }),
+			"weatherCode":    sourceField(nonNullInt, "Код погоды WMO", func(d dto.DailyForecast) interface{} { return d.WeatherCode }),
+			"description":    sourceField(nonNullString, "", func(d dto.DailyForecast) interface{} { return d.WeatherDesc }),
+		},
+	})
+
+	forecastType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Forecast",
+		Fields: graphql.Fields{
+			"timezone": sourceField(nonNullString, "", func(f *dto.ForecastResult) interface{} { return f.Timezone }),
+			"hourly":   sourceField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(hourlyType))), "", func(f *dto.ForecastResult) interface{} { return f.Hourly }),
+			"daily":    sourceField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(dailyType))), "", func(f *dto.ForecastResult) interface{} { return f.Daily }),
+		},
+	})
+
+	currentWeatherType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "CurrentWeather",
+		Fields: graphql.Fields{
+			"observedAt": sourceField(graphql.DateTime, "Время наблюдения, null если неизвестно", func(w *dto.WeatherReport) interface{} {
+				if w.ObservedAt.IsZero() {
+					return nil
+				}
+				return w.ObservedAt
+			}),
+			"temperature":   sourceField(nonNullFloat, "°C", func(w *dto.WeatherReport) interface{} { return w.Temperature }),
+			"windSpeed":     sourceField(nonNullFloat, "км/ч", func(w *dto.WeatherReport) interface{} { return w.WindSpeed }),
+			"windDirection": sourceField(nonNullFloat, "Градусы", func(w *dto.WeatherReport) interface{} { return w.WindDirection }),
+			"weatherCode":   sourceField(nonNullInt, "Код погоды WMO", func(w *dto.WeatherReport) interface{} { return w.WeatherCode }),
+			"description":   sourceField(nonNullString, "", func(w *dto.WeatherReport) interface{} { return w.WeatherDesc }),
+		},
+	})
+
+	alertsType := graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(alertType)))
+
+	cityType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "City",
+		Fields: graphql.Fields{
+			"name":      sourceField(nonNullString, "", func(c models.City) interface{} { return c.Name }),
+			"country":   sourceField(nonNullString, "", func(c models.City) interface{} { return c.Country }),
+			"latitude":  sourceField(nonNullFloat, "", func(c models.City) interface{} { return c.Latitude }),
+			"longitude": sourceField(nonNullFloat, "", func(c models.City) interface{} { return c.Longitude }),
+			"timezone":  sourceField(nonNullString, "", func(c models.City) interface{} { return c.Timezone }),
+			"weather": &graphql.Field{
+				Type: currentWeatherType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					city := p.Source.(models.City)
+					return loadWeather(p.Context, dto.Location{
+						Name:      city.Name,
+						Country:   city.Country,
+						Latitude:  city.Latitude,
+						Longitude: city.Longitude,
+						Timezone:  city.Timezone,
+					}), nil
+				},
+			},
+			"forecast": &graphql.Field{
+				Type: forecastType,
+				Args: graphql.FieldConfigArgument{"days": daysArg()},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					city := p.Source.(models.City)
+					return r.forecast(p.Context, city.Latitude, city.Longitude, p.Args["days"].(int))
+				},
+			},
+			"alerts": &graphql.Field{
+				Type: alertsType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					city := p.Source.(models.City)
+					return r.alerts(p.Context, city.Latitude, city.Longitude)
+				},
+			},
+		},
+	})
+
+	forecastArgs := coordinatesArgs()
+	forecastArgs["days"] = daysArg()
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"city": &graphql.Field{
+				Type:        cityType,
+				Description: "Город из справочника, null если его нет",
+				Args:        graphql.FieldConfigArgument{"name": &graphql.ArgumentConfig{Type: nonNullString}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadCity(p.Context, p.Args["name"].(string)), nil
+				},
+			},
+			"cities": &graphql.Field{
+				Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(cityType))),
+				Description: "Города из справочника: перечисленные в names или все",
+				Args:        graphql.FieldConfigArgument{"names": &graphql.ArgumentConfig{Type: graphql.NewList(nonNullString)}},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.cities(p.Context, p.Args["names"])
+				},
+			},
+			"weather": &graphql.Field{
+				Type:        currentWeatherType,
+				Description: "Текущая погода по координатам",
+				Args:        coordinatesArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return loadWeather(p.Context, dto.Location{
+						Latitude:  p.Args["lat"].(float64),
+						Longitude: p.Args["lon"].(float64),
+					}), nil
+				},
+			},
+			"forecast": &graphql.Field{
+				Type:        forecastType,
+				Description: "Прогноз по координатам",
+				Args:        forecastArgs,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.forecast(p.Context, p.Args["lat"].(float64), p.Args["lon"].(float64), p.Args["days"].(int))
+				},
+			},
+			"alerts": &graphql.Field{
+				Type:        alertsType,
+				Description: "Предупреждения об опасной погоде на ближайшие двое суток",
+				Args:        coordinatesArgs(),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return r.alerts(p.Context, p.Args["lat"].(float64), p.Args["lon"].(float64))
+				},
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+}
+
+// cities возвращает перечисленные города в порядке names, пропуская неизвестные, или все города
+func (r *resolver) cities(ctx context.Context, namesArg interface{}) (interface{}, error) {
+	list, ok := namesArg.([]interface{})
+	if !ok {
+		cities, err := r.weatherUseCase.GetAllCities(ctx)
+		if err != nil {
+			return nil, toPublicError(ctx, "cities", err)
+		}
+		return cities, nil
+	}
+
+	names := make([]string, 0, len(list))
+	for _, name := range list {
+		names = append(names, name.(string))
+	}
+	found, err := r.fetchCities(ctx, names)
+	if err != nil {
+		return nil, toPublicError(ctx, "cities", err)
+	}
+
+	cities := make([]models.City, 0, len(names))
+	for _, name := range names {
+		// Повторы в names отдаем один раз
+		if city, ok := found[name]; ok {
+			cities = append(cities, city)
+			delete(found, name)
+		}
+	}
+	return cities, nil
+}
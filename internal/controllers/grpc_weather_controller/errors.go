@@ -0,0 +1,38 @@
+package grpc_weather_controller
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"weather-api/internal/models"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCodes коды gRPC для HTTP статусов из models.PublicErrorFor
+var statusCodes = map[int]codes.Code{
+	http.StatusNotFound:           codes.NotFound,
+	http.StatusBadRequest:         codes.InvalidArgument,
+	http.StatusUnauthorized:       codes.Unauthenticated,
+	http.StatusForbidden:          codes.PermissionDenied,
+	http.StatusConflict:           codes.AlreadyExists,
+	http.StatusTooManyRequests:    codes.ResourceExhausted,
+	http.StatusBadGateway:         codes.Unavailable,
+	http.StatusServiceUnavailable: codes.Unavailable,
+}
+
+// toStatus переводит ошибку предметной области в статус gRPC.
+// Неизвестные ошибки отдаются как Internal без подробностей.
+func toStatus(ctx context.Context, method string, err error) error {
+	public, ok := models.PublicErrorFor(err)
+	if !ok {
+		slog.ErrorContext(ctx, "unexpected error", "method", method, "error", err)
+		public = models.InternalPublicError
+	}
+	code, ok := statusCodes[public.Status]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.Error(code, public.Detail)
+}
@@ -0,0 +1,205 @@
+package grpc_weather_controller
+
+import (
+	"context"
+	"log/slog"
+	"weather-api/internal/dto"
+	"weather-api/internal/middleware"
+	"weather-api/internal/repository"
+	"weather-api/internal/usecase"
+	"weather-api/pkg/metrics"
+	"weather-api/pkg/pb/weatherv1"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WeatherServer реализует gRPC сервис weather.v1.WeatherService
+type WeatherServer struct {
+	weatherv1.UnimplementedWeatherServiceServer
+	weatherUseCase *usecase.WeatherUseCase
+}
+
+// ServerOptions параметры для создания gRPC сервера
+type ServerOptions struct {
+	WeatherUseCase *usecase.WeatherUseCase
+	Metrics        *metrics.Metrics
+	// APIKeys проверка ключей доступа из метаданных x-api-key, если nil, API открыт
+	APIKeys middleware.APIKeyAuthenticator
+	// RateLimiter ограничитель частоты запросов, если nil, частота не ограничивается
+	RateLimiter repository.RateLimiter
+	RateLimit   dto.RateLimit
+	// IPRateLimit корзина на адрес клиента до проверки ключа
+	IPRateLimit dto.RateLimit
+	// Stopping закрывается в начале остановки приложения, после этого проверка
+	// состояния отвечает NOT_SERVING, и балансировщик успевает убрать экземпляр
+	Stopping <-chan struct{}
+}
+
+// NewServer создает gRPC сервер с сервисом погоды, проверкой состояния и reflection
+func NewServer(options ServerOptions) *grpc.Server {
+	if options.WeatherUseCase == nil {
+		panic("WeatherUseCase is required")
+	}
+	if options.Metrics == nil {
+		panic("Metrics is required")
+	}
+
+	// Ключ и частота проверяются так же, как в HTTP API: сервис расходует ту же квоту провайдера
+	access := middleware.GRPCAccessOptions{
+		Keys:    options.APIKeys,
+		Limiter: options.RateLimiter,
+		Limit:   options.RateLimit,
+		IPLimit: options.IPRateLimit,
+		OnError: toStatus,
+	}
+
+	server := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			middleware.GRPCRequestIDInterceptor(),
+			middleware.GRPCMetricsInterceptor(options.Metrics),
+			middleware.GRPCAccessInterceptor(access),
+		),
+		grpc.ChainStreamInterceptor(
+			middleware.GRPCStreamRequestIDInterceptor(),
+			middleware.GRPCStreamMetricsInterceptor(options.Metrics),
+			middleware.GRPCStreamAccessInterceptor(access),
+		),
+	)
+
+	weatherv1.RegisterWeatherServiceServer(server, &WeatherServer{weatherUseCase: options.WeatherUseCase})
+
+	// Проверка состояния: пустое имя означает сервер целиком
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus(weatherv1.WeatherService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	if options.Stopping != nil {
+		go func() {
+			<-options.Stopping
+			healthServer.Shutdown()
+		}()
+	}
+
+	// Reflection позволяет вызывать методы через grpcurl без proto файлов
+	reflection.Register(server)
+
+	return server
+}
+
+// GetWeatherToday получает погоду по координатам
+func (s *WeatherServer) GetWeatherToday(ctx context.Context, req *weatherv1.GetWeatherTodayRequest) (*weatherv1.WeatherResult, error) {
+	result, err := s.weatherUseCase.GetWeatherToday(ctx, dto.GetWeatherTodayParams{Lat: req.GetLat(), Lon: req.GetLon()})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get weather", "error", err)
+		return nil, toStatus(ctx, weatherv1.WeatherService_GetWeatherToday_FullMethodName, err)
+	}
+	return toWeatherResult(result), nil
+}
+
+// GetWeatherByCity получает погоду по названию города
+func (s *WeatherServer) GetWeatherByCity(ctx context.Context, req *weatherv1.GetWeatherByCityRequest) (*weatherv1.WeatherResult, error) {
+	if req.GetCity() == "" {
+		return nil, status.Error(codes.InvalidArgument, "city name is required")
+	}
+
+	result, err := s.weatherUseCase.GetWeatherByCity(ctx, req.GetCity())
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get weather for city", "city", req.GetCity(), "error", err)
+		return nil, toStatus(ctx, weatherv1.WeatherService_GetWeatherByCity_FullMethodName, err)
+	}
+	return toWeatherResult(result), nil
+}
+
+// GetAllCities получает список всех городов
+func (s *WeatherServer) GetAllCities(ctx context.Context, _ *weatherv1.GetAllCitiesRequest) (*weatherv1.CityList, error) {
+	cities, err := s.weatherUseCase.GetAllCities(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get cities", "error", err)
+		return nil, toStatus(ctx, weatherv1.WeatherService_GetAllCities_FullMethodName, err)
+	}
+
+	list := &weatherv1.CityList{Cities: make([]*weatherv1.City, 0, len(cities))}
+	for _, city := range cities {
+		list.Cities = append(list.Cities, &weatherv1.City{
+			Name:      city.Name,
+			Latitude:  city.Latitude,
+			Longitude: city.Longitude,
+			Country:   city.Country,
+			Timezone:  city.Timezone,
+		})
+	}
+	return list, nil
+}
+
+// GetForecast получает прогноз по координатам
+func (s *WeatherServer) GetForecast(ctx context.Context, req *weatherv1.GetForecastRequest) (*weatherv1.Forecast, error) {
+	forecast, err := s.weatherUseCase.GetForecast(ctx, dto.GetForecastParams{
+		Lat:  req.GetLat(),
+		Lon:  req.GetLon(),
+		Days: int(req.GetDays()),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get forecast", "error", err)
+		return nil, toStatus(ctx, weatherv1.WeatherService_GetForecast_FullMethodName, err)
+	}
+	return toForecast(forecast), nil
+}
+
+// GetForecastByCity получает прогноз по названию города
+func (s *WeatherServer) GetForecastByCity(ctx context.Context, req *weatherv1.GetForecastByCityRequest) (*weatherv1.Forecast, error) {
+	if req.GetCity() == "" {
+		return nil, status.Error(codes.InvalidArgument, "city name is required")
+	}
+
+	forecast, err := s.weatherUseCase.GetForecastByCity(ctx, req.GetCity(), int(req.GetDays()))
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to get forecast for city", "city", req.GetCity(), "error", err)
+		return nil, toStatus(ctx, weatherv1.WeatherService_GetForecastByCity_FullMethodName, err)
+	}
+	return toForecast(forecast), nil
+}
+
+func toWeatherResult(result *dto.WeatherResult) *weatherv1.WeatherResult {
+	return &weatherv1.WeatherResult{
+		CurrentWeather: &weatherv1.CurrentWeather{
+			Temperature:        result.CurrentWeather.Temperature,
+			WeatherCode:        int32(result.CurrentWeather.WeatherCode),
+			WeatherDescription: result.CurrentWeather.WeatherDesc,
+		},
+	}
+}
+
+func toForecast(forecast *dto.ForecastResult) *weatherv1.Forecast {
+	result := &weatherv1.Forecast{
+		Timezone: forecast.Timezone,
+		Hourly:   make([]*weatherv1.HourlyForecast, 0, len(forecast.Hourly)),
+		Daily:    make([]*weatherv1.DailyForecast, 0, len(forecast.Daily)),
+	}
+	for _, hour := range forecast.Hourly {
+		result.Hourly = append(result.Hourly, &weatherv1.HourlyForecast{
+			Time:               timestamppb.New(hour.Time),
+			Temperature:        hour.Temperature,
+			WeatherCode:        int32(hour.WeatherCode),
+			WeatherDescription: hour.WeatherDesc,
+		})
+	}
+	for _, day := range forecast.Daily {
+		result.Daily = append(result.Daily, &weatherv1.DailyForecast{
+			Date:               timestamppb.New(day.Date),
+			TemperatureMax:     day.TemperatureMax,
+			TemperatureMin:     day.TemperatureMin,
+			Precipitation:      day.Precipitation,
+			WeatherCode:        int32(day.WeatherCode),
+			WeatherDescription: day.WeatherDesc,
+		})
+	}
+	return result
+}
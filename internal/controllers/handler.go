@@ -2,13 +2,14 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"log/slog"
 	"net/http"
 	"strconv"
+	"time"
 	"weather-api/internal/dto"
 	"weather-api/internal/models"
 	"weather-api/internal/usecase"
+	"weather-api/pkg/metrics"
 
 	"github.com/gorilla/mux"
 )
@@ -21,64 +22,119 @@ type WeatherUseCase interface {
 
 // WeatherController обрабатывает HTTP запросы к погодному API
 type WeatherController struct {
-	weatherUseCase *usecase.WeatherUseCase
+	weatherUseCase  *usecase.WeatherUseCase
+	cardUseCase     *usecase.CardUseCase
+	weatherWatcher  *usecase.WeatherWatcher
+	streamHeartbeat time.Duration
+	metrics         *metrics.Metrics
+	websocket       websocketOptions
+	apiKeyUseCase   *usecase.APIKeyUseCase
+	adminUseCase    *usecase.AdminUseCase
+	healthUseCase   *usecase.HealthUseCase
+	streams         *streams
 }
 
 // WeatherControllerOptions параметры для создания контроллера
 type WeatherControllerOptions struct {
 	WeatherUseCase *usecase.WeatherUseCase
+	CardUseCase    *usecase.CardUseCase
+	WeatherWatcher *usecase.WeatherWatcher
+	// StreamHeartbeat как часто отправлять комментарий в поток SSE, по умолчанию 15 секунд
+	StreamHeartbeat time.Duration
+	// Metrics необязательные метрики WebSocket соединений
+	Metrics *metrics.Metrics
+	// WebSocketIdleTimeout закрывает WebSocket без сообщений и pong от клиента, по умолчанию 60 секунд
+	WebSocketIdleTimeout time.Duration
+	// WebSocketMaxSubscriptions сколько подписок можно держать на одном соединении, по умолчанию 20
+	WebSocketMaxSubscriptions int
+	// WebSocketSubscribeRate сколько новых подписок в секунду можно оформить на соединении, по умолчанию 1
+	WebSocketSubscribeRate float64
+	// APIKeyUseCase выпуск и отзыв ключей доступа администраторами
+	APIKeyUseCase *usecase.APIKeyUseCase
+	// AdminUseCase добавление городов через API
+	AdminUseCase *usecase.AdminUseCase
+	// HealthUseCase проверки зависимостей для /readyz, без него готовность не зависит от зависимостей
+	HealthUseCase *usecase.HealthUseCase
 }
 
 // NewWeatherController создает новый контроллер погоды
 func NewWeatherController(options WeatherControllerOptions) *WeatherController {
+	if options.StreamHeartbeat <= 0 {
+		options.StreamHeartbeat = defaultStreamHeartbeat
+	}
+	if options.WebSocketIdleTimeout <= 0 {
+		options.WebSocketIdleTimeout = defaultWebSocketIdleTimeout
+	}
+	if options.WebSocketMaxSubscriptions <= 0 {
+		options.WebSocketMaxSubscriptions = defaultWebSocketMaxSubscriptions
+	}
+	if options.WebSocketSubscribeRate <= 0 {
+		options.WebSocketSubscribeRate = defaultWebSocketSubscribeRate
+	}
+	if options.HealthUseCase == nil {
+		options.HealthUseCase = usecase.NewHealthUseCase(usecase.HealthUseCaseOptions{})
+	}
 	return &WeatherController{
-		weatherUseCase: options.WeatherUseCase,
+		weatherUseCase:  options.WeatherUseCase,
+		cardUseCase:     options.CardUseCase,
+		weatherWatcher:  options.WeatherWatcher,
+		streamHeartbeat: options.StreamHeartbeat,
+		metrics:         options.Metrics,
+		websocket: websocketOptions{
+			idleTimeout:      options.WebSocketIdleTimeout,
+			maxSubscriptions: options.WebSocketMaxSubscriptions,
+			subscribeRate:    options.WebSocketSubscribeRate,
+		},
+		apiKeyUseCase: options.APIKeyUseCase,
+		adminUseCase:  options.AdminUseCase,
+		healthUseCase: options.HealthUseCase,
+		streams:       newStreams(),
 	}
 }
 
 // GetWeather получает погоду по координатам
 func (c *WeatherController) GetWeather(w http.ResponseWriter, r *http.Request) {
-	// Получаем параметры запроса
+	// Парсим координаты
+	params, detail := parseCoordinates(r)
+	if detail != "" {
+		writeBadRequest(w, r, detail)
+		return
+	}
+
+	// Получаем погоду через usecase
+	report, err := c.weatherUseCase.GetWeatherReport(r.Context(), params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get weather", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	// Отправляем результат
+	writeCached(w, r, toWeatherResultV1(report), report.ObservedAt, report.ExpiresIn)
+}
+
+// parseCoordinates читает lat и lon из запроса. При ошибке возвращает ее описание для клиента.
+func parseCoordinates(r *http.Request) (dto.GetWeatherTodayParams, string) {
 	query := r.URL.Query()
 
-	// Парсим координаты
 	latStr := query.Get("lat")
 	lonStr := query.Get("lon")
 
 	if latStr == "" || lonStr == "" {
-		http.Error(w, "Missing lat or lon parameters", http.StatusBadRequest)
-		return
+		return dto.GetWeatherTodayParams{}, "Missing lat or lon parameters"
 	}
 
 	lat, err := strconv.ParseFloat(latStr, 64)
 	if err != nil {
-		http.Error(w, "Invalid lat parameter", http.StatusBadRequest)
-		return
+		return dto.GetWeatherTodayParams{}, "Invalid lat parameter"
 	}
 
 	lon, err := strconv.ParseFloat(lonStr, 64)
 	if err != nil {
-		http.Error(w, "Invalid lon parameter", http.StatusBadRequest)
-		return
-	}
-
-	// Формируем запрос
-	params := dto.GetWeatherTodayParams{
-		Lat: lat,
-		Lon: lon,
-	}
-
-	// Получаем погоду через usecase
-	result, err := c.weatherUseCase.GetWeatherToday(r.Context(), params)
-	if err != nil {
-		slog.Error("Failed to get weather", "error", err)
-		http.Error(w, "Error getting weather: "+err.Error(), http.StatusInternalServerError)
-		return
+		return dto.GetWeatherTodayParams{}, "Invalid lon parameter"
 	}
 
-	// Отправляем результат
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	return dto.GetWeatherTodayParams{Lat: lat, Lon: lon}, ""
 }
 
 // GetWeatherByCity получает погоду по названию города
@@ -88,21 +144,31 @@ func (c *WeatherController) GetWeatherByCity(w http.ResponseWriter, r *http.Requ
 	cityName := vars["city"]
 
 	if cityName == "" {
-		http.Error(w, "City name is required", http.StatusBadRequest)
+		writeBadRequest(w, r, "City name is required")
 		return
 	}
 
 	// Получаем погоду через usecase
-	result, err := c.weatherUseCase.GetWeatherByCity(r.Context(), cityName)
+	report, err := c.weatherUseCase.GetWeatherReportByCity(r.Context(), cityName)
 	if err != nil {
-		slog.Error("Failed to get weather for city", "city", cityName, "error", err)
-		http.Error(w, "Error getting weather: "+err.Error(), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Failed to get weather for city", "city", cityName, "error", err)
+		writeError(w, r, err)
 		return
 	}
 
 	// Отправляем результат
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(result)
+	writeCached(w, r, toWeatherResultV1(report), report.ObservedAt, report.ExpiresIn)
+}
+
+// toWeatherResultV1 ответ API v1: только текущая погода без данных о месте
+func toWeatherResultV1(report *dto.WeatherReport) *dto.WeatherResult {
+	return &dto.WeatherResult{
+		CurrentWeather: dto.CurrentWeather{
+			Temperature: report.Temperature,
+			WeatherCode: report.WeatherCode,
+			WeatherDesc: report.WeatherDesc,
+		},
+	}
 }
 
 // GetAllCities получает список всех городов
@@ -110,12 +176,49 @@ func (c *WeatherController) GetAllCities(w http.ResponseWriter, r *http.Request)
 	// Получаем список городов через usecase
 	cities, err := c.weatherUseCase.GetAllCities(r.Context())
 	if err != nil {
-		slog.Error("Failed to get cities", "error", err)
-		http.Error(w, "Error getting cities: "+err.Error(), http.StatusInternalServerError)
+		slog.ErrorContext(r.Context(), "Failed to get cities", "error", err)
+		writeError(w, r, err)
 		return
 	}
 
 	// Отправляем результат
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(cities)
+	writeCached(w, r, cities, time.Time{}, c.weatherUseCase.CitiesExpiresIn(r.Context()))
+}
+
+// GetCityCard отдает PNG карточку погоды города.
+// Необязательные параметры: lang (ru, en) и units (c, f).
+func (c *WeatherController) GetCityCard(w http.ResponseWriter, r *http.Request) {
+	cityName := mux.Vars(r)["city"]
+	if cityName == "" {
+		writeBadRequest(w, r, "City name is required")
+		return
+	}
+
+	query := r.URL.Query()
+	language := query.Get("lang")
+	if language == "" {
+		language = models.LanguageRU
+	}
+	if language != models.LanguageRU && language != models.LanguageEN {
+		writeBadRequest(w, r, "Invalid lang parameter")
+		return
+	}
+	units := query.Get("units")
+	if units == "" {
+		units = models.UnitsCelsius
+	}
+	if units != models.UnitsCelsius && units != models.UnitsFahrenheit {
+		writeBadRequest(w, r, "Invalid units parameter")
+		return
+	}
+
+	card, err := c.cardUseCase.GetCityCard(r.Context(), cityName, language, units)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get weather card for city", "city", cityName, "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(card)
 }
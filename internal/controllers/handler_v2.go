@@ -0,0 +1,160 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/middleware"
+	"weather-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// unitsV2 единицы измерения Open-Meteo по умолчанию
+var unitsV2 = dto.UnitsV2{
+	Temperature:   "celsius",
+	WindSpeed:     "km/h",
+	WindDirection: "degrees",
+}
+
+// GetWeatherV2 получает погоду по координатам в формате API v2
+func (c *WeatherController) GetWeatherV2(w http.ResponseWriter, r *http.Request) {
+	params, detail := parseCoordinates(r)
+	if detail != "" {
+		writeBadRequest(w, r, detail)
+		return
+	}
+
+	report, err := c.weatherUseCase.GetWeatherReport(r.Context(), params)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get weather", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	writeV2Cached(w, r, toWeatherResultV2(report), report.ObservedAt, report.ExpiresIn)
+}
+
+// GetWeatherByCityV2 получает погоду по названию города в формате API v2
+func (c *WeatherController) GetWeatherByCityV2(w http.ResponseWriter, r *http.Request) {
+	cityName := mux.Vars(r)["city"]
+	if cityName == "" {
+		writeBadRequest(w, r, "City name is required")
+		return
+	}
+
+	report, err := c.weatherUseCase.GetWeatherReportByCity(r.Context(), cityName)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get weather for city", "city", cityName, "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	writeV2Cached(w, r, toWeatherResultV2(report), report.ObservedAt, report.ExpiresIn)
+}
+
+// GetAllCitiesV2 получает список всех городов в формате API v2
+func (c *WeatherController) GetAllCitiesV2(w http.ResponseWriter, r *http.Request) {
+	cities, err := c.weatherUseCase.GetAllCities(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to get cities", "error", err)
+		writeError(w, r, err)
+		return
+	}
+
+	writeV2Cached(w, r, toCitiesV2(cities), time.Time{}, c.weatherUseCase.CitiesExpiresIn(r.Context()))
+}
+
+// CreateCityV2 добавляет город в справочник. Часовой пояс определяется по координатам.
+func (c *WeatherController) CreateCityV2(w http.ResponseWriter, r *http.Request) {
+	var request dto.CreateCityRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes)).Decode(&request); err != nil {
+		writeBadRequest(w, r, "Invalid request body")
+		return
+	}
+	if request.Name == "" || request.Country == "" {
+		writeBadRequest(w, r, "City name and country are required")
+		return
+	}
+
+	if !checkAcceptable[dto.ResponseV2[dto.CityV2]](w, r) {
+		return
+	}
+
+	city, err := c.adminUseCase.AddCity(r.Context(), models.City{
+		Name:      request.Name,
+		Country:   request.Country,
+		Latitude:  request.Latitude,
+		Longitude: request.Longitude,
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to create city", "city", request.Name, "error", err)
+		writeError(w, r, err)
+		return
+	}
+	if key, ok := middleware.APIKeyFromContext(r.Context()); ok {
+		slog.InfoContext(r.Context(), "city created", "city", city.Name, "key_id", key.ID)
+	}
+
+	writeResponseStatus(w, r, http.StatusCreated, dto.ResponseV2[dto.CityV2]{
+		APIVersion: dto.APIVersionV2,
+		Data:       toCitiesV2([]models.City{*city})[0],
+	})
+}
+
+// writeV2 отправляет данные в конверте API v2
+func writeV2[T any](w http.ResponseWriter, r *http.Request, data T) {
+	writeResponse(w, r, dto.ResponseV2[T]{
+		APIVersion: dto.APIVersionV2,
+		Data:       data,
+	})
+}
+
+// writeV2Cached отправляет данные в конверте API v2 с заголовками кэширования
+func writeV2Cached[T any](w http.ResponseWriter, r *http.Request, data T, lastModified time.Time, maxAge time.Duration) {
+	writeCached(w, r, dto.ResponseV2[T]{
+		APIVersion: dto.APIVersionV2,
+		Data:       data,
+	}, lastModified, maxAge)
+}
+
+func toWeatherResultV2(report *dto.WeatherReport) dto.WeatherResultV2 {
+	result := dto.WeatherResultV2{
+		Location: dto.LocationV2{
+			Name:      report.Location.Name,
+			Country:   report.Location.Country,
+			Latitude:  report.Location.Latitude,
+			Longitude: report.Location.Longitude,
+			Timezone:  report.Location.Timezone,
+		},
+		Units: unitsV2,
+		Current: dto.CurrentWeatherV2{
+			Temperature:   report.Temperature,
+			WindSpeed:     report.WindSpeed,
+			WindDirection: report.WindDirection,
+			WeatherCode:   report.WeatherCode,
+			WeatherDesc:   report.WeatherDesc,
+		},
+	}
+	if !report.ObservedAt.IsZero() {
+		observedAt := report.ObservedAt
+		result.Current.ObservedAt = &observedAt
+	}
+	return result
+}
+
+func toCitiesV2(cities []models.City) []dto.CityV2 {
+	result := make([]dto.CityV2, 0, len(cities))
+	for _, city := range cities {
+		result = append(result, dto.CityV2{
+			Name:      city.Name,
+			Country:   city.Country,
+			Latitude:  city.Latitude,
+			Longitude: city.Longitude,
+			Timezone:  city.Timezone,
+		})
+	}
+	return result
+}
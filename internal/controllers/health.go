@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"weather-api/internal/dto"
+)
+
+// Livez отвечает, что процесс жив. Зависимости не проверяются.
+func (c *WeatherController) Livez(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, c.healthUseCase.Liveness())
+}
+
+// Readyz отвечает 503, если не работает критичная зависимость или приложение останавливается
+func (c *WeatherController) Readyz(w http.ResponseWriter, r *http.Request) {
+	report := c.healthUseCase.Readiness(r.Context())
+	status := http.StatusOK
+	if report.Status == dto.HealthStatusFailing {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(w, status, report)
+}
+
+// writeHealth всегда отвечает JSON: пробы балансировщиков не согласуют формат
+func writeHealth(w http.ResponseWriter, status int, report *dto.HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(report)
+}
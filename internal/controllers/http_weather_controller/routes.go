@@ -2,41 +2,207 @@ package http_weather_controller
 
 import (
 	"net/http"
+	"time"
 	"weather-api/internal/controllers"
+	"weather-api/internal/dto"
 	"weather-api/internal/middleware"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
 	"weather-api/pkg/metrics"
 
 	"github.com/gorilla/mux"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-// SetupRoutes настраивает маршруты для HTTP API
-func SetupRoutes(controller *controllers.WeatherController, metrics *metrics.Metrics) *mux.Router {
+// RoutesOptions параметры маршрутов HTTP API
+type RoutesOptions struct {
+	// V1DeprecatedAt и V1Sunset попадают в заголовки Deprecation и Sunset ответов v1
+	V1DeprecatedAt time.Time
+	V1Sunset       time.Time
+	// GraphQL обработчик /graphql, если nil, маршрут не регистрируется
+	GraphQL http.Handler
+	// APIKeys проверка ключей доступа к /api и /graphql, если nil, API открыт
+	APIKeys middleware.APIKeyAuthenticator
+	// AdminToken токен маршрутов /admin, если пустой, они не регистрируются
+	AdminToken string
+	// RateLimiter ограничитель частоты запросов к /api и /graphql, если nil, частота не ограничивается
+	RateLimiter repository.RateLimiter
+	RateLimit   dto.RateLimit
+	// IPRateLimit корзина на адрес клиента до проверки ключа. Ограничивает перебор
+	// и запросы с неверными ключами, поэтому щедрее RateLimit.
+	IPRateLimit dto.RateLimit
+	// TrustForwardedFor брать адрес клиента для ограничения частоты и лога из X-Forwarded-For
+	TrustForwardedFor bool
+	// TelegramWebhook обработчик обновлений Telegram в режиме webhook, если nil, маршрут не регистрируется
+	TelegramWebhook http.Handler
+	// TelegramWebhookPath путь webhook, по умолчанию /telegram/webhook
+	TelegramWebhookPath string
+}
+
+// defaultTelegramWebhookPath путь webhook, описанный в openapi.json
+const defaultTelegramWebhookPath = "/telegram/webhook"
+
+// routeScopes права для маршрутов, которым мало models.ScopeWeatherRead
+var routeScopes = map[string]string{
+	http.MethodPost + " /api/v2/cities": models.ScopeCitiesWrite,
+}
+
+// scopeFor право, которое нужно ключу для запроса
+func scopeFor(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			if scope, ok := routeScopes[r.Method+" "+template]; ok {
+				return scope
+			}
+		}
+	}
+	return models.ScopeWeatherRead
+}
+
+// SetupRoutes настраивает маршруты для HTTP API.
+// Каждый маршрут должен быть описан в openapi.json, это проверяет routes_test.go.
+func SetupRoutes(controller *controllers.WeatherController, metrics *metrics.Metrics, options RoutesOptions) *mux.Router {
+	doc, err := loadOpenAPISpec()
+	if err != nil {
+		panic(err)
+	}
+	validation, err := middleware.OpenAPIValidationMiddleware(doc, controllers.InvalidRequest)
+	if err != nil {
+		panic(err)
+	}
+	deprecation := middleware.DeprecationMiddleware(options.V1DeprecatedAt, options.V1Sunset, "/api/v2")
+
+	// Без проверки ключей API открыт, как раньше. Ограничение частоты стоит после
+	// проверки ключа, чтобы считать запросы по ключу, а не по адресу. Запросы без
+	// верного ключа до него не доходят, поэтому перед проверкой ключа стоит
+	// отдельное ограничение по адресу клиента.
+	auth := func(next http.Handler) http.Handler { return next }
+	if options.APIKeys != nil {
+		keyAuth := middleware.APIKeyAuth(middleware.APIKeyAuthOptions{
+			Keys:    options.APIKeys,
+			Scope:   scopeFor,
+			OnError: controllers.AccessDenied,
+		})
+		auth = keyAuth
+		if options.RateLimiter != nil && options.IPRateLimit.Rate > 0 && options.IPRateLimit.Burst > 0 {
+			ipRateLimit := middleware.RateLimit(middleware.RateLimitOptions{
+				Limiter:           options.RateLimiter,
+				Limit:             options.IPRateLimit,
+				TrustForwardedFor: options.TrustForwardedFor,
+				ByClientIP:        true,
+				OnError:           controllers.AccessDenied,
+			})
+			auth = func(next http.Handler) http.Handler { return ipRateLimit(keyAuth(next)) }
+		}
+	}
+	rateLimit := func(next http.Handler) http.Handler { return next }
+	if options.RateLimiter != nil && options.RateLimit.Rate > 0 && options.RateLimit.Burst > 0 {
+		rateLimit = middleware.RateLimit(middleware.RateLimitOptions{
+			Limiter:           options.RateLimiter,
+			Limit:             options.RateLimit,
+			TrustForwardedFor: options.TrustForwardedFor,
+			OnError:           controllers.AccessDenied,
+		})
+	}
+
+	// Запросы к провайдеру сверх первого списываются с ключа и корзины как отдельные запросы
+	upstreamCharge := middleware.UpstreamChargeMiddleware()
+
 	router := mux.NewRouter()
 
+	// Ошибки маршрутизации отдаем в том же формате, что и остальные ошибки API
+	router.NotFoundHandler = http.HandlerFunc(controllers.NotFound)
+	router.MethodNotAllowedHandler = http.HandlerFunc(controllers.MethodNotAllowed)
+
+	// Трассировка идет первой, чтобы время и логи остальных middleware попали в span запроса
+	router.Use(middleware.TracingMiddleware())
+
+	// Идентификатор запроса нужен всем записям лога, поэтому он идет сразу после трассировки
+	router.Use(middleware.RequestIDMiddleware())
+
 	// Применяем middleware для сбора метрик ко всем маршрутам
 	router.Use(middleware.MetricsMiddleware(metrics))
 
-	// API маршруты
-	api := router.PathPrefix("/api").Subrouter()
+	// Одна запись лога на запрос
+	router.Use(middleware.AccessLogMiddleware(options.TrustForwardedFor))
 
-	// Маршрут для получения погоды по координатам
-	api.HandleFunc("/weather", controller.GetWeather).Methods(http.MethodGet)
+	// API v2. Версионные префиксы регистрируем раньше "/api", иначе их перехватит он.
+	v2 := router.PathPrefix("/api/v2").Subrouter()
+	v2.Use(auth, rateLimit, upstreamCharge, validation)
+	setupV2Routes(v2, controller)
 
-	// Маршрут для получения погоды по названию города
-	api.HandleFunc("/weather/city/{city}", controller.GetWeatherByCity).Methods(http.MethodGet)
+	// API v1: замороженный набор маршрутов. Доступен по "/api/v1" и по старому "/api".
+	for _, prefix := range []string{"/api/v1", "/api"} {
+		v1 := router.PathPrefix(prefix).Subrouter()
+		v1.Use(deprecation, auth, rateLimit, upstreamCharge, validation)
+		setupV1Routes(v1, controller)
+	}
 
-	// Маршрут для получения списка всех городов
-	api.HandleFunc("/cities", controller.GetAllCities).Methods(http.MethodGet)
+	// GraphQL поверх тех же usecase
+	if options.GraphQL != nil {
+		router.Handle("/graphql", auth(rateLimit(upstreamCharge(options.GraphQL)))).Methods(http.MethodGet, http.MethodPost)
+	}
+
+	// Выпуск и отзыв ключей доступа
+	if options.AdminToken != "" {
+		admin := router.PathPrefix("/admin").Subrouter()
+		admin.Use(middleware.AdminTokenAuth(options.AdminToken, controllers.AccessDenied), validation)
+		admin.HandleFunc("/keys", controller.ListAPIKeys).Methods(http.MethodGet)
+		admin.HandleFunc("/keys", controller.IssueAPIKey).Methods(http.MethodPost)
+		admin.HandleFunc("/keys/{id}", controller.RevokeAPIKey).Methods(http.MethodDelete)
+	}
+
+	// В режиме webhook обновления Telegram приходят на тот же HTTP сервер
+	if options.TelegramWebhook != nil {
+		path := options.TelegramWebhookPath
+		if path == "" {
+			path = defaultTelegramWebhookPath
+		}
+		router.Handle(path, options.TelegramWebhook).Methods(http.MethodPost)
+	}
 
 	// Маршрут для метрик Prometheus
 	router.Handle("/metrics", promhttp.Handler())
 
-	// Маршрут для проверки состояния сервиса
+	// Маршрут для проверки состояния сервиса. Оставлен для старых проверок,
+	// новым нужны /livez и /readyz.
 	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
 	}).Methods(http.MethodGet)
+	router.HandleFunc("/livez", controller.Livez).Methods(http.MethodGet)
+	router.HandleFunc("/readyz", controller.Readyz).Methods(http.MethodGet)
+
+	// Спецификация OpenAPI и документация
+	router.HandleFunc("/openapi.json", serveOpenAPISpec).Methods(http.MethodGet)
+	router.HandleFunc("/docs", serveSwaggerUI).Methods(http.MethodGet)
 
 	return router
 }
+
+// setupV1Routes маршруты API v1. Формат ответов не меняется, новые возможности идут в v2.
+func setupV1Routes(api *mux.Router, controller *controllers.WeatherController) {
+	// Маршрут для получения погоды по координатам
+	api.HandleFunc("/weather", controller.GetWeather).Methods(http.MethodGet)
+
+	// Маршрут для получения погоды по названию города
+	api.HandleFunc("/weather/city/{city}", controller.GetWeatherByCity).Methods(http.MethodGet)
+
+	// Маршрут для получения карточки погоды города в PNG
+	api.HandleFunc("/weather/city/{city}/card.png", controller.GetCityCard).Methods(http.MethodGet)
+
+	// Маршрут для получения списка всех городов
+	api.HandleFunc("/cities", controller.GetAllCities).Methods(http.MethodGet)
+}
+
+// setupV2Routes маршруты API v2 с ответами в конверте dto.ResponseV2
+func setupV2Routes(api *mux.Router, controller *controllers.WeatherController) {
+	api.HandleFunc("/weather", controller.GetWeatherV2).Methods(http.MethodGet)
+	api.HandleFunc("/weather/city/{city}", controller.GetWeatherByCityV2).Methods(http.MethodGet)
+	api.HandleFunc("/weather/city/{city}/card.png", controller.GetCityCard).Methods(http.MethodGet)
+	api.HandleFunc("/weather/city/{city}/stream", controller.StreamWeatherByCityV2).Methods(http.MethodGet)
+	api.HandleFunc("/ws", controller.WeatherWebSocket).Methods(http.MethodGet)
+	api.HandleFunc("/cities", controller.GetAllCitiesV2).Methods(http.MethodGet)
+	api.HandleFunc("/cities", controller.CreateCityV2).Methods(http.MethodPost)
+	api.HandleFunc("/weather/batch", controller.PostWeatherBatchV2).Methods(http.MethodPost)
+}
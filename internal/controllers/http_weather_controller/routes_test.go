@@ -0,0 +1,66 @@
+package http_weather_controller
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"weather-api/internal/controllers"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+)
+
+// TestRoutesDocumented проверяет, что у каждого маршрута, включая необязательные, есть описание в openapi.json
+func TestRoutesDocumented(t *testing.T) {
+	doc, err := loadOpenAPISpec()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stub := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	router := SetupRoutes(controllers.NewWeatherController(controllers.WeatherControllerOptions{}), nil, RoutesOptions{
+		GraphQL:         stub,
+		AdminToken:      "admin",
+		TelegramWebhook: stub,
+	})
+
+	if err := checkRoutesDocumented(router, doc); err != nil {
+		t.Error(err)
+	}
+}
+
+// checkRoutesDocumented проверяет, что у каждого маршрута есть описание в спецификации.
+// Маршрут без списка методов считается GET.
+func checkRoutesDocumented(router *mux.Router, doc *openapi3.T) error {
+	var missing []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			// Префиксы подроутеров не являются самостоятельными маршрутами
+			return nil
+		}
+		if route.GetHandler() == nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			methods = []string{http.MethodGet}
+		}
+
+		item := doc.Paths.Find(path)
+		for _, method := range methods {
+			if item == nil || item.GetOperation(method) == nil {
+				missing = append(missing, method+" "+path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("walk routes: %w", err)
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("routes missing from openapi spec: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package http_weather_controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	tgadapter "weather-api/internal/adapters/telegram"
+	"weather-api/internal/controllers"
+	"weather-api/internal/controllers/telegram"
+	"weather-api/internal/models"
+	"weather-api/internal/usecase"
+	"weather-api/pkg/metrics"
+)
+
+// testMetrics метрики регистрируются в общем реестре Prometheus только один раз на процесс
+var testMetrics = sync.OnceValue(metrics.NewMetrics)
+
+// fakeBotAPI фейковый сервер Bot API, запоминающий отправленные сообщения по чатам
+type fakeBotAPI struct {
+	mu   sync.Mutex
+	sent map[int64][]string
+}
+
+func (f *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case strings.HasSuffix(r.URL.Path, "/getMe"):
+		w.Write([]byte(`{"ok":true,"result":{"id":1,"is_bot":true,"first_name":"Test","username":"test_bot"}}`))
+	case strings.HasSuffix(r.URL.Path, "/setWebhook"):
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	case strings.HasSuffix(r.URL.Path, "/sendMessage"):
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		chatID, _ := strconv.ParseInt(r.PostForm.Get("chat_id"), 10, 64)
+		f.mu.Lock()
+		if f.sent == nil {
+			f.sent = make(map[int64][]string)
+		}
+		f.sent[chatID] = append(f.sent[chatID], r.PostForm.Get("text"))
+		f.mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":{"message_id":1,"date":0,"chat":{"id":` + strconv.FormatInt(chatID, 10) + `,"type":"private"}}}`))
+	default:
+		w.Write([]byte(`{"ok":false,"error_code":404,"description":"Not Found"}`))
+	}
+}
+
+func (f *fakeBotAPI) sentTo(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent[chatID]...)
+}
+
+// Фейковые репозитории для сценариев без базы и провайдера погоды
+type fakePreferencesRepository struct{}
+
+func (fakePreferencesRepository) Get(ctx context.Context, chatID int64) (*models.ChatPreferences, error) {
+	return models.DefaultChatPreferences(chatID), nil
+}
+
+func (fakePreferencesRepository) Save(ctx context.Context, preferences models.ChatPreferences) error {
+	return nil
+}
+
+type fakeCityRepository struct{}
+
+func (fakeCityRepository) GetCityByName(ctx context.Context, name string) (*models.City, error) {
+	return nil, models.ErrNotFound
+}
+
+func (fakeCityRepository) GetAllCities(ctx context.Context) ([]models.City, error) {
+	return nil, nil
+}
+
+func (fakeCityRepository) CreateCity(ctx context.Context, city models.City) error {
+	return nil
+}
+
+type fakeChatRepository struct{}
+
+func (fakeChatRepository) Touch(ctx context.Context, chatID int64) error {
+	return nil
+}
+
+func (fakeChatRepository) Count(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (fakeChatRepository) ListIDs(ctx context.Context) ([]int64, error) {
+	return nil, nil
+}
+
+type fakeCacheInvalidator struct{}
+
+func (fakeCacheInvalidator) InvalidateCity(ctx context.Context, name string) error {
+	return nil
+}
+
+func (fakeCacheInvalidator) InvalidateLocation(ctx context.Context, lat, lon float64) error {
+	return nil
+}
+
+type fakeWeatherRepository struct{}
+
+func (fakeWeatherRepository) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+	return nil, models.ErrUpstreamUnavailable
+}
+
+func (fakeWeatherRepository) WeatherForecast(ctx context.Context, params models.ForecastParams) (*models.ForecastResult, error) {
+	return nil, models.ErrUpstreamUnavailable
+}
+
+// TestTelegramWebhookRoute проверяет webhook через маршруты API вместе с
+// контроллером бота: обновление с верным секретом обрабатывается, а с неверным
+// отклоняется 401 до обработки
+func TestTelegramWebhookRoute(t *testing.T) {
+	fake := &fakeBotAPI{}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	bot, err := tgadapter.NewBot(tgadapter.BotOptions{
+		Token:         "token",
+		APIEndpoint:   server.URL + "/bot%s/%s",
+		Mode:          tgadapter.ModeWebhook,
+		WebhookURL:    "https://example.com/telegram/webhook",
+		WebhookSecret: "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(bot.Close)
+
+	tgController := telegram.NewTelegramController(telegram.TelegramControllerOptions{
+		Bot: bot,
+		PreferencesUseCase: usecase.NewPreferencesUseCase(usecase.PreferencesUseCaseOptions{
+			PreferencesRepository: fakePreferencesRepository{},
+			CityRepository:        fakeCityRepository{},
+		}),
+		AdminUseCase: usecase.NewAdminUseCase(usecase.AdminUseCaseOptions{
+			CityRepository:    fakeCityRepository{},
+			WeatherRepository: fakeWeatherRepository{},
+			ChatRepository:    fakeChatRepository{},
+			CityCache:         fakeCacheInvalidator{},
+			WeatherCache:      fakeCacheInvalidator{},
+		}),
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	started := make(chan struct{})
+	go func() {
+		defer close(started)
+		tgController.Start(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-started
+	})
+
+	router := SetupRoutes(controllers.NewWeatherController(controllers.WeatherControllerOptions{}), testMetrics(), RoutesOptions{
+		TelegramWebhook: bot.WebhookHandler(),
+	})
+
+	post := func(chatID int64, secret string) int {
+		body, err := json.Marshal(map[string]any{
+			"update_id": chatID,
+			"message": map[string]any{
+				"message_id": 1,
+				"date":       0,
+				"chat":       map[string]any{"id": chatID, "type": "private"},
+				"text":       "/unknown",
+				"entities":   []map[string]any{{"type": "bot_command", "offset": 0, "length": 8}},
+			},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/telegram/webhook", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if secret != "" {
+			req.Header.Set("X-Telegram-Bot-Api-Secret-Token", secret)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	const wrongChat, validChat = 200, 100
+	if code := post(wrongChat, "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("wrong secret: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := post(wrongChat, ""); code != http.StatusUnauthorized {
+		t.Fatalf("no secret: status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if code := post(validChat, "secret"); code != http.StatusOK {
+		t.Fatalf("valid secret: status = %d, want %d", code, http.StatusOK)
+	}
+
+	deadline := time.After(2 * time.Second)
+	for len(fake.sentTo(validChat)) == 0 {
+		select {
+		case <-deadline:
+			t.Fatal("controller did not handle the update")
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if got := fake.sentTo(validChat); len(got) != 1 {
+		t.Errorf("sent to valid chat %v, want one reply", got)
+	}
+	// Отклоненные обновления пришли раньше верного и успели бы обработаться вместе с ним
+	if got := fake.sentTo(wrongChat); len(got) != 0 {
+		t.Errorf("update with wrong secret was handled: %v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// defaultStreamHeartbeat интервал комментариев, не дающих прокси закрыть соединение
+	defaultStreamHeartbeat = 15 * time.Second
+	// streamRetry через сколько миллисекунд браузер переподключится после обрыва
+	streamRetry = 5000
+)
+
+// streams долгие соединения SSE и WebSocket. http.Server.Shutdown не дожидается
+// их сам: поток SSE никогда не простаивает, а WebSocket соединение перехвачено.
+type streams struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newStreams() *streams {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &streams{ctx: ctx, cancel: cancel}
+}
+
+// open учитывает новый поток. После начала остановки новые потоки не принимаются.
+func (s *streams) open() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	s.wg.Add(1)
+	return true
+}
+
+func (s *streams) done() {
+	s.wg.Done()
+}
+
+// CloseStreams закрывает потоки SSE и WebSocket и ждет завершения их обработчиков.
+// Вызывается при остановке сервера до http.Server.Shutdown.
+func (c *WeatherController) CloseStreams(ctx context.Context) error {
+	c.streams.mu.Lock()
+	c.streams.closed = true
+	c.streams.mu.Unlock()
+	c.streams.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.streams.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StreamWeatherByCityV2 отправляет изменения погоды в городе как Server-Sent Events в формате API v2.
+// Поток держится, пока клиент не отключится. Событие weather приходит при каждом
+// изменении погоды, его id можно передать в Last-Event-ID при переподключении,
+// чтобы получить пропущенные изменения.
+func (c *WeatherController) StreamWeatherByCityV2(w http.ResponseWriter, r *http.Request) {
+	cityName := mux.Vars(r)["city"]
+	if cityName == "" {
+		writeBadRequest(w, r, "City name is required")
+		return
+	}
+
+	var lastEventID int64
+	if header := r.Header.Get("Last-Event-ID"); header != "" {
+		id, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			writeBadRequest(w, r, "Invalid Last-Event-ID header")
+			return
+		}
+		lastEventID = id
+	}
+
+	if !c.streams.open() {
+		writeProblem(w, r, http.StatusServiceUnavailable, "Service Unavailable", "The server is shutting down.")
+		return
+	}
+	defer c.streams.done()
+
+	subscription, err := c.weatherWatcher.SubscribeCity(r.Context(), cityName, lastEventID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Failed to subscribe to weather for city", "city", cityName, "error", err)
+		writeError(w, r, err)
+		return
+	}
+	defer subscription.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	flusher := http.NewResponseController(w)
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if err := flusher.Flush(); err != nil {
+		slog.ErrorContext(r.Context(), "Streaming is not supported", "error", err)
+		return
+	}
+
+	heartbeat := time.NewTicker(c.streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-c.streams.ctx.Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case update := <-subscription.Updates():
+			data, err := json.Marshal(toWeatherResultV2(update.Report))
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Failed to encode weather update", "error", err)
+				return
+			}
+			fmt.Fprintf(w, "id: %d\nevent: weather\ndata: %s\n\n", update.ID, data)
+		}
+		if err := flusher.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"weather-api/internal/models"
+	"weather-api/internal/usecase"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// broadcastInterval пауза между сообщениями рассылки, чтобы оставить запас
+// лимитов Telegram для обычных ответов бота
+const broadcastInterval = 100 * time.Millisecond
+
+// isAdmin проверяет, входит ли пользователь в список администраторов
+func (c *TelegramController) isAdmin(userID int64) bool {
+	return slices.Contains(c.admins, userID)
+}
+
+// audit пишет запись аудита для административной команды. Чат записи берется из контекста.
+func audit(ctx context.Context, message *tgbotapi.Message, status string, args ...any) {
+	attrs := []any{
+		"audit", true,
+		"admin_id", message.From.ID,
+		"command", message.Command(),
+		"args", message.CommandArguments(),
+		"status", status,
+	}
+	slog.InfoContext(ctx, "admin command", append(attrs, args...)...)
+}
+
+// handleAdminCommand выполняет административную команду. Возвращает false,
+// если команда не административная.
+func (c *TelegramController) handleAdminCommand(ctx context.Context, message *tgbotapi.Message, preferences *models.ChatPreferences) bool {
+	command := message.Command()
+	switch command {
+	case "stats", "addcity", "broadcast", "flushcache":
+	default:
+		return false
+	}
+
+	if message.From == nil || !c.isAdmin(message.From.ID) {
+		if message.From != nil {
+			audit(ctx, message, "denied")
+		}
+		return false
+	}
+
+	language := preferences.Language
+	chatID := message.Chat.ID
+
+	switch command {
+	case "stats":
+		stats, err := c.adminUseCase.Stats(ctx)
+		if err != nil {
+			audit(ctx, message, "error", "error", err)
+			c.bot.SendMessage(chatID, text(language, "admin.error"), nil)
+			return true
+		}
+		audit(ctx, message, "ok")
+		c.bot.SendMessage(chatID, text(language, "admin.stats",
+			stats.Users,
+			stats.HttpRequests,
+			stats.TelegramUpdates,
+			stats.CacheHitRatio*100,
+			stats.CacheHits,
+			stats.CacheMisses,
+		), nil)
+
+	case "addcity":
+		city, ok := parseAddCityArgs(message.CommandArguments())
+		if !ok {
+			audit(ctx, message, "invalid")
+			c.bot.SendMessage(chatID, text(language, "admin.addcity.usage"), nil)
+			return true
+		}
+		created, err := c.adminUseCase.AddCity(ctx, city)
+		if err != nil {
+			audit(ctx, message, "error", "error", err)
+			if errors.Is(err, usecase.ErrInvalidCoordinates) {
+				c.bot.SendMessage(chatID, text(language, "admin.addcity.usage"), nil)
+				return true
+			}
+			c.bot.SendMessage(chatID, text(language, "admin.addcity.error"), nil)
+			return true
+		}
+		audit(ctx, message, "ok", "city", created.Name, "timezone", created.Timezone)
+		c.bot.SendMessage(chatID, text(language, "admin.addcity.ok", created.Name, created.Timezone), nil)
+
+	case "broadcast":
+		broadcastText := strings.TrimSpace(message.CommandArguments())
+		if broadcastText == "" {
+			audit(ctx, message, "invalid")
+			c.bot.SendMessage(chatID, text(language, "admin.broadcast.usage"), nil)
+			return true
+		}
+		recipients, err := c.adminUseCase.BroadcastRecipients(ctx)
+		if err != nil {
+			audit(ctx, message, "error", "error", err)
+			c.bot.SendMessage(chatID, text(language, "admin.error"), nil)
+			return true
+		}
+		audit(ctx, message, "started", "recipients", len(recipients))
+		c.bot.SendMessage(chatID, text(language, "admin.broadcast.started", len(recipients)), nil)
+
+		// Рассылка идет в фоне, чтобы не задерживать другие обновления этого чата
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			sent, failed, skipped := c.broadcast(ctx, recipients, broadcastText)
+			if skipped > 0 {
+				audit(ctx, message, "interrupted", "sent", sent, "failed", failed, "skipped", skipped)
+				c.bot.SendMessage(chatID, text(language, "admin.broadcast.stopped", sent, failed, skipped), nil)
+				return
+			}
+			audit(ctx, message, "finished", "sent", sent, "failed", failed)
+			c.bot.SendMessage(chatID, text(language, "admin.broadcast.done", sent, failed), nil)
+		}()
+
+	case "flushcache":
+		cityName := strings.TrimSpace(message.CommandArguments())
+		if cityName == "" {
+			audit(ctx, message, "invalid")
+			c.bot.SendMessage(chatID, text(language, "admin.flush.usage"), nil)
+			return true
+		}
+		if err := c.adminUseCase.FlushCityCache(ctx, cityName); err != nil {
+			audit(ctx, message, "error", "error", err)
+			c.bot.SendMessage(chatID, text(language, "admin.flush.error"), nil)
+			return true
+		}
+		audit(ctx, message, "ok")
+		c.bot.SendMessage(chatID, text(language, "admin.flush.ok", cityName), nil)
+	}
+
+	return true
+}
+
+// broadcast отправляет текст всем получателям с паузой между сообщениями.
+// Прогресс не сохраняется: при остановке контроллера рассылка прерывается,
+// и skipped показывает, скольким получателям сообщение не отправлялось.
+func (c *TelegramController) broadcast(ctx context.Context, recipients []int64, broadcastText string) (sent, failed, skipped int) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+
+	for _, recipient := range recipients {
+		select {
+		case <-ctx.Done():
+			return sent, failed, len(recipients) - sent - failed
+		case <-c.stopping:
+			return sent, failed, len(recipients) - sent - failed
+		case <-ticker.C:
+		}
+
+		if err := c.bot.SendMessage(recipient, broadcastText, nil); err != nil {
+			slog.WarnContext(ctx, "failed to deliver broadcast", "recipient_id", recipient, "error", err)
+			failed++
+			continue
+		}
+		sent++
+	}
+	return sent, failed, 0
+}
+
+// parseAddCityArgs разбирает "<название> <широта> <долгота> <страна>".
+// Название и страна могут содержать пробелы, поэтому ищем пару чисел подряд.
+func parseAddCityArgs(args string) (models.City, bool) {
+	fields := strings.Fields(args)
+	for i := 1; i+2 < len(fields); i++ {
+		lat, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			continue
+		}
+		lon, err := strconv.ParseFloat(fields[i+1], 64)
+		if err != nil {
+			continue
+		}
+		return models.City{
+			Name:      strings.Join(fields[:i], " "),
+			Latitude:  lat,
+			Longitude: lon,
+			Country:   strings.Join(fields[i+2:], " "),
+		}, true
+	}
+	return models.City{}, false
+}
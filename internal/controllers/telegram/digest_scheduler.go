@@ -0,0 +1,167 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"weather-api/internal/adapters/telegram"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/usecase"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// unclaimTimeout сколько ждать возврата подписок в очередь при остановке
+const unclaimTimeout = 5 * time.Second
+
+// DigestScheduler рассылает ежедневные сводки погоды подписанным чатам.
+// Состояние отправки хранится в Postgres, поэтому планировщик переживает
+// перезапуски и может работать одновременно в нескольких репликах.
+type DigestScheduler struct {
+	bot                 *telegram.Bot
+	weatherUseCase      *usecase.WeatherUseCase
+	subscriptionUseCase *usecase.SubscriptionUseCase
+	preferencesUseCase  *usecase.PreferencesUseCase
+	interval            time.Duration
+}
+
+// DigestSchedulerOptions параметры для создания планировщика
+type DigestSchedulerOptions struct {
+	Bot                 *telegram.Bot
+	WeatherUseCase      *usecase.WeatherUseCase
+	SubscriptionUseCase *usecase.SubscriptionUseCase
+	PreferencesUseCase  *usecase.PreferencesUseCase
+	// Interval период проверки подписок, по умолчанию минута
+	Interval time.Duration
+}
+
+func NewDigestScheduler(options DigestSchedulerOptions) *DigestScheduler {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	return &DigestScheduler{
+		bot:                 options.Bot,
+		weatherUseCase:      options.WeatherUseCase,
+		subscriptionUseCase: options.SubscriptionUseCase,
+		preferencesUseCase:  options.PreferencesUseCase,
+		interval:            interval,
+	}
+}
+
+// Run проверяет подписки с заданным интервалом, пока не отменен контекст
+func (s *DigestScheduler) Run(ctx context.Context) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendDue забирает подписки, время которых наступило, и отправляет сводки.
+// Если сводку доставить не удалось, подписка возвращается в очередь. При остановке
+// рассылка прерывается, а неотправленные подписки возвращаются в очередь без учета попытки.
+func (s *DigestScheduler) sendDue(ctx context.Context) {
+	subscriptions, err := s.subscriptionUseCase.ClaimDue(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to claim due subscriptions", "error", err)
+		return
+	}
+
+	for i, subscription := range subscriptions {
+		if ctx.Err() != nil {
+			s.unclaim(ctx, subscriptions[i:])
+			return
+		}
+
+		ctx := logger.With(ctx, "chat_id", subscription.ChatID, "city", subscription.CityName)
+		err := s.sendDigest(ctx, subscription)
+		switch {
+		case err != nil && ctx.Err() != nil:
+			// Сводку прервала остановка сервиса, а не ошибка доставки
+			s.unclaim(ctx, subscriptions[i:])
+			return
+		case err == nil:
+			if err := s.subscriptionUseCase.Delivered(ctx, subscription); err != nil {
+				slog.WarnContext(ctx, "failed to reset digest attempts", "error", err)
+			}
+		case errors.Is(err, telegram.ErrChatUnavailable):
+			// Пользователь заблокировал бота или чата нет: повторы не помогут
+			slog.InfoContext(ctx, "chat is unavailable, disabling subscription", "error", err)
+			if err := s.subscriptionUseCase.Disable(ctx, subscription.ChatID); err != nil {
+				slog.ErrorContext(ctx, "failed to disable subscription", "error", err)
+			}
+		default:
+			slog.ErrorContext(ctx, "failed to send digest", "attempt", subscription.FailedAttempts+1, "error", err)
+			err := s.subscriptionUseCase.Release(ctx, subscription)
+			if errors.Is(err, usecase.ErrDigestAttemptsExhausted) {
+				slog.WarnContext(ctx, "giving up on today's digest", "attempts", subscription.FailedAttempts+1)
+			} else if err != nil {
+				slog.ErrorContext(ctx, "failed to release subscription", "error", err)
+			}
+		}
+	}
+}
+
+// unclaim возвращает подписки в очередь. Контекст рассылки уже отменен,
+// поэтому запросы к базе выполняются с отдельным таймаутом.
+func (s *DigestScheduler) unclaim(ctx context.Context, subscriptions []models.Subscription) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), unclaimTimeout)
+	defer cancel()
+
+	for _, subscription := range subscriptions {
+		if err := s.subscriptionUseCase.Unclaim(ctx, subscription); err != nil {
+			slog.ErrorContext(ctx, "failed to unclaim subscription", "chat_id", subscription.ChatID, "error", err)
+		}
+	}
+	slog.InfoContext(ctx, "digest delivery interrupted by shutdown", "unclaimed", len(subscriptions))
+}
+
+func (s *DigestScheduler) sendDigest(ctx context.Context, subscription models.Subscription) (err error) {
+	ctx, span := tracer.Start(ctx, "telegram.digest", trace.WithAttributes(
+		attribute.Int64("telegram.chat_id", subscription.ChatID),
+		attribute.String("city", subscription.CityName),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	// Сводка может тратить резерв лимита запросов к провайдеру погоды
+	ctx = models.WithScheduledPriority(ctx)
+	forecast, err := s.weatherUseCase.GetForecastByCity(ctx, subscription.CityName, 1)
+	if err != nil {
+		return err
+	}
+	if len(forecast.Daily) == 0 {
+		return fmt.Errorf("empty forecast for %s", subscription.CityName)
+	}
+
+	preferences, err := s.preferencesUseCase.GetPreferences(ctx, subscription.ChatID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to get chat preferences", "error", err)
+		preferences = models.DefaultChatPreferences(subscription.ChatID)
+	}
+
+	return s.bot.SendMessage(subscription.ChatID, formatDigest(subscription.CityName, forecast.Daily[0], preferences), nil)
+}
+
+func formatDigest(cityName string, day dto.DailyForecast, preferences *models.ChatPreferences) string {
+	return text(preferences.Language, "digest",
+		cityName,
+		models.FormatTemperature(day.TemperatureMin, preferences.Units),
+		models.FormatTemperature(day.TemperatureMax, preferences.Units),
+		day.Precipitation,
+		models.GetWeatherDescriptionLang(day.WeatherCode, preferences.Language),
+	)
+}
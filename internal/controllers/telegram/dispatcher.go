@@ -0,0 +1,199 @@
+package telegram
+
+import (
+	"context"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+	"weather-api/pkg/logger"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Значения по умолчанию для пула обработчиков
+const (
+	defaultWorkers   = 8
+	defaultQueueSize = 100
+)
+
+// dispatch обрабатывает обновления бота пулом воркеров, пока не отменен контекст
+func (c *TelegramController) dispatch(ctx context.Context, updates tgbotapi.UpdatesChannel) {
+	dispatchUpdates(ctx, updates, c.workers, c.queueSize, c.safeHandleUpdate)
+}
+
+// dispatchUpdates раскладывает обновления по очередям чатов и обрабатывает их
+// пулом из workers воркеров. Обновления одного чата обрабатываются строго по
+// очереди, а любой свободный воркер берет следующий чат, у которого нет
+// обновления в обработке, поэтому медленный чат не задерживает остальные.
+// Прием обновлений никогда не ждет обработчиков: если в очереди чата уже
+// queueSize обновлений, новое обновление этого чата отбрасывается.
+//
+// Прием прекращается при отмене контекста или закрытии канала обновлений,
+// после чего уже принятые обновления дообрабатываются. Обработчики получают
+// контекст без отмены: ответы на принятые обновления прерывает только закрытие бота.
+func dispatchUpdates(ctx context.Context, updates tgbotapi.UpdatesChannel, workers, queueSize int, handle func(context.Context, tgbotapi.Update)) {
+	queues := newChatQueues(queueSize)
+	handleCtx := context.WithoutCancel(ctx)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			queues.work(handleCtx, handle)
+		}()
+	}
+
+	// Закрываем очереди и ждем, пока воркеры обработают принятые обновления
+	defer func() {
+		queues.close()
+		wg.Wait()
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case update, ok := <-updates:
+			if !ok {
+				return
+			}
+			if !queues.push(updateChatID(update), update) {
+				slog.WarnContext(ctx, "telegram chat queue is full, update dropped",
+					"update_id", update.UpdateID, "chat_id", updateChatID(update))
+			}
+		}
+	}
+}
+
+// chatQueues очереди обновлений по чатам. Чат попадает в ready, когда у него
+// есть обновления и ни одно из них не обрабатывается, поэтому один чат
+// обрабатывает не больше одного воркера одновременно.
+type chatQueues struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+	// chats ожидающие обновления по чатам. Чат есть в карте, пока у него есть
+	// ожидающие обновления или обновление в обработке.
+	chats  map[int64][]tgbotapi.Update
+	ready  []int64
+	limit  int
+	closed bool
+}
+
+func newChatQueues(limit int) *chatQueues {
+	q := &chatQueues{
+		chats: make(map[int64][]tgbotapi.Update),
+		limit: limit,
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// push добавляет обновление в очередь чата. Возвращает false, если очередь
+// чата заполнена или очереди закрыты.
+func (q *chatQueues) push(chatID int64, update tgbotapi.Update) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return false
+	}
+	pending, active := q.chats[chatID]
+	if len(pending) >= q.limit {
+		return false
+	}
+	q.chats[chatID] = append(pending, update)
+	if !active {
+		q.ready = append(q.ready, chatID)
+		q.cond.Signal()
+	}
+	return true
+}
+
+// next ждет чат, готовый к обработке, и забирает его первое обновление.
+// Возвращает false, когда очереди закрыты и готовых чатов не осталось.
+func (q *chatQueues) next() (int64, tgbotapi.Update, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.ready) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.ready) == 0 {
+		return 0, tgbotapi.Update{}, false
+	}
+	chatID := q.ready[0]
+	q.ready = q.ready[1:]
+	pending := q.chats[chatID]
+	update := pending[0]
+	q.chats[chatID] = pending[1:]
+	return chatID, update, true
+}
+
+// done отмечает, что обновление чата обработано. Если у чата остались
+// обновления, он снова встает в конец ready.
+func (q *chatQueues) done(chatID int64) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.chats[chatID]) == 0 {
+		delete(q.chats, chatID)
+		return
+	}
+	q.ready = append(q.ready, chatID)
+	q.cond.Signal()
+}
+
+// close запрещает новые обновления и будит ждущих воркеров
+func (q *chatQueues) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// work обрабатывает готовые чаты, пока очереди не закрыты и не опустели
+func (q *chatQueues) work(ctx context.Context, handle func(context.Context, tgbotapi.Update)) {
+	for {
+		chatID, update, ok := q.next()
+		if !ok {
+			return
+		}
+		handle(ctx, update)
+		q.done(chatID)
+	}
+}
+
+// safeHandleUpdate не дает панике в обработчике остановить воркер
+func (c *TelegramController) safeHandleUpdate(ctx context.Context, update tgbotapi.Update) {
+	chatID := updateChatID(update)
+	ctx, span := tracer.Start(ctx, "telegram.update", trace.WithSpanKind(trace.SpanKindConsumer), trace.WithAttributes(
+		attribute.Int("telegram.update_id", update.UpdateID),
+		attribute.Int64("telegram.chat_id", chatID),
+	))
+	defer span.End()
+
+	// Все записи лога при обработке обновления получат его чат
+	ctx = logger.With(ctx, "update_id", update.UpdateID, "chat_id", chatID)
+
+	defer func() {
+		if r := recover(); r != nil {
+			slog.ErrorContext(ctx, "telegram update handler panicked", "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	c.handleUpdate(ctx, update)
+}
+
+// updateChatID возвращает чат, к которому относится обновление. Для inline-запросов
+// и кнопок под inline-сообщениями чата нет, используется пользователь.
+func updateChatID(update tgbotapi.Update) int64 {
+	switch {
+	case update.Message != nil:
+		return update.Message.Chat.ID
+	case update.CallbackQuery != nil && update.CallbackQuery.Message != nil:
+		return update.CallbackQuery.Message.Chat.ID
+	case update.CallbackQuery != nil:
+		return update.CallbackQuery.From.ID
+	case update.InlineQuery != nil:
+		return update.InlineQuery.From.ID
+	}
+	return 0
+}
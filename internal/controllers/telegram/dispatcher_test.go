@@ -0,0 +1,230 @@
+package telegram
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeBot фейковый источник обновлений, запоминающий обработанные обновления
+// по чатам. Обработка обновлений чата из block ждет закрытия release.
+type fakeBot struct {
+	updates chan tgbotapi.Update
+	block   map[int64]bool
+	release chan struct{}
+
+	mu      sync.Mutex
+	handled map[int64][]int
+	started chan int64
+}
+
+func newFakeBot(block ...int64) *fakeBot {
+	f := &fakeBot{
+		updates: make(chan tgbotapi.Update),
+		block:   make(map[int64]bool),
+		release: make(chan struct{}),
+		handled: make(map[int64][]int),
+		started: make(chan int64, 1000),
+	}
+	for _, chatID := range block {
+		f.block[chatID] = true
+	}
+	return f
+}
+
+func (f *fakeBot) send(t *testing.T, chatID int64, updateID int) {
+	t.Helper()
+	update := tgbotapi.Update{
+		UpdateID: updateID,
+		Message:  &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatID}},
+	}
+	select {
+	case f.updates <- update:
+	case <-time.After(time.Second):
+		t.Fatalf("update %d of chat %d was not received", updateID, chatID)
+	}
+}
+
+func (f *fakeBot) handle(ctx context.Context, update tgbotapi.Update) {
+	chatID := updateChatID(update)
+	f.started <- chatID
+	if f.block[chatID] {
+		<-f.release
+	}
+	f.mu.Lock()
+	f.handled[chatID] = append(f.handled[chatID], update.UpdateID)
+	f.mu.Unlock()
+}
+
+func (f *fakeBot) handledOf(chatID int64) []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]int(nil), f.handled[chatID]...)
+}
+
+func (f *fakeBot) run(ctx context.Context, workers, queueSize int) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dispatchUpdates(ctx, f.updates, workers, queueSize, f.handle)
+	}()
+	return done
+}
+
+func waitDone(t *testing.T, done <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatcher did not stop")
+	}
+}
+
+// TestDispatchSlowChatDoesNotBlockOthers проверяет, что медленный чат не
+// задерживает обновления другого чата и прием новых обновлений
+func TestDispatchSlowChatDoesNotBlockOthers(t *testing.T) {
+	const chatA, chatB = 1, 2
+	bot := newFakeBot(chatA)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Воркеров меньше, чем обновлений чата A: раньше их очередь шла к одному воркеру
+	done := bot.run(ctx, 2, 10)
+
+	for i := 1; i <= 5; i++ {
+		bot.send(t, chatA, i)
+	}
+	for i := 11; i <= 13; i++ {
+		bot.send(t, chatB, i)
+	}
+
+	deadline := time.After(time.Second)
+	for len(bot.handledOf(chatB)) < 3 {
+		select {
+		case <-deadline:
+			t.Fatalf("chat B handled %v while chat A is blocked", bot.handledOf(chatB))
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+	if got := bot.handledOf(chatA); len(got) != 0 {
+		t.Fatalf("chat A handled %v before release", got)
+	}
+
+	close(bot.release)
+	cancel()
+	waitDone(t, done)
+}
+
+// TestDispatchKeepsChatOrder проверяет, что обновления одного чата
+// обрабатываются по порядку даже при нескольких воркерах
+func TestDispatchKeepsChatOrder(t *testing.T) {
+	bot := newFakeBot()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := bot.run(ctx, 4, 100)
+
+	const updates = 50
+	for i := 1; i <= updates; i++ {
+		bot.send(t, 1, i)
+		bot.send(t, 2, 100+i)
+	}
+	close(bot.updates)
+	waitDone(t, done)
+
+	for chatID, offset := range map[int64]int{1: 0, 2: 100} {
+		got := bot.handledOf(chatID)
+		if len(got) != updates {
+			t.Fatalf("chat %d handled %d updates, want %d", chatID, len(got), updates)
+		}
+		for i, updateID := range got {
+			if updateID != offset+i+1 {
+				t.Fatalf("chat %d handled out of order: %v", chatID, got)
+			}
+		}
+	}
+}
+
+// TestDispatchDropsOverflow проверяет, что переполненная очередь чата не
+// останавливает прием обновлений, а лишние обновления этого чата отбрасываются
+func TestDispatchDropsOverflow(t *testing.T) {
+	const chatA, chatB = 1, 2
+	bot := newFakeBot(chatA)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := bot.run(ctx, 1, 2)
+
+	bot.send(t, chatA, 1)
+	<-bot.started
+	// Обновление 1 в обработке, 2 и 3 в очереди, 4 не помещается
+	for i := 2; i <= 4; i++ {
+		bot.send(t, chatA, i)
+	}
+	bot.send(t, chatB, 11)
+
+	close(bot.release)
+	close(bot.updates)
+	waitDone(t, done)
+
+	if got := bot.handledOf(chatA); len(got) != 3 || got[2] != 3 {
+		t.Errorf("chat A handled %v, want [1 2 3]", got)
+	}
+	if got := bot.handledOf(chatB); len(got) != 1 {
+		t.Errorf("chat B handled %v, want [11]", got)
+	}
+}
+
+// TestDispatchShutdown проверяет, что после отмены контекста диспетчер
+// перестает принимать обновления, но дообрабатывает уже принятые
+func TestDispatchShutdown(t *testing.T) {
+	const chatA, chatB = 1, 2
+	bot := newFakeBot(chatA)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := bot.run(ctx, 2, 10)
+
+	for i := 1; i <= 3; i++ {
+		bot.send(t, chatA, i)
+	}
+	bot.send(t, chatB, 11)
+	<-bot.started
+	cancel()
+
+	select {
+	case <-done:
+		t.Fatal("dispatcher returned before queued updates were handled")
+	case <-time.After(20 * time.Millisecond):
+	}
+	select {
+	case bot.updates <- tgbotapi.Update{UpdateID: 12, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: chatB}}}:
+		t.Fatal("update received after cancellation")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	close(bot.release)
+	waitDone(t, done)
+	if got := bot.handledOf(chatA); len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf("chat A handled %v, want [1 2 3]", got)
+	}
+	if got := bot.handledOf(chatB); len(got) != 1 || got[0] != 11 {
+		t.Errorf("chat B handled %v, want [11]", got)
+	}
+}
+
+// TestDispatchHandlersOutliveCancellation проверяет, что обработчики получают
+// контекст, который не отменяется вместе с приемом обновлений
+func TestDispatchHandlersOutliveCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	updates := make(chan tgbotapi.Update, 1)
+	updates <- tgbotapi.Update{UpdateID: 1, Message: &tgbotapi.Message{Chat: &tgbotapi.Chat{ID: 1}}}
+	close(updates)
+
+	var handlerErr error
+	dispatchUpdates(ctx, updates, 1, 10, func(ctx context.Context, update tgbotapi.Update) {
+		cancel()
+		handlerErr = ctx.Err()
+	})
+	if handlerErr != nil {
+		t.Errorf("handler context error = %v, want nil", handlerErr)
+	}
+}
@@ -0,0 +1,215 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"weather-api/internal/models"
+	"weather-api/internal/usecase"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// settingsPrefix префикс callback data кнопок настроек: "settings:действие[:значение]".
+// Город передается как "#id", чтобы уложиться в 64 байта callback data.
+const settingsPrefix = "settings:"
+
+// Действия в меню настроек
+const (
+	settingsMenu      = "menu"
+	settingsLanguage  = "lang"
+	settingsUnits     = "units"
+	settingsHome      = "home"
+	settingsFavourite = "fav"
+	settingsDone      = "done"
+)
+
+func settingsData(action string, value ...string) string {
+	return settingsPrefix + strings.Join(append([]string{action}, value...), ":")
+}
+
+// settingsCity возвращает название города из значения кнопки: "#id" или
+// название в кнопках, отправленных раньше
+func (c *TelegramController) settingsCity(ctx context.Context, value string) (string, error) {
+	id, ok := strings.CutPrefix(value, "#")
+	if !ok {
+		return value, nil
+	}
+	cityID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid city id %q: %w", value, models.ErrInvalidInput)
+	}
+	city, err := c.usecase.GetCityByID(ctx, cityID)
+	if err != nil {
+		return "", err
+	}
+	return city.Name, nil
+}
+
+// sendSettings отправляет меню настроек чата
+func (c *TelegramController) sendSettings(chatID int64, preferences *models.ChatPreferences) {
+	c.bot.SendMessage(chatID, settingsText(preferences), settingsKeyboard(preferences))
+}
+
+func settingsText(preferences *models.ChatPreferences) string {
+	language := preferences.Language
+
+	home := preferences.HomeCity
+	if home == "" {
+		home = text(language, "settings.none")
+	}
+	favourites := strings.Join(preferences.FavouriteCities, ", ")
+	if favourites == "" {
+		favourites = text(language, "settings.none")
+	}
+
+	return text(language, "settings.title", text(language, "language.name"), unitsLabel(preferences.Units), home, favourites)
+}
+
+func settingsKeyboard(preferences *models.ChatPreferences) tgbotapi.InlineKeyboardMarkup {
+	language := preferences.Language
+
+	nextLanguage := models.LanguageEN
+	if language == models.LanguageEN {
+		nextLanguage = models.LanguageRU
+	}
+	nextUnits := models.UnitsFahrenheit
+	if preferences.Units == models.UnitsFahrenheit {
+		nextUnits = models.UnitsCelsius
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "settings.language"), settingsData(settingsLanguage, nextLanguage)),
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "settings.units", unitsLabel(preferences.Units)), settingsData(settingsUnits, nextUnits)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "settings.home"), settingsData(settingsHome)),
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "settings.favourite"), settingsData(settingsFavourite)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.done"), settingsData(settingsDone)),
+		),
+	)
+}
+
+func unitsLabel(units string) string {
+	if units == models.UnitsFahrenheit {
+		return "°F"
+	}
+	return "°C"
+}
+
+// handleSettingsCallback обрабатывает кнопки меню настроек, редактируя сообщение с меню
+func (c *TelegramController) handleSettingsCallback(ctx context.Context, query *tgbotapi.CallbackQuery, preferences *models.ChatPreferences) {
+	if query.Message == nil {
+		c.bot.AnswerCallbackQuery(query.ID, text(preferences.Language, "error.stale_button"))
+		return
+	}
+	chatID := query.Message.Chat.ID
+
+	action, value, hasValue := strings.Cut(strings.TrimPrefix(query.Data, settingsPrefix), ":")
+
+	updated := preferences
+	var err error
+	switch action {
+	case settingsMenu:
+	case settingsLanguage:
+		updated, err = c.preferencesUseCase.SetLanguage(ctx, chatID, value)
+	case settingsUnits:
+		updated, err = c.preferencesUseCase.SetUnits(ctx, chatID, value)
+	case settingsHome:
+		if !hasValue {
+			c.showCityPicker(ctx, query, preferences, settingsHome)
+			return
+		}
+		// Пустое значение снимает домашний город
+		var city string
+		if city, err = c.settingsCity(ctx, value); err == nil {
+			updated, err = c.preferencesUseCase.SetHomeCity(ctx, chatID, city)
+		}
+	case settingsFavourite:
+		if !hasValue {
+			c.showCityPicker(ctx, query, preferences, settingsFavourite)
+			return
+		}
+		var city string
+		if city, err = c.settingsCity(ctx, value); err == nil {
+			updated, err = c.preferencesUseCase.ToggleFavourite(ctx, chatID, city)
+		}
+		if err == nil {
+			// Остаемся в списке, чтобы можно было отметить несколько городов
+			c.showCityPicker(ctx, query, updated, settingsFavourite)
+			return
+		}
+	case settingsDone:
+		c.editMessage(ctx, query, text(preferences.Language, "settings.saved"), nil)
+		c.bot.AnswerCallbackQuery(query.ID, "")
+		c.sendMainMenu(ctx, chatID, preferences)
+		return
+	default:
+		c.bot.AnswerCallbackQuery(query.ID, text(preferences.Language, "error.stale_button"))
+		return
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update chat preferences", "data", query.Data, "error", err)
+		message := text(preferences.Language, "error.settings")
+		if errors.Is(err, usecase.ErrTooManyFavourites) {
+			message = text(preferences.Language, "settings.too_many")
+		}
+		c.bot.AnswerCallbackQuery(query.ID, message)
+		return
+	}
+
+	keyboard := settingsKeyboard(updated)
+	c.editMessage(ctx, query, settingsText(updated), &keyboard)
+	c.bot.AnswerCallbackQuery(query.ID, "")
+}
+
+// showCityPicker показывает список городов для выбора домашнего или избранных
+func (c *TelegramController) showCityPicker(ctx context.Context, query *tgbotapi.CallbackQuery, preferences *models.ChatPreferences, action string) {
+	language := preferences.Language
+
+	cities, err := c.usecase.GetAllCities(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get cities", "error", err)
+		c.bot.AnswerCallbackQuery(query.ID, text(language, "error.cities"))
+		return
+	}
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, city := range cities {
+		selected := city.Name == preferences.HomeCity
+		if action == settingsFavourite {
+			selected = slices.Contains(preferences.FavouriteCities, city.Name)
+		}
+		label := city.Name
+		if selected {
+			label = "✓ " + label
+		}
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, settingsData(action, "#"+strconv.FormatInt(city.ID, 10))),
+		))
+	}
+	if action == settingsHome {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.no_home"), settingsData(settingsHome, "")),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(text(language, "button.back"), settingsData(settingsMenu)),
+	))
+
+	title := text(language, "settings.pick_home")
+	if action == settingsFavourite {
+		title = text(language, "settings.pick_fav")
+	}
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	c.editMessage(ctx, query, title, &keyboard)
+	c.bot.AnswerCallbackQuery(query.ID, "")
+}
@@ -2,83 +2,410 @@ package telegram
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
 	"weather-api/internal/adapters/telegram"
+	"weather-api/internal/models"
 	"weather-api/internal/usecase"
+	"weather-api/pkg/metrics"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 type TelegramController struct {
-	bot     *telegram.Bot
-	usecase *usecase.WeatherUseCase
+	bot                 *telegram.Bot
+	usecase             *usecase.WeatherUseCase
+	subscriptionUseCase *usecase.SubscriptionUseCase
+	preferencesUseCase  *usecase.PreferencesUseCase
+	adminUseCase        *usecase.AdminUseCase
+	cardUseCase         *usecase.CardUseCase
+	admins              []int64
+	metrics             *metrics.Metrics
+	workers             int
+	queueSize           int
+	// background фоновые задачи, например рассылки, которые Start дожидается при остановке
+	background sync.WaitGroup
+	// stopping закрывается, когда отменен контекст Start, и прерывает фоновые задачи
+	stopping <-chan struct{}
 }
 
-func NewTelegramController(bot *telegram.Bot, usecase *usecase.WeatherUseCase) *TelegramController {
+// TelegramControllerOptions параметры для создания контроллера
+type TelegramControllerOptions struct {
+	Bot                 *telegram.Bot
+	WeatherUseCase      *usecase.WeatherUseCase
+	SubscriptionUseCase *usecase.SubscriptionUseCase
+	PreferencesUseCase  *usecase.PreferencesUseCase
+	AdminUseCase        *usecase.AdminUseCase
+	CardUseCase         *usecase.CardUseCase
+	// Admins идентификаторы пользователей Telegram, которым доступны административные команды
+	Admins  []int64
+	Metrics *metrics.Metrics
+	// Workers число параллельных обработчиков обновлений, по умолчанию 8
+	Workers int
+	// QueueSize наибольшее число ожидающих обновлений одного чата, по умолчанию 100
+	QueueSize int
+}
+
+func NewTelegramController(options TelegramControllerOptions) *TelegramController {
+	workers := options.Workers
+	if workers <= 0 {
+		workers = defaultWorkers
+	}
+	queueSize := options.QueueSize
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
 	return &TelegramController{
-		bot:     bot,
-		usecase: usecase,
+		bot:                 options.Bot,
+		usecase:             options.WeatherUseCase,
+		subscriptionUseCase: options.SubscriptionUseCase,
+		preferencesUseCase:  options.PreferencesUseCase,
+		adminUseCase:        options.AdminUseCase,
+		cardUseCase:         options.CardUseCase,
+		admins:              options.Admins,
+		metrics:             options.Metrics,
+		workers:             workers,
+		queueSize:           queueSize,
 	}
 }
 
+// Start обрабатывает обновления бота, пока не отменен контекст или не закрыт канал обновлений.
+// Возвращается после обработки всех принятых обновлений и фоновых задач.
 func (c *TelegramController) Start(ctx context.Context) error {
-	for update := range c.bot.Updates() {
-		select {
-		case <-ctx.Done():
-			return nil
+	c.stopping = ctx.Done()
+	c.dispatch(ctx, c.bot.Updates())
+	c.background.Wait()
+	return nil
+}
+
+// handleUpdate направляет обновление нужному обработчику
+func (c *TelegramController) handleUpdate(ctx context.Context, update tgbotapi.Update) {
+	updateType := "other"
+	switch {
+	case update.InlineQuery != nil:
+		updateType = "inline_query"
+		c.handleInlineQuery(ctx, update.InlineQuery)
+	case update.CallbackQuery != nil:
+		updateType = "callback_query"
+		c.handleCallbackQuery(ctx, update.CallbackQuery)
+	case update.Message != nil:
+		updateType = "message"
+		c.handleMessage(ctx, update.Message)
+	}
+
+	if c.metrics != nil {
+		c.metrics.TelegramUpdatesTotal.WithLabelValues(updateType).Inc()
+	}
+}
+
+func (c *TelegramController) handleMessage(ctx context.Context, message *tgbotapi.Message) {
+	chatID := message.Chat.ID
+	preferences := c.preferences(ctx, chatID)
+
+	if err := c.adminUseCase.TrackChat(ctx, chatID); err != nil {
+		slog.WarnContext(ctx, "failed to track chat", "error", err)
+	}
+
+	if message.Location != nil {
+		c.sendWeather(ctx, chatID, preferences, weatherView{
+			Mode:  viewCurrent,
+			Units: preferences.Units,
+			Lat:   message.Location.Latitude,
+			Lon:   message.Location.Longitude,
+		})
+		return
+	}
+
+	if message.IsCommand() {
+		if c.handleAdminCommand(ctx, message, preferences) {
+			return
+		}
+
+		switch message.Command() {
+		case "start":
+			c.sendMainMenu(ctx, chatID, preferences)
+			return
+		case "settings":
+			c.sendSettings(chatID, preferences)
+			return
+		case "subscribe":
+			c.handleSubscribe(ctx, chatID, preferences, message.CommandArguments())
+			return
+		case "unsubscribe":
+			c.handleUnsubscribe(ctx, chatID, preferences)
+			return
 		default:
-			if update.Message == nil {
-				continue
-			}
-
-			if update.Message.IsCommand() && update.Message.Command() == "start" {
-				c.sendMainMenu(update.Message.Chat.ID)
-				continue
-			}
-
-			city := update.Message.Text
-			if city == "Главное меню" {
-				c.sendMainMenu(update.Message.Chat.ID)
-				continue
-			}
-
-			weather, err := c.usecase.GetWeatherByCity(ctx, city)
-			if err != nil {
-				c.bot.SendMessage(update.Message.Chat.ID, "Ошибка: город не найден или проблемы с погодой.", nil)
-				c.sendMainMenu(update.Message.Chat.ID)
-				continue
-			}
-
-			weatherResponse := fmt.Sprintf(
-				"Погода в %s:\nТемпература: %.1f°C\nСостояние: %s",
-				city, weather.CurrentWeather.Temperature, weather.CurrentWeather.WeatherDesc,
-			)
-			c.bot.SendMessage(update.Message.Chat.ID, weatherResponse, nil)
-			c.sendMainMenu(update.Message.Chat.ID)
+			// Иначе команда, в том числе админская без прав, ушла бы в поиск города
+			c.bot.SendMessage(chatID, text(preferences.Language, "error.unknown_command"), nil)
+			return
 		}
 	}
-	return nil
+
+	switch {
+	case isButton(message.Text, "button.main_menu"):
+		c.sendMainMenu(ctx, chatID, preferences)
+		return
+	case isButton(message.Text, "button.settings"):
+		c.sendSettings(chatID, preferences)
+		return
+	}
+
+	c.sendWeather(ctx, chatID, preferences, weatherView{Mode: viewCurrent, Units: preferences.Units, City: message.Text})
+}
+
+// preferences возвращает настройки чата, при ошибке - настройки по умолчанию
+func (c *TelegramController) preferences(ctx context.Context, chatID int64) *models.ChatPreferences {
+	preferences, err := c.preferencesUseCase.GetPreferences(ctx, chatID)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get chat preferences", "error", err)
+		return models.DefaultChatPreferences(chatID)
+	}
+	return preferences
+}
+
+// sendWeather отправляет погоду с inline-клавиатурой и показывает главное меню
+func (c *TelegramController) sendWeather(ctx context.Context, chatID int64, preferences *models.ChatPreferences, view weatherView) {
+	language := preferences.Language
+
+	view, err := c.resolveCity(ctx, view)
+	var weatherText string
+	if err == nil {
+		weatherText, err = c.renderWeather(ctx, view, language)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render weather", "view", view.encode(), "error", err)
+		if view.City != "" {
+			c.bot.SendMessage(chatID, text(language, "error.city_weather"), nil)
+		} else {
+			c.bot.SendMessage(chatID, text(language, "error.location"), nil)
+		}
+		c.sendMainMenu(ctx, chatID, preferences)
+		return
+	}
+
+	c.bot.SendMessage(chatID, weatherText, view.keyboard(language))
+	c.sendMainMenu(ctx, chatID, preferences)
+}
+
+// handleCallbackQuery обрабатывает нажатия на inline-кнопки, редактируя исходное сообщение
+func (c *TelegramController) handleCallbackQuery(ctx context.Context, query *tgbotapi.CallbackQuery) {
+	// Для сообщений из inline-режима чата нет, настройки берем по пользователю
+	chatID := query.From.ID
+	if query.Message != nil {
+		chatID = query.Message.Chat.ID
+	}
+	preferences := c.preferences(ctx, chatID)
+
+	if strings.HasPrefix(query.Data, settingsPrefix) {
+		c.handleSettingsCallback(ctx, query, preferences)
+		return
+	}
+
+	language := preferences.Language
+	view, err := parseWeatherView(query.Data)
+	if err == nil {
+		view, err = c.resolveCity(ctx, view)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "invalid callback data", "data", query.Data, "error", err)
+		message := text(language, "error.stale_button")
+		if view.CityID != 0 && !errors.Is(err, models.ErrNotFound) {
+			message = text(language, "error.weather")
+		}
+		c.bot.AnswerCallbackQuery(query.ID, message)
+		return
+	}
+
+	if view.Mode == viewCard {
+		c.sendCard(ctx, query, view, language)
+		return
+	}
+
+	weatherText, err := c.renderWeather(ctx, view, language)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render weather", "view", query.Data, "error", err)
+		c.bot.AnswerCallbackQuery(query.ID, text(language, "error.weather"))
+		return
+	}
+
+	keyboard := view.keyboard(language)
+	c.editMessage(ctx, query, weatherText, &keyboard)
+	c.bot.AnswerCallbackQuery(query.ID, "")
+}
+
+// sendCard отправляет карточку погоды отдельным фото в чат, где нажата кнопка
+func (c *TelegramController) sendCard(ctx context.Context, query *tgbotapi.CallbackQuery, view weatherView, language string) {
+	// В сообщения inline-режима фото не отправить: чата у бота нет
+	if query.Message == nil {
+		c.bot.AnswerCallbackQuery(query.ID, text(language, "error.card_inline"))
+		return
+	}
+
+	place := c.placeName(ctx, view, language)
+	var card []byte
+	var err error
+	if view.City != "" {
+		card, err = c.cardUseCase.GetCityCard(ctx, view.City, language, view.Units)
+	} else {
+		// Заголовок карточки короткий: ближайший город или координаты
+		title := fmt.Sprintf("%.2f, %.2f", view.Lat, view.Lon)
+		if city, err := c.usecase.GetNearestCity(ctx, view.Lat, view.Lon); err == nil {
+			title = city.Name
+		}
+		card, err = c.cardUseCase.GetLocationCard(ctx, view.Lat, view.Lon, title, language, view.Units)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to render weather card", "view", query.Data, "error", err)
+		c.bot.AnswerCallbackQuery(query.ID, text(language, "error.weather"))
+		return
+	}
+
+	if err := c.bot.SendPhoto(query.Message.Chat.ID, card, place, nil); err != nil {
+		slog.ErrorContext(ctx, "failed to send weather card", "error", err)
+	}
+	c.bot.AnswerCallbackQuery(query.ID, "")
+}
+
+// editMessage редактирует сообщение, к которому привязана нажатая кнопка
+func (c *TelegramController) editMessage(ctx context.Context, query *tgbotapi.CallbackQuery, messageText string, keyboard *tgbotapi.InlineKeyboardMarkup) {
+	var chatID int64
+	var messageID int
+	if query.Message != nil {
+		chatID = query.Message.Chat.ID
+		messageID = query.Message.MessageID
+	}
+	if err := c.bot.EditMessage(chatID, messageID, query.InlineMessageID, messageText, keyboard); err != nil {
+		slog.ErrorContext(ctx, "failed to edit message", "error", err)
+	}
+}
+
+// inlineQueryLimit максимальное число карточек в ответе на inline-запрос
+const inlineQueryLimit = 10
+
+// handleInlineQuery отвечает на "@bot <город>" карточками погоды подходящих городов
+func (c *TelegramController) handleInlineQuery(ctx context.Context, query *tgbotapi.InlineQuery) {
+	cities, err := c.usecase.GetAllCities(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get cities", "error", err)
+		return
+	}
+
+	preferences := c.preferences(ctx, query.From.ID)
+	language := preferences.Language
+
+	search := strings.ToLower(strings.TrimSpace(query.Query))
+	results := []interface{}{}
+	for _, city := range cities {
+		if !strings.Contains(strings.ToLower(city.Name), search) {
+			continue
+		}
+
+		view := weatherView{Mode: viewCurrent, Units: preferences.Units, City: city.Name, CityID: city.ID}
+		weatherText, err := c.renderWeather(ctx, view, language)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to render weather", "city", city.Name, "error", err)
+			continue
+		}
+
+		article := tgbotapi.NewInlineQueryResultArticle(city.Name, city.Name, weatherText)
+		article.Description = city.Country
+		keyboard := view.keyboard(language)
+		article.ReplyMarkup = &keyboard
+		results = append(results, article)
+
+		if len(results) == inlineQueryLimit {
+			break
+		}
+	}
+
+	if err := c.bot.AnswerInlineQuery(query.ID, results); err != nil {
+		slog.ErrorContext(ctx, "failed to answer inline query", "error", err)
+	}
 }
 
-func (c *TelegramController) sendMainMenu(chatID int64) {
-	cities, err := c.usecase.GetAllCities(context.Background())
+// handleSubscribe обрабатывает команду /subscribe <город> <HH:MM>
+func (c *TelegramController) handleSubscribe(ctx context.Context, chatID int64, preferences *models.ChatPreferences, args string) {
+	language := preferences.Language
+
+	// Название города может содержать пробелы, поэтому время берем последним словом
+	fields := strings.Fields(args)
+	if len(fields) < 2 {
+		c.bot.SendMessage(chatID, text(language, "subscribe.usage"), nil)
+		return
+	}
+	cityName := strings.Join(fields[:len(fields)-1], " ")
+	sendTime := fields[len(fields)-1]
+
+	subscription, err := c.subscriptionUseCase.Subscribe(ctx, chatID, cityName, sendTime)
 	if err != nil {
-		slog.Error("failed to get cities", "error", err)
-		c.bot.SendMessage(chatID, "Ошибка при загрузке списка городов.", nil)
+		if errors.Is(err, usecase.ErrInvalidSendTime) {
+			c.bot.SendMessage(chatID, text(language, "subscribe.bad_time"), nil)
+			return
+		}
+		slog.ErrorContext(ctx, "failed to subscribe", "city", cityName, "error", err)
+		c.bot.SendMessage(chatID, text(language, "subscribe.error"), nil)
+		return
+	}
+
+	c.bot.SendMessage(chatID, text(language, "subscribe.ok",
+		subscription.CityName, subscription.SendTime, subscription.Timezone,
+	), nil)
+}
+
+// handleUnsubscribe обрабатывает команду /unsubscribe
+func (c *TelegramController) handleUnsubscribe(ctx context.Context, chatID int64, preferences *models.ChatPreferences) {
+	if err := c.subscriptionUseCase.Unsubscribe(ctx, chatID); err != nil {
+		slog.ErrorContext(ctx, "failed to unsubscribe", "error", err)
+		c.bot.SendMessage(chatID, text(preferences.Language, "unsubscribe.error"), nil)
 		return
 	}
+	c.bot.SendMessage(chatID, text(preferences.Language, "unsubscribe.ok"), nil)
+}
+
+// sendMainMenu показывает клавиатуру с домашним и избранными городами.
+// Пока избранного нет, показываем все города.
+func (c *TelegramController) sendMainMenu(ctx context.Context, chatID int64, preferences *models.ChatPreferences) {
+	language := preferences.Language
+
+	var names []string
+	if preferences.HomeCity != "" {
+		names = append(names, preferences.HomeCity)
+	}
+	for _, name := range preferences.FavouriteCities {
+		if name != preferences.HomeCity {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		cities, err := c.usecase.GetAllCities(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get cities", "error", err)
+			c.bot.SendMessage(chatID, text(language, "error.cities"), nil)
+			return
+		}
+		for _, city := range cities {
+			names = append(names, city.Name)
+		}
+	}
 
 	var keyboardRows [][]tgbotapi.KeyboardButton
-	for i := 0; i < len(cities); i += 2 {
-		row := []tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButton(cities[i].Name)}
-		if i+1 < len(cities) {
-			row = append(row, tgbotapi.NewKeyboardButton(cities[i+1].Name))
+	for i := 0; i < len(names); i += 2 {
+		row := []tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButton(names[i])}
+		if i+1 < len(names) {
+			row = append(row, tgbotapi.NewKeyboardButton(names[i+1]))
 		}
 		keyboardRows = append(keyboardRows, row)
 	}
-	keyboardRows = append(keyboardRows, []tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButton("Главное меню")})
+	keyboardRows = append(keyboardRows, []tgbotapi.KeyboardButton{tgbotapi.NewKeyboardButtonLocation(text(language, "button.location"))})
+	keyboardRows = append(keyboardRows, []tgbotapi.KeyboardButton{
+		tgbotapi.NewKeyboardButton(text(language, "button.settings")),
+		tgbotapi.NewKeyboardButton(text(language, "button.main_menu")),
+	})
 	keyboard := tgbotapi.NewReplyKeyboard(keyboardRows...)
 
-	c.bot.SendMessage(chatID, "Выберите город:", keyboard)
+	c.bot.SendMessage(chatID, text(language, "menu.choose_city"), keyboard)
 }
@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"fmt"
+	"weather-api/internal/models"
+)
+
+// texts тексты бота на поддерживаемых языках
+var texts = map[string]map[string]string{
+	models.LanguageRU: {
+		"button.main_menu":        "Главное меню",
+		"button.location":         "Отправить местоположение",
+		"button.settings":         "Настройки",
+		"button.hourly":           "По часам",
+		"button.daily":            "7 дней",
+		"button.refresh":          "Обновить",
+		"button.card":             "Карточка",
+		"button.back":             "Назад",
+		"button.done":             "Готово",
+		"button.no_home":          "Без домашнего города",
+		"menu.choose_city":        "Выберите город:",
+		"error.cities":            "Ошибка при загрузке списка городов.",
+		"error.city_weather":      "Ошибка: город не найден или проблемы с погодой.",
+		"error.location":          "Ошибка: не удалось получить погоду для вашего местоположения.",
+		"error.weather":           "Не удалось получить погоду",
+		"error.stale_button":      "Кнопка устарела",
+		"error.card_inline":       "Карточку можно получить в чате с ботом",
+		"error.settings":          "Ошибка: не удалось сохранить настройки.",
+		"error.unknown_command":   "Неизвестная команда. Отправьте название города или /start.",
+		"weather.current":         "Погода в %s:\nТемпература: %s\nСостояние: %s",
+		"weather.hourly":          "Погода в %s по часам:",
+		"weather.daily":           "Погода в %s на 7 дней:",
+		"place.here":              "вашем местоположении",
+		"place.nearest":           "вашем местоположении (ближайший город: %s)",
+		"digest":                  "Прогноз на сегодня в %s:\nТемпература: от %s до %s\nОсадки: %.1f мм\nСостояние: %s",
+		"subscribe.usage":         "Использование: /subscribe <город> <ЧЧ:ММ>\nНапример: /subscribe Moscow 08:00",
+		"subscribe.bad_time":      "Ошибка: время нужно указать в формате ЧЧ:ММ, например 08:00.",
+		"subscribe.error":         "Ошибка: город не найден или не удалось сохранить подписку.",
+		"subscribe.ok":            "Подписка оформлена: прогноз для %s каждый день в %s (%s).",
+		"unsubscribe.error":       "Ошибка: не удалось отменить подписку.",
+		"unsubscribe.ok":          "Подписка на ежедневный прогноз отменена.",
+		"settings.title":          "Настройки:\nЯзык: %s\nЕдиницы: %s\nДомашний город: %s\nИзбранное: %s",
+		"settings.language":       "Язык: Русский",
+		"settings.units":          "Единицы: %s",
+		"settings.home":           "Домашний город",
+		"settings.favourite":      "Избранные города",
+		"settings.pick_home":      "Выберите домашний город:",
+		"settings.pick_fav":       "Отметьте избранные города:",
+		"settings.saved":          "Настройки сохранены.",
+		"settings.too_many":       "Слишком много избранных городов",
+		"settings.none":           "—",
+		"language.name":           "Русский",
+		"admin.error":             "Ошибка выполнения команды.",
+		"admin.stats":             "Пользователи: %d\nHTTP запросы: %.0f\nОбновления Telegram: %.0f\nКэш: %.1f%% попаданий (%.0f / %.0f)",
+		"admin.addcity.usage":     "Использование: /addcity <название> <широта> <долгота> <страна>",
+		"admin.addcity.ok":        "Город %s добавлен (часовой пояс %s).",
+		"admin.addcity.error":     "Ошибка: не удалось добавить город.",
+		"admin.broadcast.usage":   "Использование: /broadcast <текст>",
+		"admin.broadcast.started": "Рассылка запущена, получателей: %d. Прогресс не сохраняется: при перезапуске бота рассылка прервется.",
+		"admin.broadcast.done":    "Рассылка завершена: доставлено %d, ошибок %d.",
+		"admin.broadcast.stopped": "Рассылка прервана остановкой бота: доставлено %d, ошибок %d, не отправлено %d.",
+		"admin.flush.usage":       "Использование: /flushcache <город>",
+		"admin.flush.ok":          "Кэш города %s сброшен.",
+		"admin.flush.error":       "Ошибка: город не найден или не удалось сбросить кэш.",
+	},
+	models.LanguageEN: {
+		"button.main_menu":        "Main menu",
+		"button.location":         "Share location",
+		"button.settings":         "Settings",
+		"button.hourly":           "Hourly",
+		"button.daily":            "7 days",
+		"button.refresh":          "Refresh",
+		"button.card":             "Card",
+		"button.back":             "Back",
+		"button.done":             "Done",
+		"button.no_home":          "No home city",
+		"menu.choose_city":        "Choose a city:",
+		"error.cities":            "Failed to load the list of cities.",
+		"error.city_weather":      "Error: city not found or weather is unavailable.",
+		"error.location":          "Error: failed to get weather for your location.",
+		"error.weather":           "Failed to get weather",
+		"error.stale_button":      "This button has expired",
+		"error.card_inline":       "Cards are only available in a chat with the bot",
+		"error.settings":          "Error: failed to save settings.",
+		"error.unknown_command":   "Unknown command. Send a city name or /start.",
+		"weather.current":         "Weather in %s:\nTemperature: %s\nConditions: %s",
+		"weather.hourly":          "Hourly weather in %s:",
+		"weather.daily":           "7-day weather in %s:",
+		"place.here":              "your location",
+		"place.nearest":           "your location (nearest city: %s)",
+		"digest":                  "Today's forecast for %s:\nTemperature: %s to %s\nPrecipitation: %.1f mm\nConditions: %s",
+		"subscribe.usage":         "Usage: /subscribe <city> <HH:MM>\nExample: /subscribe Moscow 08:00",
+		"subscribe.bad_time":      "Error: time must be in HH:MM format, e.g. 08:00.",
+		"subscribe.error":         "Error: city not found or failed to save the subscription.",
+		"subscribe.ok":            "Subscribed: forecast for %s every day at %s (%s).",
+		"unsubscribe.error":       "Error: failed to cancel the subscription.",
+		"unsubscribe.ok":          "Daily forecast subscription cancelled.",
+		"settings.title":          "Settings:\nLanguage: %s\nUnits: %s\nHome city: %s\nFavourites: %s",
+		"settings.language":       "Language: English",
+		"settings.units":          "Units: %s",
+		"settings.home":           "Home city",
+		"settings.favourite":      "Favourite cities",
+		"settings.pick_home":      "Choose your home city:",
+		"settings.pick_fav":       "Mark your favourite cities:",
+		"settings.saved":          "Settings saved.",
+		"settings.too_many":       "Too many favourite cities",
+		"settings.none":           "—",
+		"language.name":           "English",
+		"admin.error":             "Command failed.",
+		"admin.stats":             "Users: %d\nHTTP requests: %.0f\nTelegram updates: %.0f\nCache: %.1f%% hits (%.0f / %.0f)",
+		"admin.addcity.usage":     "Usage: /addcity <name> <latitude> <longitude> <country>",
+		"admin.addcity.ok":        "City %s added (timezone %s).",
+		"admin.addcity.error":     "Error: failed to add the city.",
+		"admin.broadcast.usage":   "Usage: /broadcast <text>",
+		"admin.broadcast.started": "Broadcast started, recipients: %d. Progress is not saved: a bot restart interrupts the broadcast.",
+		"admin.broadcast.done":    "Broadcast finished: delivered %d, failed %d.",
+		"admin.broadcast.stopped": "Broadcast interrupted by bot shutdown: delivered %d, failed %d, not sent %d.",
+		"admin.flush.usage":       "Usage: /flushcache <city>",
+		"admin.flush.ok":          "Cache for %s flushed.",
+		"admin.flush.error":       "Error: city not found or failed to flush the cache.",
+	},
+}
+
+// text возвращает текст по ключу на языке чата, по умолчанию на русском
+func text(language, key string, args ...any) string {
+	messages, ok := texts[language]
+	if !ok {
+		messages = texts[models.LanguageRU]
+	}
+	message, ok := messages[key]
+	if !ok {
+		message = texts[models.LanguageRU][key]
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// isButton проверяет, совпадает ли сообщение с текстом кнопки на любом языке
+func isButton(message, key string) bool {
+	for _, messages := range texts {
+		if messages[key] == message {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,5 @@
+package telegram
+
+import "go.opentelemetry.io/otel"
+
+var tracer = otel.Tracer("weather-api/internal/controllers/telegram")
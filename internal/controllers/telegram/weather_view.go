@@ -0,0 +1,237 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Режимы отображения погоды под inline-клавиатурой
+const (
+	viewCurrent = "current"
+	viewHourly  = "hourly"
+	viewDaily   = "daily"
+	// viewCard отправляет карточку погоды картинкой, исходное сообщение не меняется
+	viewCard = "card"
+)
+
+// hourlyForecastHours сколько ближайших часов показывать в почасовом прогнозе
+const hourlyForecastHours = 12
+
+// weatherView описывает, что показано в сообщении с погодой.
+// Состояние целиком хранится в callback data кнопок: "режим|единицы|цель",
+// где цель - "#id" города или "@широта,долгота". Название города в callback data
+// не кладется: Telegram ограничивает ее 64 байтами. Его понимает только
+// parseWeatherView для кнопок, отправленных раньше.
+type weatherView struct {
+	Mode   string
+	Units  string
+	City   string
+	CityID int64
+	Lat    float64
+	Lon    float64
+}
+
+func (v weatherView) encode() string {
+	var target string
+	switch {
+	case v.CityID != 0:
+		target = "#" + strconv.FormatInt(v.CityID, 10)
+	case v.City != "":
+		target = v.City
+	default:
+		target = fmt.Sprintf("@%.4f,%.4f", v.Lat, v.Lon)
+	}
+	return v.Mode + "|" + v.Units + "|" + target
+}
+
+func parseWeatherView(data string) (weatherView, error) {
+	parts := strings.SplitN(data, "|", 3)
+	if len(parts) != 3 {
+		return weatherView{}, fmt.Errorf("invalid callback data: %q", data)
+	}
+
+	view := weatherView{Mode: parts[0], Units: parts[1]}
+	switch view.Mode {
+	case viewCurrent, viewHourly, viewDaily, viewCard:
+	default:
+		return weatherView{}, fmt.Errorf("invalid view mode: %q", view.Mode)
+	}
+	if view.Units != models.UnitsCelsius && view.Units != models.UnitsFahrenheit {
+		return weatherView{}, fmt.Errorf("invalid units: %q", view.Units)
+	}
+
+	target := parts[2]
+	if id, ok := strings.CutPrefix(target, "#"); ok {
+		cityID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil || cityID <= 0 {
+			return weatherView{}, fmt.Errorf("invalid city id: %q", target)
+		}
+		view.CityID = cityID
+		return view, nil
+	}
+	if !strings.HasPrefix(target, "@") {
+		view.City = target
+		return view, nil
+	}
+
+	coords := strings.SplitN(strings.TrimPrefix(target, "@"), ",", 2)
+	if len(coords) != 2 {
+		return weatherView{}, fmt.Errorf("invalid coordinates: %q", target)
+	}
+	var err error
+	if view.Lat, err = strconv.ParseFloat(coords[0], 64); err != nil {
+		return weatherView{}, fmt.Errorf("invalid latitude: %w", err)
+	}
+	if view.Lon, err = strconv.ParseFloat(coords[1], 64); err != nil {
+		return weatherView{}, fmt.Errorf("invalid longitude: %w", err)
+	}
+	return view, nil
+}
+
+// keyboard строит inline-клавиатуру, каждая кнопка которой несет итоговое состояние
+func (v weatherView) keyboard(language string) tgbotapi.InlineKeyboardMarkup {
+	hourly, daily, card, toggled := v, v, v, v
+	hourly.Mode = viewHourly
+	daily.Mode = viewDaily
+	card.Mode = viewCard
+	toggled.Units = models.UnitsFahrenheit
+	if v.Units == models.UnitsFahrenheit {
+		toggled.Units = models.UnitsCelsius
+	}
+
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.hourly"), hourly.encode()),
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.daily"), daily.encode()),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.refresh"), v.encode()),
+			tgbotapi.NewInlineKeyboardButtonData("°C / °F", toggled.encode()),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text(language, "button.card"), card.encode()),
+		),
+	)
+}
+
+// resolveCity дополняет вид города недостающим: названием по id из кнопки
+// или id по названию, чтобы построить клавиатуру. При ошибке вид возвращается как есть.
+func (c *TelegramController) resolveCity(ctx context.Context, view weatherView) (weatherView, error) {
+	switch {
+	case view.CityID != 0 && view.City == "":
+		city, err := c.usecase.GetCityByID(ctx, view.CityID)
+		if err != nil {
+			return view, err
+		}
+		view.City = city.Name
+	case view.City != "" && view.CityID == 0:
+		cities, err := c.usecase.GetCitiesByNames(ctx, []string{view.City})
+		if err != nil {
+			return view, err
+		}
+		if len(cities) == 0 {
+			return view, fmt.Errorf("city %q: %w", view.City, models.ErrNotFound)
+		}
+		view.City, view.CityID = cities[0].Name, cities[0].ID
+	}
+	return view, nil
+}
+
+// renderWeather получает данные через usecase и формирует текст сообщения
+func (c *TelegramController) renderWeather(ctx context.Context, view weatherView, language string) (string, error) {
+	place := c.placeName(ctx, view, language)
+	var err error
+
+	switch view.Mode {
+	case viewHourly, viewDaily:
+		days := 2
+		if view.Mode == viewDaily {
+			days = 7
+		}
+		var forecast *dto.ForecastResult
+		if view.City != "" {
+			forecast, err = c.usecase.GetForecastByCity(ctx, view.City, days)
+		} else {
+			forecast, err = c.usecase.GetForecast(ctx, dto.GetForecastParams{Lat: view.Lat, Lon: view.Lon, Days: days})
+		}
+		if err != nil {
+			return "", err
+		}
+		if view.Mode == viewHourly {
+			return formatHourly(place, forecast, view.Units, language), nil
+		}
+		return formatDaily(place, forecast, view.Units, language), nil
+	default:
+		var weather *dto.WeatherResult
+		if view.City != "" {
+			weather, err = c.usecase.GetWeatherByCity(ctx, view.City)
+		} else {
+			weather, err = c.usecase.GetWeatherToday(ctx, dto.GetWeatherTodayParams{Lat: view.Lat, Lon: view.Lon})
+		}
+		if err != nil {
+			return "", err
+		}
+		return text(language, "weather.current",
+			place,
+			models.FormatTemperature(weather.CurrentWeather.Temperature, view.Units),
+			models.GetWeatherDescriptionLang(weather.CurrentWeather.WeatherCode, language),
+		), nil
+	}
+}
+
+// placeName возвращает название места для заголовка сообщения
+func (c *TelegramController) placeName(ctx context.Context, view weatherView, language string) string {
+	if view.City != "" {
+		return view.City
+	}
+	city, err := c.usecase.GetNearestCity(ctx, view.Lat, view.Lon)
+	if err != nil {
+		return text(language, "place.here")
+	}
+	return text(language, "place.nearest", city.Name)
+}
+
+func formatHourly(place string, forecast *dto.ForecastResult, units, language string) string {
+	var b strings.Builder
+	b.WriteString(text(language, "weather.hourly", place))
+
+	now := time.Now().Truncate(time.Hour)
+	shown := 0
+	for _, hour := range forecast.Hourly {
+		if hour.Time.Before(now) {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s  %s, %s",
+			hour.Time.Format("15:04"),
+			models.FormatTemperature(hour.Temperature, units),
+			models.GetWeatherDescriptionLang(hour.WeatherCode, language),
+		)
+		shown++
+		if shown == hourlyForecastHours {
+			break
+		}
+	}
+	return b.String()
+}
+
+func formatDaily(place string, forecast *dto.ForecastResult, units, language string) string {
+	var b strings.Builder
+	b.WriteString(text(language, "weather.daily", place))
+
+	for _, day := range forecast.Daily {
+		fmt.Fprintf(&b, "\n%s  %s … %s, %s",
+			day.Date.Format("02.01"),
+			models.FormatTemperature(day.TemperatureMin, units),
+			models.FormatTemperature(day.TemperatureMax, units),
+			models.GetWeatherDescriptionLang(day.WeatherCode, language),
+		)
+	}
+	return b.String()
+}
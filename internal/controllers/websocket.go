@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/middleware"
+	"weather-api/internal/usecase"
+
+	"github.com/gorilla/websocket"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// defaultWebSocketIdleTimeout сколько ждать сообщений или pong от клиента
+	defaultWebSocketIdleTimeout = 60 * time.Second
+	// defaultWebSocketMaxSubscriptions сколько подписок можно держать на соединении
+	defaultWebSocketMaxSubscriptions = 20
+	// defaultWebSocketSubscribeRate сколько новых подписок в секунду можно оформить на соединении
+	defaultWebSocketSubscribeRate = 1
+	// websocketWriteWait сколько ждать записи одного сообщения
+	websocketWriteWait = 10 * time.Second
+	// websocketMaxMessageSize ограничение на размер сообщения клиента
+	websocketMaxMessageSize = 4096
+	// websocketQueueSize сколько сообщений сервера может ждать отправки. Клиент,
+	// который не успевает их читать, отключается, чтобы не копить память.
+	websocketQueueSize = 64
+)
+
+type websocketOptions struct {
+	idleTimeout      time.Duration
+	maxSubscriptions int
+	subscribeRate    float64
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// websocketClose причина закрытия соединения. code 0 значит, что close frame не отправляется.
+type websocketClose struct {
+	reason string
+	code   int
+	text   string
+}
+
+func (e *websocketClose) Error() string {
+	return e.reason
+}
+
+var (
+	errWebSocketClientClosed = &websocketClose{reason: "client_closed"}
+	errWebSocketIdle         = &websocketClose{reason: "idle_timeout", code: websocket.CloseGoingAway, text: "idle timeout"}
+	errWebSocketTooBig       = &websocketClose{reason: "message_too_big", code: websocket.CloseMessageTooBig, text: "message too big"}
+	errWebSocketSlowConsumer = &websocketClose{reason: "slow_consumer", code: websocket.CloseTryAgainLater, text: "slow consumer"}
+	errWebSocketReadFailed   = &websocketClose{reason: "read_error"}
+	errWebSocketWriteFailed  = &websocketClose{reason: "write_error"}
+	errWebSocketShutdown     = &websocketClose{reason: "server_shutdown", code: websocket.CloseGoingAway, text: "server shutdown"}
+)
+
+// websocketConnection одно соединение WebSocket API. Сообщения клиента читаются
+// в горутине обработчика, запись идет через очередь в отдельной горутине.
+type websocketConnection struct {
+	controller *WeatherController
+	request    *http.Request
+	conn       *websocket.Conn
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	outbound   chan dto.WebSocketMessage
+
+	// subscriptions используется только горутиной чтения
+	subscriptions map[string]*usecase.WeatherSubscription
+	// subscribeLimiter ограничивает смену подписок: каждая новая точка сразу идет к провайдеру.
+	// Запас равен числу подписок, чтобы клиент мог сразу подписаться на все.
+	subscribeLimiter *rate.Limiter
+	forwarders       sync.WaitGroup
+}
+
+// WeatherWebSocket подписки на погоду и предупреждения в нескольких городах и точках
+// через одно соединение. Протокол описан в dto.WebSocketRequest и dto.WebSocketMessage.
+func (c *WeatherController) WeatherWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !c.streams.open() {
+		writeProblem(w, r, http.StatusServiceUnavailable, "Service Unavailable", "The server is shutting down.")
+		return
+	}
+	defer c.streams.done()
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade уже ответил клиенту
+		slog.WarnContext(r.Context(), "WebSocket upgrade failed", "error", err)
+		return
+	}
+
+	ctx, cancel := context.WithCancelCause(r.Context())
+	// При остановке сервера клиент получает close frame с кодом 1001
+	stop := context.AfterFunc(c.streams.ctx, func() { cancel(errWebSocketShutdown) })
+	defer stop()
+	connection := &websocketConnection{
+		controller:       c,
+		request:          r,
+		conn:             conn,
+		ctx:              ctx,
+		cancel:           cancel,
+		outbound:         make(chan dto.WebSocketMessage, websocketQueueSize),
+		subscriptions:    make(map[string]*usecase.WeatherSubscription),
+		subscribeLimiter: rate.NewLimiter(rate.Limit(c.websocket.subscribeRate), c.websocket.maxSubscriptions),
+	}
+
+	if c.metrics != nil {
+		c.metrics.WebSocketConnections.Inc()
+		defer c.metrics.WebSocketConnections.Dec()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		connection.writeLoop()
+	}()
+
+	connection.readLoop()
+	<-done
+
+	for _, subscription := range connection.subscriptions {
+		subscription.Close()
+	}
+	connection.forwarders.Wait()
+
+	reason := closeReason(context.Cause(ctx))
+	if c.metrics != nil {
+		c.metrics.WebSocketDisconnects.WithLabelValues(reason.reason).Inc()
+	}
+	slog.DebugContext(r.Context(), "WebSocket connection closed", "reason", reason.reason)
+}
+
+// closeReason приводит причину отмены контекста к причине закрытия
+func closeReason(cause error) *websocketClose {
+	var reason *websocketClose
+	if errors.As(cause, &reason) {
+		return reason
+	}
+	return errWebSocketShutdown
+}
+
+// readLoop читает сообщения клиента, пока соединение не закроется
+func (c *websocketConnection) readLoop() {
+	idleTimeout := c.controller.websocket.idleTimeout
+	c.conn.SetReadLimit(websocketMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			c.cancel(readError(err))
+			return
+		}
+		c.conn.SetReadDeadline(time.Now().Add(idleTimeout))
+
+		var request dto.WebSocketRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			c.sendProblem("", http.StatusBadRequest, "Bad Request", "Message must be a JSON object")
+			continue
+		}
+		c.handle(request)
+	}
+}
+
+// readError определяет причину закрытия по ошибке чтения
+func readError(err error) *websocketClose {
+	var netErr net.Error
+	switch {
+	case websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived):
+		return errWebSocketClientClosed
+	case errors.Is(err, websocket.ErrReadLimit):
+		return errWebSocketTooBig
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return errWebSocketIdle
+	default:
+		return errWebSocketReadFailed
+	}
+}
+
+// writeLoop отправляет сообщения из очереди и ping, пока соединение не закроется.
+// Ping идет чаще таймаута простоя, чтобы живой клиент успевал ответить pong.
+func (c *websocketConnection) writeLoop() {
+	defer c.conn.Close()
+
+	ping := time.NewTicker(c.controller.websocket.idleTimeout * 9 / 10)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			reason := closeReason(context.Cause(c.ctx))
+			if reason.code != 0 {
+				message := websocket.FormatCloseMessage(reason.code, reason.text)
+				c.conn.WriteControl(websocket.CloseMessage, message, time.Now().Add(websocketWriteWait))
+			}
+			return
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteWait)); err != nil {
+				c.cancel(errWebSocketWriteFailed)
+				return
+			}
+		case message := <-c.outbound:
+			c.conn.SetWriteDeadline(time.Now().Add(websocketWriteWait))
+			if err := c.conn.WriteJSON(message); err != nil {
+				c.cancel(errWebSocketWriteFailed)
+				return
+			}
+		}
+	}
+}
+
+// send ставит сообщение в очередь. Если очередь заполнена, клиент не успевает
+// читать, и соединение закрывается.
+func (c *websocketConnection) send(message dto.WebSocketMessage) bool {
+	select {
+	case c.outbound <- message:
+		return true
+	case <-c.ctx.Done():
+		return false
+	default:
+		c.cancel(errWebSocketSlowConsumer)
+		return false
+	}
+}
+
+func (c *websocketConnection) sendProblem(subscription string, status int, title, detail string) {
+	c.send(dto.WebSocketMessage{
+		Type:         dto.WebSocketError,
+		Subscription: subscription,
+		Error: &dto.Problem{
+			Type:     "about:blank",
+			Title:    title,
+			Status:   status,
+			Detail:   detail,
+			Instance: c.request.URL.Path,
+		},
+	})
+}
+
+// handle выполняет одно сообщение клиента
+func (c *websocketConnection) handle(request dto.WebSocketRequest) {
+	switch request.Type {
+	case dto.WebSocketPing:
+		c.send(dto.WebSocketMessage{Type: dto.WebSocketPong})
+	case dto.WebSocketSubscribe, dto.WebSocketUnsubscribe:
+		key, detail := subscriptionKey(request)
+		if detail != "" {
+			c.sendProblem("", http.StatusBadRequest, "Bad Request", detail)
+			return
+		}
+		if request.Type == dto.WebSocketSubscribe {
+			c.subscribe(key, request)
+		} else {
+			c.unsubscribe(key)
+		}
+	default:
+		c.sendProblem("", http.StatusBadRequest, "Bad Request", "Unknown message type")
+	}
+}
+
+// subscriptionKey ключ подписки, по которому клиент отличает сообщения и отписывается
+func subscriptionKey(request dto.WebSocketRequest) (string, string) {
+	switch {
+	case request.City != "" && (request.Lat != nil || request.Lon != nil):
+		return "", "Either city or lat and lon must be set, not both"
+	case request.City != "":
+		return "city:" + strings.ToLower(request.City), ""
+	case request.Lat != nil && request.Lon != nil:
+		return fmt.Sprintf("coords:%g,%g", *request.Lat, *request.Lon), ""
+	default:
+		return "", "Missing city or lat and lon"
+	}
+}
+
+func (c *websocketConnection) subscribe(key string, request dto.WebSocketRequest) {
+	if _, ok := c.subscriptions[key]; ok {
+		c.send(dto.WebSocketMessage{Type: dto.WebSocketSubscribed, Subscription: key})
+		return
+	}
+	if len(c.subscriptions) >= c.controller.websocket.maxSubscriptions {
+		c.sendProblem(key, http.StatusBadRequest, "Bad Request",
+			fmt.Sprintf("At most %d subscriptions per connection", c.controller.websocket.maxSubscriptions))
+		return
+	}
+
+	if !c.subscribeLimiter.Allow() {
+		c.sendProblem(key, http.StatusTooManyRequests, "Too Many Requests", "Subscriptions are changed too often")
+		return
+	}
+	// Подписка стоит как отдельный запрос к API: списываем квоту ключа и частоту
+	if err := middleware.Charge(c.ctx); err != nil {
+		problem := problemFor(c.request, err)
+		c.sendProblem(key, problem.Status, problem.Title, problem.Detail)
+		return
+	}
+
+	var subscription *usecase.WeatherSubscription
+	var err error
+	if request.City != "" {
+		subscription, err = c.controller.weatherWatcher.SubscribeCity(c.ctx, request.City, 0)
+	} else {
+		subscription, err = c.controller.weatherWatcher.Subscribe(*request.Lat, *request.Lon, 0)
+	}
+	if err != nil {
+		if c.ctx.Err() == nil {
+			slog.ErrorContext(c.ctx, "Failed to subscribe to weather", "subscription", key, "error", err)
+		}
+		problem := problemFor(c.request, err)
+		c.sendProblem(key, problem.Status, problem.Title, problem.Detail)
+		return
+	}
+
+	c.subscriptions[key] = subscription
+	c.send(dto.WebSocketMessage{Type: dto.WebSocketSubscribed, Subscription: key})
+
+	c.forwarders.Add(1)
+	go func() {
+		defer c.forwarders.Done()
+		c.forward(key, subscription)
+	}()
+}
+
+func (c *websocketConnection) unsubscribe(key string) {
+	if subscription, ok := c.subscriptions[key]; ok {
+		subscription.Close()
+		delete(c.subscriptions, key)
+	}
+	c.send(dto.WebSocketMessage{Type: dto.WebSocketUnsubscribed, Subscription: key})
+}
+
+// forward пересылает изменения подписки клиенту. Предупреждения отправляются
+// отдельным сообщением только когда они изменились.
+func (c *websocketConnection) forward(key string, subscription *usecase.WeatherSubscription) {
+	var alerts []dto.WeatherAlert
+	for update := range subscription.Updates() {
+		if !c.send(dto.WebSocketMessage{
+			Type:         dto.WebSocketWeather,
+			Subscription: key,
+			ID:           update.ID,
+			Data:         toWeatherResultV2(update.Report),
+		}) {
+			return
+		}
+		if slices.EqualFunc(alerts, update.Alerts, dto.WeatherAlert.Equal) {
+			continue
+		}
+		alerts = update.Alerts
+		data := alerts
+		if data == nil {
+			data = []dto.WeatherAlert{}
+		}
+		if !c.send(dto.WebSocketMessage{Type: dto.WebSocketAlerts, Subscription: key, ID: update.ID, Data: data}) {
+			return
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package dto
+
+// AdminStats статистика для администраторов бота
+type AdminStats struct {
+	Users           int
+	HttpRequests    float64
+	TelegramUpdates float64
+	CacheHits       float64
+	CacheMisses     float64
+	CacheHitRatio   float64
+}
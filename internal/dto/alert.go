@@ -0,0 +1,32 @@
+package dto
+
+import "time"
+
+// Виды предупреждений о погоде
+const (
+	AlertThunderstorm       = "thunderstorm"
+	AlertHeavyPrecipitation = "heavy_precipitation"
+	AlertHeat               = "heat"
+	AlertFrost              = "frost"
+)
+
+// Уровни опасности предупреждений
+const (
+	AlertSeverityModerate = "moderate"
+	AlertSeveritySevere   = "severe"
+)
+
+// WeatherAlert предупреждение об опасной погоде в интервале [Start, End)
+type WeatherAlert struct {
+	Event       string    `json:"event" xml:"event"`
+	Severity    string    `json:"severity" xml:"severity"`
+	Start       time.Time `json:"start" xml:"start"`
+	End         time.Time `json:"end" xml:"end"`
+	Description string    `json:"description" xml:"description"`
+}
+
+// Equal сравнивает предупреждения, время сравнивается как момент, а не по представлению
+func (a WeatherAlert) Equal(b WeatherAlert) bool {
+	return a.Event == b.Event && a.Severity == b.Severity && a.Description == b.Description &&
+		a.Start.Equal(b.Start) && a.End.Equal(b.End)
+}
@@ -0,0 +1,37 @@
+package dto
+
+import "time"
+
+// IssueAPIKeyRequest тело запроса на выпуск ключа
+type IssueAPIKeyRequest struct {
+	Owner  string   `json:"owner"`
+	Scopes []string `json:"scopes"`
+	// DailyQuota сколько запросов в сутки по UTC разрешено ключу, 0 без ограничения
+	DailyQuota int `json:"daily_quota"`
+}
+
+// APIKey описание ключа для администраторов. Key заполняется только при выпуске.
+type APIKey struct {
+	ID         string     `json:"id" xml:"id"`
+	Owner      string     `json:"owner" xml:"owner"`
+	Scopes     []string   `json:"scopes" xml:"scopes>scope"`
+	DailyQuota int        `json:"daily_quota" xml:"daily_quota"`
+	CreatedAt  time.Time  `json:"created_at" xml:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" xml:"revoked_at,omitempty"`
+	Key        string     `json:"key,omitempty" xml:"key,omitempty"`
+}
+
+// APIKeyUsage использование суточной квоты ключа. Limit 0 значит без ограничения.
+type APIKeyUsage struct {
+	Limit   int
+	Used    int64
+	ResetAt time.Time
+}
+
+// CreateCityRequest тело запроса на добавление города
+type CreateCityRequest struct {
+	Name      string  `json:"name"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
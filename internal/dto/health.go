@@ -0,0 +1,28 @@
+package dto
+
+import "time"
+
+// Состояния сервиса и отдельных проверок
+const (
+	HealthStatusOK = "ok"
+	// HealthStatusDegraded не работает некритичная зависимость, сервис готов принимать запросы
+	HealthStatusDegraded = "degraded"
+	HealthStatusFailing  = "failing"
+)
+
+// HealthReport ответ /livez и /readyz
+type HealthReport struct {
+	Status string `json:"status"`
+	// ShuttingDown приложение останавливается и не принимает новую нагрузку
+	ShuttingDown bool                         `json:"shutting_down,omitempty"`
+	Checks       map[string]HealthCheckResult `json:"checks,omitempty"`
+}
+
+// HealthCheckResult результат проверки одной зависимости. Текст ошибки
+// пишется только в лог, чтобы не раскрывать адреса внутренних сервисов.
+type HealthCheckResult struct {
+	Status    string    `json:"status"`
+	Critical  bool      `json:"critical"`
+	LatencyMs float64   `json:"latency_ms"`
+	CheckedAt time.Time `json:"checked_at"`
+}
@@ -0,0 +1,11 @@
+package dto
+
+// Problem тело ответа с ошибкой по RFC 9457. Detail содержит только безопасный
+// для клиента текст, внутренние ошибки в ответ не попадают и пишутся в лог.
+type Problem struct {
+	Type     string `json:"type" xml:"type"`
+	Title    string `json:"title" xml:"title"`
+	Status   int    `json:"status" xml:"status"`
+	Detail   string `json:"detail,omitempty" xml:"detail,omitempty"`
+	Instance string `json:"instance,omitempty" xml:"instance,omitempty"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// RateLimit правило корзины токенов: Rate запросов в секунду в среднем и до Burst подряд
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitDecision результат списания запроса из корзины
+type RateLimitDecision struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter через сколько появится токен, если запрос не разрешен
+	RetryAfter time.Duration
+	// Reset через сколько корзина снова будет полной
+	Reset time.Duration
+}
@@ -0,0 +1,10 @@
+package dto
+
+// WeatherUpdate изменение погоды в точке. ID растет со временем и
+// позволяет клиенту продолжить поток после переподключения.
+type WeatherUpdate struct {
+	ID     int64
+	Report *WeatherReport
+	// Alerts предупреждения на ближайшие двое суток, обновляются реже погоды
+	Alerts []WeatherAlert
+}
@@ -1,16 +1,88 @@
 package dto
 
+import "time"
+
 type GetWeatherTodayParams struct {
 	Lat float64
 	Lon float64
 }
 
 type CurrentWeather struct {
-	Temperature float64 `json:"temperature"`
-	WeatherCode int     `json:"weathercode"`
-	WeatherDesc string  `json:"weather_description"`
+	Temperature float64 `json:"temperature" xml:"temperature"`
+	WeatherCode int     `json:"weathercode" xml:"weathercode"`
+	WeatherDesc string  `json:"weather_description" xml:"weather_description"`
 }
 
 type WeatherResult struct {
-	CurrentWeather CurrentWeather `json:"current_weather"`
+	CurrentWeather CurrentWeather `json:"current_weather" xml:"current_weather"`
+}
+
+type GetForecastParams struct {
+	Lat  float64
+	Lon  float64
+	Days int
+}
+
+type HourlyForecast struct {
+	Time        time.Time `json:"time" xml:"time"`
+	Temperature float64   `json:"temperature" xml:"temperature"`
+	WeatherCode int       `json:"weathercode" xml:"weathercode"`
+	WeatherDesc string    `json:"weather_description" xml:"weather_description"`
+}
+
+type DailyForecast struct {
+	Date           time.Time `json:"date" xml:"date"`
+	TemperatureMax float64   `json:"temperature_max" xml:"temperature_max"`
+	TemperatureMin float64   `json:"temperature_min" xml:"temperature_min"`
+	Precipitation  float64   `json:"precipitation" xml:"precipitation"`
+	WeatherCode    int       `json:"weathercode" xml:"weathercode"`
+	WeatherDesc    string    `json:"weather_description" xml:"weather_description"`
+}
+
+type ForecastResult struct {
+	Timezone string           `json:"timezone" xml:"timezone"`
+	Hourly   []HourlyForecast `json:"hourly" xml:"hourly"`
+	Daily    []DailyForecast  `json:"daily" xml:"daily"`
+}
+
+// Location место, для которого получена погода. Для запроса по координатам
+// название, страна и часовой пояс не заполняются.
+type Location struct {
+	Name      string
+	Country   string
+	Latitude  float64
+	Longitude float64
+	Timezone  string
+}
+
+// WeatherReport текущая погода с данными о месте и времени наблюдения.
+// Из нее контроллеры собирают ответы разных версий API.
+type WeatherReport struct {
+	Location      Location
+	ObservedAt    time.Time
+	Temperature   float64
+	WindSpeed     float64
+	WindDirection float64
+	WeatherCode   int
+	WeatherDesc   string
+	// ExpiresIn сколько еще данные будут лежать в кэше, 0 если неизвестно
+	ExpiresIn time.Duration
+}
+
+// WeatherBatchItem точка пакетного запроса: город или пара координат
+type WeatherBatchItem struct {
+	City string   `json:"city,omitempty" xml:"city,omitempty"`
+	Lat  *float64 `json:"lat,omitempty" xml:"lat,omitempty"`
+	Lon  *float64 `json:"lon,omitempty" xml:"lon,omitempty"`
+}
+
+type WeatherBatchRequest struct {
+	Items []WeatherBatchItem `json:"items" xml:"items"`
+}
+
+// WeatherBatchResult результат для одной точки пакета: погода или ошибка
+type WeatherBatchResult struct {
+	Item   WeatherBatchItem
+	Report *WeatherReport
+	Err    error
 }
@@ -0,0 +1,56 @@
+package dto
+
+import "time"
+
+// APIVersionV2 версия API в ответах v2
+const APIVersionV2 = "2"
+
+// ResponseV2 конверт всех ответов API v2
+type ResponseV2[T any] struct {
+	APIVersion string `json:"api_version" xml:"api_version"`
+	Data       T      `json:"data" xml:"data"`
+}
+
+type LocationV2 struct {
+	Name      string  `json:"name,omitempty" xml:"name,omitempty"`
+	Country   string  `json:"country,omitempty" xml:"country,omitempty"`
+	Latitude  float64 `json:"latitude" xml:"latitude"`
+	Longitude float64 `json:"longitude" xml:"longitude"`
+	Timezone  string  `json:"timezone,omitempty" xml:"timezone,omitempty"`
+}
+
+type UnitsV2 struct {
+	Temperature   string `json:"temperature" xml:"temperature"`
+	WindSpeed     string `json:"wind_speed" xml:"wind_speed"`
+	WindDirection string `json:"wind_direction" xml:"wind_direction"`
+}
+
+type CurrentWeatherV2 struct {
+	ObservedAt    *time.Time `json:"observed_at,omitempty" xml:"observed_at,omitempty"`
+	Temperature   float64    `json:"temperature" xml:"temperature"`
+	WindSpeed     float64    `json:"wind_speed" xml:"wind_speed"`
+	WindDirection float64    `json:"wind_direction" xml:"wind_direction"`
+	WeatherCode   int        `json:"weather_code" xml:"weather_code"`
+	WeatherDesc   string     `json:"weather_description" xml:"weather_description"`
+}
+
+type WeatherResultV2 struct {
+	Location LocationV2       `json:"location" xml:"location"`
+	Units    UnitsV2          `json:"units" xml:"units"`
+	Current  CurrentWeatherV2 `json:"current" xml:"current"`
+}
+
+type CityV2 struct {
+	Name      string  `json:"name" xml:"name"`
+	Country   string  `json:"country" xml:"country"`
+	Latitude  float64 `json:"latitude" xml:"latitude"`
+	Longitude float64 `json:"longitude" xml:"longitude"`
+	Timezone  string  `json:"timezone" xml:"timezone"`
+}
+
+// WeatherBatchItemV2 результат точки пакета в API v2
+type WeatherBatchItemV2 struct {
+	WeatherBatchItem
+	Weather *WeatherResultV2 `json:"weather,omitempty" xml:"weather,omitempty"`
+	Error   *Problem         `json:"error,omitempty" xml:"error,omitempty"`
+}
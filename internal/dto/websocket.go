@@ -0,0 +1,36 @@
+package dto
+
+// Типы сообщений клиента WebSocket API
+const (
+	WebSocketSubscribe   = "subscribe"
+	WebSocketUnsubscribe = "unsubscribe"
+	WebSocketPing        = "ping"
+)
+
+// Типы сообщений сервера WebSocket API
+const (
+	WebSocketSubscribed   = "subscribed"
+	WebSocketUnsubscribed = "unsubscribed"
+	WebSocketWeather      = "weather"
+	WebSocketAlerts       = "alerts"
+	WebSocketError        = "error"
+	WebSocketPong         = "pong"
+)
+
+// WebSocketRequest сообщение клиента. Подписка задается городом или парой lat и lon.
+type WebSocketRequest struct {
+	Type string   `json:"type"`
+	City string   `json:"city,omitempty"`
+	Lat  *float64 `json:"lat,omitempty"`
+	Lon  *float64 `json:"lon,omitempty"`
+}
+
+// WebSocketMessage сообщение сервера. Subscription ключ подписки вида city:<название>
+// или coords:<lat>,<lon>, к которой относится сообщение.
+type WebSocketMessage struct {
+	Type         string   `json:"type"`
+	Subscription string   `json:"subscription,omitempty"`
+	ID           int64    `json:"id,omitempty"`
+	Data         any      `json:"data,omitempty"`
+	Error        *Problem `json:"error,omitempty"`
+}
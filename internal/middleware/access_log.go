@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AccessLogMiddleware пишет одну запись лога на запрос. Служебные маршруты из
+// untracedPaths пишутся на уровне debug, чтобы частые проверки не засоряли лог.
+// Должен стоять после MetricsMiddleware, чтобы видеть ключ доступа запроса.
+func AccessLogMiddleware(trustForwardedFor bool) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := NewResponseWriter(w)
+
+			next.ServeHTTP(ww, r)
+
+			level := slog.LevelInfo
+			if untracedPaths[r.URL.Path] {
+				level = slog.LevelDebug
+			}
+			slog.Log(r.Context(), level, "http request",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", ww.Status(),
+				"duration_ms", float64(time.Since(start).Microseconds())/1000,
+				"bytes", ww.Size(),
+				"remote_ip", clientIP(r, trustForwardedFor),
+				"user_agent", r.UserAgent(),
+				"key_id", keyIDLabel(r.Context()),
+			)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"weather-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+var errInvalidAdminToken = fmt.Errorf("%w: missing or invalid admin token", models.ErrUnauthorized)
+
+// AdminTokenAuth пропускает только запросы с заголовком "Authorization: Bearer <token>"
+func AdminTokenAuth(token string, onError func(w http.ResponseWriter, r *http.Request, err error)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+				onError(w, r, errInvalidAdminToken)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
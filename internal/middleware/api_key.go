@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// APIKeyHeader заголовок с ключом доступа
+	APIKeyHeader = "X-API-Key"
+	// APIKeyQueryParam параметр запроса с ключом для клиентов, которые не могут задать заголовок,
+	// например WebSocket в браузере
+	APIKeyQueryParam = "api_key"
+)
+
+var errMissingAPIKey = fmt.Errorf("%w: missing api key", models.ErrUnauthorized)
+
+// APIKeyAuthenticator проверяет ключи доступа
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, raw string) (*models.APIKey, error)
+	Authorize(ctx context.Context, key *models.APIKey, scope string) (*dto.APIKeyUsage, error)
+}
+
+type APIKeyAuthOptions struct {
+	Keys APIKeyAuthenticator
+	// Scope право, которое нужно для запроса
+	Scope func(r *http.Request) string
+	// OnError отвечает клиенту на ошибку проверки ключа
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type apiKeyContextKey struct{}
+
+// APIKeyFromContext возвращает ключ, с которым пришел запрос
+func APIKeyFromContext(ctx context.Context) (*models.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyContextKey{}).(*models.APIKey)
+	return key, ok
+}
+
+// APIKeyAuth пропускает только запросы с действующим ключом, у которого есть нужное право
+// и не исчерпана суточная квота. Ключ убирается из строки запроса, чтобы не попасть
+// в логи обработчиков, а в метрики попадает только идентификатор ключа.
+func APIKeyAuth(options APIKeyAuthOptions) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			raw := r.Header.Get(APIKeyHeader)
+			if query := r.URL.Query(); query.Has(APIKeyQueryParam) {
+				if raw == "" {
+					raw = query.Get(APIKeyQueryParam)
+				}
+				query.Del(APIKeyQueryParam)
+				r = r.Clone(r.Context())
+				r.URL.RawQuery = query.Encode()
+			}
+			if raw == "" {
+				options.OnError(w, r, errMissingAPIKey)
+				return
+			}
+
+			key, err := options.Keys.Authenticate(r.Context(), raw)
+			if err != nil {
+				options.OnError(w, r, err)
+				return
+			}
+			setKeyIDLabel(r.Context(), key.ID)
+
+			scope := options.Scope(r)
+			usage, err := options.Keys.Authorize(r.Context(), key, scope)
+			if usage != nil && usage.Limit > 0 {
+				remaining := max(int64(usage.Limit)-usage.Used, 0)
+				w.Header().Set("X-Quota-Limit", strconv.Itoa(usage.Limit))
+				w.Header().Set("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+				w.Header().Set("X-Quota-Reset", usage.ResetAt.Format(http.TimeFormat))
+			}
+			if err != nil {
+				if usage != nil && errors.Is(err, models.ErrRateLimited) {
+					retryAfter := int(time.Until(usage.ResetAt).Seconds()) + 1
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+				}
+				options.OnError(w, r, err)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), apiKeyContextKey{}, key)
+			ctx = withCharge(ctx, func(ctx context.Context) error {
+				_, err := options.Keys.Authorize(ctx, key, scope)
+				return err
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"weather-api/internal/models"
+
+	"github.com/gorilla/mux"
+)
+
+// chargeFunc списывает еще один запрос с ключа или корзины, через которые прошел запрос
+type chargeFunc func(ctx context.Context) error
+
+type chargesKey struct{}
+
+// withCharge добавляет в контекст запроса способ списать повторный запрос
+func withCharge(ctx context.Context, charge chargeFunc) context.Context {
+	parent, _ := ctx.Value(chargesKey{}).([]chargeFunc)
+	charges := make([]chargeFunc, 0, len(parent)+1)
+	charges = append(charges, parent...)
+	return context.WithValue(ctx, chargesKey{}, append(charges, charge))
+}
+
+// Charge списывает с ключа доступа и корзины частоты запроса еще один запрос, как
+// если бы клиент пришел повторно. Нужна долгим соединениям, где одно сообщение
+// клиента стоит как отдельный запрос, например подписка в WebSocket.
+// Без ключа и ограничителя частоты ничего не делает.
+func Charge(ctx context.Context) error {
+	charges, _ := ctx.Value(chargesKey{}).([]chargeFunc)
+	for _, charge := range charges {
+		if err := charge(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UpstreamChargeMiddleware списывает через Charge каждый запрос к провайдеру погоды
+// сверх первого: иначе пакет или GraphQL запрос по цене одного запроса мог бы
+// израсходовать лимит провайдера в десятки раз быстрее квоты ключа
+func UpstreamChargeMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(models.WithUpstreamCharge(r.Context(), Charge)))
+		})
+	}
+}
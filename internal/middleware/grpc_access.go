@@ -0,0 +1,153 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// grpcPublicServices сервисы, доступные без ключа: проверка состояния и reflection
+var grpcPublicServices = []string{"/grpc.health.v1.", "/grpc.reflection."}
+
+type GRPCAccessOptions struct {
+	// Keys проверка ключей доступа из метаданных x-api-key, если nil, API открыт
+	Keys APIKeyAuthenticator
+	// Limiter ограничитель частоты запросов, если nil, частота не ограничивается
+	Limiter repository.RateLimiter
+	Limit   dto.RateLimit
+	// IPLimit корзина на адрес клиента до проверки ключа, ограничивает перебор ключей
+	IPLimit dto.RateLimit
+	// OnError переводит ошибку проверки в статус gRPC
+	OnError func(ctx context.Context, method string, err error) error
+}
+
+// grpcAccess проверяет ключ доступа и частоту запросов так же, как APIKeyAuth и RateLimit для HTTP
+type grpcAccess struct {
+	options GRPCAccessOptions
+	limiter *fallbackRateLimiter
+	// ipLimited проверять IPLimit до ключа
+	ipLimited bool
+}
+
+func newGRPCAccess(options GRPCAccessOptions) *grpcAccess {
+	if options.OnError == nil {
+		panic("OnError is required")
+	}
+	access := &grpcAccess{options: options}
+	if options.Limiter != nil {
+		access.limiter = newFallbackRateLimiter(options.Limiter)
+		access.ipLimited = options.Keys != nil && options.IPLimit.Rate > 0 && options.IPLimit.Burst > 0
+	}
+	return access
+}
+
+// GRPCAccessInterceptor создает interceptor с проверкой ключа и частоты unary gRPC запросов
+func GRPCAccessInterceptor(options GRPCAccessOptions) grpc.UnaryServerInterceptor {
+	access := newGRPCAccess(options)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := access.check(ctx, info.FullMethod)
+		if err != nil {
+			return nil, options.OnError(ctx, info.FullMethod, err)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// GRPCStreamAccessInterceptor создает interceptor с проверкой ключа и частоты потоковых gRPC запросов
+func GRPCStreamAccessInterceptor(options GRPCAccessOptions) grpc.StreamServerInterceptor {
+	access := newGRPCAccess(options)
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := access.check(stream.Context(), info.FullMethod)
+		if err != nil {
+			return options.OnError(ctx, info.FullMethod, err)
+		}
+		return handler(srv, &contextServerStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// check возвращает контекст с ключом запроса или ошибку проверки
+func (a *grpcAccess) check(ctx context.Context, method string) (context.Context, error) {
+	for _, prefix := range grpcPublicServices {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	limitKey := "ip:" + grpcPeerIP(ctx)
+	if a.ipLimited {
+		if err := a.allow(ctx, "client"+limitKey, a.options.IPLimit); err != nil {
+			return ctx, err
+		}
+	}
+	if a.options.Keys != nil {
+		var raw string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(strings.ToLower(APIKeyHeader)); len(values) > 0 {
+				raw = values[0]
+			}
+		}
+		if raw == "" {
+			return ctx, errMissingAPIKey
+		}
+
+		key, err := a.options.Keys.Authenticate(ctx, raw)
+		if err != nil {
+			return ctx, err
+		}
+		// gRPC API только читает погоду
+		usage, err := a.options.Keys.Authorize(ctx, key, models.ScopeWeatherRead)
+		if err != nil {
+			if usage != nil && errors.Is(err, models.ErrRateLimited) {
+				setGRPCRetryAfter(ctx, time.Until(usage.ResetAt))
+			}
+			return ctx, err
+		}
+		ctx = context.WithValue(ctx, apiKeyContextKey{}, key)
+		limitKey = "key:" + key.ID
+	}
+
+	if a.limiter != nil && a.options.Limit.Rate > 0 && a.options.Limit.Burst > 0 {
+		if err := a.allow(ctx, limitKey, a.options.Limit); err != nil {
+			return ctx, err
+		}
+	}
+	return ctx, nil
+}
+
+// allow списывает запрос из корзины key
+func (a *grpcAccess) allow(ctx context.Context, key string, limit dto.RateLimit) error {
+	decision := a.limiter.Allow(ctx, key, limit)
+	if !decision.Allowed {
+		setGRPCRetryAfter(ctx, decision.RetryAfter)
+		return errRateLimited
+	}
+	return nil
+}
+
+// setGRPCRetryAfter сообщает клиенту, через сколько секунд повторить запрос
+func setGRPCRetryAfter(ctx context.Context, d time.Duration) {
+	_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(max(ceilSeconds(d), 1))))
+}
+
+// grpcPeerIP адрес клиента gRPC соединения
+func grpcPeerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"context"
+	"time"
+	"weather-api/pkg/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCMetricsInterceptor создает interceptor для сбора метрик unary gRPC запросов
+func GRPCMetricsInterceptor(metrics *metrics.Metrics) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err).String()
+		metrics.GrpcRequestsTotal.WithLabelValues(info.FullMethod, code).Inc()
+		metrics.GrpcRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return resp, err
+	}
+}
+
+// GRPCStreamMetricsInterceptor создает interceptor для сбора метрик потоковых gRPC запросов
+func GRPCStreamMetricsInterceptor(metrics *metrics.Metrics) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+
+		err := handler(srv, stream)
+
+		code := status.Code(err).String()
+		metrics.GrpcRequestsTotal.WithLabelValues(info.FullMethod, code).Inc()
+		metrics.GrpcRequestDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
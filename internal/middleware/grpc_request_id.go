@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"strings"
+	"weather-api/pkg/logger"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// grpcRequestID принимает идентификатор запроса из метаданных x-request-id или создает
+// новый, возвращает его в заголовках ответа и добавляет вместе с методом в контекст лога
+func grpcRequestID(ctx context.Context, method string) context.Context {
+	var requestID string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(strings.ToLower(RequestIDHeader)); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if !validRequestID(requestID) {
+		requestID = newRequestID()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(RequestIDHeader), requestID))
+
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return logger.With(ctx, "request_id", requestID, "route", method)
+}
+
+// GRPCRequestIDInterceptor создает interceptor с идентификатором unary gRPC запросов
+func GRPCRequestIDInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(grpcRequestID(ctx, info.FullMethod), req)
+	}
+}
+
+// GRPCStreamRequestIDInterceptor создает interceptor с идентификатором потоковых gRPC запросов
+func GRPCStreamRequestIDInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &contextServerStream{
+			ServerStream: stream,
+			ctx:          grpcRequestID(stream.Context(), info.FullMethod),
+		})
+	}
+}
+
+// contextServerStream подменяет контекст потока
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -1,6 +1,9 @@
 package middleware
 
 import (
+	"bufio"
+	"context"
+	"net"
 	"net/http"
 	"strconv"
 	"time"
@@ -18,6 +21,10 @@ func MetricsMiddleware(metrics *metrics.Metrics) mux.MiddlewareFunc {
 			// Создаем ResponseWriter, который может записывать статус-код
 			ww := NewResponseWriter(w)
 
+			// Метки, которые заполнят middleware дальше по цепочке
+			labels := &requestLabels{keyID: anonymousKeyID}
+			r = r.WithContext(context.WithValue(r.Context(), requestLabelsKey{}, labels))
+
 			// Вызываем следующий обработчик
 			next.ServeHTTP(ww, r)
 
@@ -31,21 +38,54 @@ func MetricsMiddleware(metrics *metrics.Metrics) mux.MiddlewareFunc {
 			status := strconv.Itoa(ww.Status())
 
 			// Обновляем метрики
-			metrics.HttpRequestsTotal.WithLabelValues(method, path, status).Inc()
+			metrics.HttpRequestsTotal.WithLabelValues(method, path, status, labels.keyID).Inc()
 			metrics.HttpRequestDuration.WithLabelValues(method, path).Observe(duration)
 		})
 	}
 }
 
-// ResponseWriter оборачивает http.ResponseWriter для отслеживания статус-кода
+// anonymousKeyID метка запросов без ключа доступа
+const anonymousKeyID = "anonymous"
+
+// requestLabels метки запроса, которые становятся известны только внутри цепочки middleware
+type requestLabels struct {
+	keyID string
+}
+
+type requestLabelsKey struct{}
+
+// keyIDLabel возвращает идентификатор ключа, которым отмечен запрос
+func keyIDLabel(ctx context.Context) string {
+	if labels, ok := ctx.Value(requestLabelsKey{}).(*requestLabels); ok {
+		return labels.keyID
+	}
+	return anonymousKeyID
+}
+
+// setKeyIDLabel отмечает запрос идентификатором ключа. Сам ключ в метки не попадает.
+func setKeyIDLabel(ctx context.Context, keyID string) {
+	if labels, ok := ctx.Value(requestLabelsKey{}).(*requestLabels); ok {
+		labels.keyID = keyID
+	}
+}
+
+// ResponseWriter оборачивает http.ResponseWriter для отслеживания статус-кода и размера ответа
 type ResponseWriter struct {
 	http.ResponseWriter
 	statusCode int
+	size       int64
 }
 
 // NewResponseWriter создает новый ResponseWriter
 func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
-	return &ResponseWriter{w, http.StatusOK}
+	return &ResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+}
+
+// Write реализует интерфейс http.ResponseWriter и считает записанные байты
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.size += int64(n)
+	return n, err
 }
 
 // WriteHeader реализует интерфейс http.ResponseWriter
@@ -54,7 +94,23 @@ func (rw *ResponseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap отдает исходный ResponseWriter для http.ResponseController, например для Flush
+func (rw *ResponseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack передает соединение обработчику, например для WebSocket
+func (rw *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	rw.statusCode = http.StatusSwitchingProtocols
+	return http.NewResponseController(rw.ResponseWriter).Hijack()
+}
+
 // Status возвращает статус-код ответа
 func (rw *ResponseWriter) Status() int {
 	return rw.statusCode
 }
+
+// Size возвращает число байт тела ответа
+func (rw *ResponseWriter) Size() int64 {
+	return rw.size
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gorilla/mux"
+)
+
+// OpenAPIValidationMiddleware проверяет параметры запроса по спецификации OpenAPI.
+// Запросы к маршрутам, которых нет в спецификации, пропускаются без проверки.
+// Текст для клиента передается в onInvalid, он не содержит внутренних подробностей схемы.
+func OpenAPIValidationMiddleware(doc *openapi3.T, onInvalid func(w http.ResponseWriter, r *http.Request, detail string)) (mux.MiddlewareFunc, error) {
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("gorillamux.NewRouter(...): %w", err)
+	}
+
+	options := &openapi3filter.Options{
+		// Аутентификация в спецификации не описана, а тела ответов не проверяем
+		AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+		MultiError:         false,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route, pathParams, err := router.FindRoute(r)
+			if err != nil {
+				// Неизвестный путь или метод обработает сам роутер
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			input := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams,
+				Route:      route,
+				Options:    options,
+			}
+			if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+				onInvalid(w, r, validationDetail(err))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+// validationDetail формирует короткое описание ошибки проверки для клиента
+func validationDetail(err error) string {
+	var requestErr *openapi3filter.RequestError
+	if !errors.As(err, &requestErr) {
+		return "The request does not match the API specification."
+	}
+
+	reason := requestErr.Reason
+	var schemaErr *openapi3.SchemaError
+	if errors.As(requestErr.Err, &schemaErr) {
+		reason = schemaErr.Reason
+	} else if requestErr.Err != nil && reason == "" {
+		reason = requestErr.Err.Error()
+	}
+
+	if requestErr.Parameter != nil {
+		return fmt.Sprintf("Invalid %s parameter %q: %s", requestErr.Parameter.In, requestErr.Parameter.Name, reason)
+	}
+	return "Invalid request: " + reason
+}
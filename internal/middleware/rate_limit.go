@@ -0,0 +1,199 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+
+	"github.com/gorilla/mux"
+)
+
+// memoryBucketIdleTTL через сколько неиспользуемая корзина в памяти удаляется
+const memoryBucketIdleTTL = 5 * time.Minute
+
+var errRateLimited = fmt.Errorf("%w: too many requests", models.ErrRateLimited)
+
+type RateLimitOptions struct {
+	// Limiter общий для всех экземпляров ограничитель. Пока он недоступен,
+	// используется корзина в памяти, и лимит действует на каждый экземпляр отдельно.
+	Limiter repository.RateLimiter
+	Limit   dto.RateLimit
+	// TrustForwardedFor брать адрес клиента из X-Forwarded-For. Включать только
+	// за своим прокси, иначе клиент может подставить любой адрес.
+	TrustForwardedFor bool
+	// ByClientIP считать запросы только по адресу клиента в отдельных корзинах.
+	// Такой ограничитель ставится до APIKeyAuth: запросы с неверным ключом
+	// отклоняются раньше основного ограничителя, но каждый стоит запроса к базе.
+	ByClientIP bool
+	// OnError отвечает клиенту, превысившему лимит
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// RateLimit ограничивает частоту запросов по ключу доступа, а без ключа по адресу клиента.
+// Должен стоять после APIKeyAuth, чтобы видеть ключ запроса.
+func RateLimit(options RateLimitOptions) mux.MiddlewareFunc {
+	limiter := newFallbackRateLimiter(options.Limiter)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := "ip:" + clientIP(r, options.TrustForwardedFor)
+			if options.ByClientIP {
+				key = "client" + key
+			} else if apiKey, ok := APIKeyFromContext(r.Context()); ok {
+				key = "key:" + apiKey.ID
+			}
+
+			decision := limiter.Allow(r.Context(), key, options.Limit)
+
+			header := w.Header()
+			header.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", options.Limit.Burst, windowSeconds(options.Limit)))
+			header.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(decision.Reset)))
+			if !decision.Allowed {
+				header.Set("Retry-After", strconv.Itoa(max(ceilSeconds(decision.RetryAfter), 1)))
+				options.OnError(w, r, errRateLimited)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(withCharge(r.Context(), func(ctx context.Context) error {
+				if !limiter.Allow(ctx, key, options.Limit).Allowed {
+					return errRateLimited
+				}
+				return nil
+			})))
+		})
+	}
+}
+
+// windowSeconds за сколько секунд пустая корзина наполняется целиком
+func windowSeconds(limit dto.RateLimit) int {
+	return ceilSeconds(time.Duration(float64(limit.Burst) / limit.Rate * float64(time.Second)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// clientIP адрес клиента. Из X-Forwarded-For берется последний адрес, его добавил наш прокси.
+func clientIP(r *http.Request, trustForwardedFor bool) string {
+	if trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			parts := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(parts[len(parts)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// fallbackRateLimiter списывает запросы из общего ограничителя, а пока он
+// недоступен, из корзин в памяти этого экземпляра
+type fallbackRateLimiter struct {
+	limiter  repository.RateLimiter
+	fallback *memoryRateLimiter
+	degraded atomic.Bool
+}
+
+func newFallbackRateLimiter(limiter repository.RateLimiter) *fallbackRateLimiter {
+	return &fallbackRateLimiter{limiter: limiter, fallback: newMemoryRateLimiter()}
+}
+
+// Allow списывает один запрос из корзины key
+func (l *fallbackRateLimiter) Allow(ctx context.Context, key string, limit dto.RateLimit) *dto.RateLimitDecision {
+	decision, err := l.limiter.Allow(ctx, key, limit)
+	if err != nil {
+		if !l.degraded.Swap(true) {
+			slog.WarnContext(ctx, "rate limiter unavailable, falling back to in-memory limits", "error", err)
+		}
+		return l.fallback.Allow(key, limit)
+	}
+	if l.degraded.Swap(false) {
+		slog.InfoContext(ctx, "rate limiter recovered")
+	}
+	return decision
+}
+
+// memoryRateLimiter корзины токенов в памяти с тем же алгоритмом, что скрипт в Redis
+type memoryRateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastPrune time.Time
+	// now текущее время, в тестах подменяется
+	now func() time.Time
+}
+
+type memoryBucket struct {
+	tokens float64
+	ts     time.Time
+}
+
+func newMemoryRateLimiter() *memoryRateLimiter {
+	return &memoryRateLimiter{
+		buckets:   make(map[string]*memoryBucket),
+		lastPrune: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// Allow списывает один запрос из корзины key
+func (l *memoryRateLimiter) Allow(key string, limit dto.RateLimit) *dto.RateLimitDecision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	burst := float64(limit.Burst)
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: burst, ts: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.ts).Seconds()*limit.Rate)
+	bucket.ts = now
+
+	decision := &dto.RateLimitDecision{Limit: limit.Burst}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = secondsDuration((1 - bucket.tokens) / limit.Rate)
+	}
+	decision.Remaining = int(bucket.tokens)
+	decision.Reset = secondsDuration((burst - bucket.tokens) / limit.Rate)
+	return decision
+}
+
+// prune удаляет давно неиспользуемые корзины, чтобы карта не росла бесконечно
+func (l *memoryRateLimiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < memoryBucketIdleTTL {
+		return
+	}
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.ts) > memoryBucketIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+	l.lastPrune = now
+}
+
+func secondsDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
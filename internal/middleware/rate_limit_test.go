@@ -0,0 +1,207 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"weather-api/internal/dto"
+)
+
+// fakeRateLimiter общий ограничитель, который можно сделать недоступным
+type fakeRateLimiter struct {
+	err     error
+	allowed bool
+	keys    []string
+}
+
+func (f *fakeRateLimiter) Allow(ctx context.Context, key string, limit dto.RateLimit) (*dto.RateLimitDecision, error) {
+	f.keys = append(f.keys, key)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &dto.RateLimitDecision{Allowed: f.allowed, Limit: limit.Burst, Remaining: 42}, nil
+}
+
+// TestMemoryRateLimiter проверяет наполнение корзины и предел burst
+func TestMemoryRateLimiter(t *testing.T) {
+	limit := dto.RateLimit{Rate: 2, Burst: 3}
+
+	type step struct {
+		advance       time.Duration
+		wantAllowed   bool
+		wantRemaining int
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "burst then denied",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{wantAllowed: false, wantRemaining: 0, wantRetry: 500 * time.Millisecond},
+			},
+		},
+		{
+			name: "refill at rate",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+				{wantAllowed: true, wantRemaining: 0},
+				{advance: 250 * time.Millisecond, wantAllowed: false, wantRemaining: 0, wantRetry: 250 * time.Millisecond},
+				{advance: 250 * time.Millisecond, wantAllowed: true, wantRemaining: 0},
+			},
+		},
+		{
+			name: "refill is capped by burst",
+			steps: []step{
+				{wantAllowed: true, wantRemaining: 2},
+				{advance: time.Hour, wantAllowed: true, wantRemaining: 2},
+				{wantAllowed: true, wantRemaining: 1},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+			l := newMemoryRateLimiter()
+			l.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+				d := l.Allow("key", limit)
+				if d.Allowed != s.wantAllowed || d.Remaining != s.wantRemaining || d.RetryAfter != s.wantRetry {
+					t.Fatalf("step %d: allowed=%v remaining=%d retry=%s, want allowed=%v remaining=%d retry=%s",
+						i, d.Allowed, d.Remaining, d.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+				}
+				if d.Limit != limit.Burst {
+					t.Fatalf("step %d: limit = %d, want %d", i, d.Limit, limit.Burst)
+				}
+			}
+		})
+	}
+}
+
+// TestMemoryRateLimiterSeparateKeys проверяет, что у каждого ключа своя корзина
+func TestMemoryRateLimiterSeparateKeys(t *testing.T) {
+	l := newMemoryRateLimiter()
+	limit := dto.RateLimit{Rate: 1, Burst: 1}
+	if !l.Allow("a", limit).Allowed {
+		t.Fatal("first request of a denied")
+	}
+	if l.Allow("a", limit).Allowed {
+		t.Fatal("second request of a allowed")
+	}
+	if !l.Allow("b", limit).Allowed {
+		t.Fatal("first request of b denied")
+	}
+}
+
+// TestFallbackRateLimiter проверяет переход на корзины в памяти при ошибке
+// общего ограничителя и возврат к нему после восстановления
+func TestFallbackRateLimiter(t *testing.T) {
+	shared := &fakeRateLimiter{allowed: true}
+	l := newFallbackRateLimiter(shared)
+	limit := dto.RateLimit{Rate: 1, Burst: 1}
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		err           error
+		wantAllowed   bool
+		wantRemaining int
+		wantDegraded  bool
+	}{
+		{name: "shared limiter", wantAllowed: true, wantRemaining: 42},
+		{name: "shared limiter fails", err: errors.New("redis is down"), wantAllowed: true, wantRemaining: 0, wantDegraded: true},
+		{name: "memory bucket is exhausted", err: errors.New("redis is down"), wantAllowed: false, wantRemaining: 0, wantDegraded: true},
+		{name: "shared limiter recovered", wantAllowed: true, wantRemaining: 42},
+	}
+	for _, tt := range tests {
+		shared.err = tt.err
+		d := l.Allow(ctx, "key", limit)
+		if d.Allowed != tt.wantAllowed || d.Remaining != tt.wantRemaining {
+			t.Fatalf("%s: allowed=%v remaining=%d, want allowed=%v remaining=%d",
+				tt.name, d.Allowed, d.Remaining, tt.wantAllowed, tt.wantRemaining)
+		}
+		if got := l.degraded.Load(); got != tt.wantDegraded {
+			t.Fatalf("%s: degraded = %v, want %v", tt.name, got, tt.wantDegraded)
+		}
+	}
+}
+
+// TestRateLimitBucketKey проверяет, по какому ключу считаются запросы
+func TestRateLimitBucketKey(t *testing.T) {
+	tests := []struct {
+		name              string
+		byClientIP        bool
+		trustForwardedFor bool
+		forwardedFor      string
+		want              string
+	}{
+		{name: "remote address", want: "ip:192.0.2.1"},
+		{name: "client ip bucket", byClientIP: true, want: "clientip:192.0.2.1"},
+		{name: "forwarded for ignored", forwardedFor: "198.51.100.7", want: "ip:192.0.2.1"},
+		{name: "last forwarded address", trustForwardedFor: true, forwardedFor: "203.0.113.9, 198.51.100.7", want: "ip:198.51.100.7"},
+		{name: "client ip bucket behind proxy", byClientIP: true, trustForwardedFor: true, forwardedFor: "198.51.100.7", want: "clientip:198.51.100.7"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared := &fakeRateLimiter{allowed: true}
+			handler := RateLimit(RateLimitOptions{
+				Limiter:           shared,
+				Limit:             dto.RateLimit{Rate: 1, Burst: 5},
+				TrustForwardedFor: tt.trustForwardedFor,
+				ByClientIP:        tt.byClientIP,
+				OnError:           func(w http.ResponseWriter, r *http.Request, err error) {},
+			})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+			r := httptest.NewRequest(http.MethodGet, "/api/v2/weather", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), r)
+
+			if len(shared.keys) != 1 || shared.keys[0] != tt.want {
+				t.Errorf("keys = %v, want [%s]", shared.keys, tt.want)
+			}
+		})
+	}
+}
+
+// TestRateLimitDenied проверяет ответ на превышение лимита
+func TestRateLimitDenied(t *testing.T) {
+	var called bool
+	var gotErr error
+	handler := RateLimit(RateLimitOptions{
+		Limiter: &fakeRateLimiter{allowed: false},
+		Limit:   dto.RateLimit{Rate: 0.5, Burst: 5},
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+			gotErr = err
+			w.WriteHeader(http.StatusTooManyRequests)
+		},
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/weather", nil))
+
+	if called {
+		t.Error("handler called after limit exceeded")
+	}
+	if !errors.Is(gotErr, errRateLimited) {
+		t.Errorf("error = %v, want %v", gotErr, errRateLimited)
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("Retry-After = %q, want 1", got)
+	}
+	if got := w.Header().Get("RateLimit-Policy"); got != "5;w=10" {
+		t.Errorf("RateLimit-Policy = %q, want 5;w=10", got)
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"weather-api/pkg/logger"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength длина, после которой идентификатор клиента не принимается
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware принимает идентификатор запроса из X-Request-ID или создает новый
+// и возвращает его в ответе. Идентификатор и шаблон маршрута попадают во все записи лога,
+// сделанные с контекстом запроса.
+func RequestIDMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !validRequestID(requestID) {
+				requestID = newRequestID()
+			}
+			w.Header().Set(RequestIDHeader, requestID)
+
+			trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("http.request_id", requestID))
+
+			ctx := context.WithValue(r.Context(), requestIDKey{}, requestID)
+			args := []any{"request_id", requestID}
+			if template := routeTemplate(r); template != "" {
+				args = append(args, "route", template)
+			}
+			next.ServeHTTP(w, r.WithContext(logger.With(ctx, args...)))
+		})
+	}
+}
+
+// RequestIDFromContext возвращает идентификатор запроса, если он есть в контексте
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// validRequestID идентификатор клиента попадает в логи и заголовки ответа,
+// поэтому принимаем только короткие строки из безопасных символов
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
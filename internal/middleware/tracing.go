@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// untracedPaths служебные маршруты, которые опрашиваются слишком часто для трассировки
+var untracedPaths = map[string]bool{
+	"/metrics": true,
+	"/health":  true,
+	"/livez":   true,
+	"/readyz":  true,
+}
+
+// TracingMiddleware начинает span запроса с именем по шаблону маршрута. Если клиент
+// передал заголовок traceparent, span продолжает его трассу.
+func TracingMiddleware() mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		route := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if template := routeTemplate(r); template != "" {
+				trace.SpanFromContext(r.Context()).SetAttributes(semconv.HTTPRoute(template))
+			}
+			next.ServeHTTP(w, r)
+		})
+		return otelhttp.NewHandler(route, "http.request",
+			otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+				if template := routeTemplate(r); template != "" {
+					return r.Method + " " + template
+				}
+				return r.Method
+			}),
+			otelhttp.WithFilter(func(r *http.Request) bool {
+				return !untracedPaths[r.URL.Path]
+			}),
+		)
+	}
+}
+
+func routeTemplate(r *http.Request) string {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return ""
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return ""
+	}
+	return template
+}
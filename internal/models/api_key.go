@@ -0,0 +1,34 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Права API ключей
+const (
+	// ScopeWeatherRead чтение погоды, прогнозов и списка городов
+	ScopeWeatherRead = "weather:read"
+	// ScopeCitiesWrite добавление городов в справочник
+	ScopeCitiesWrite = "cities:write"
+)
+
+// Scopes все известные права
+var Scopes = []string{ScopeWeatherRead, ScopeCitiesWrite}
+
+// APIKey ключ доступа к HTTP API. Сам ключ не хранится, только SHA-256 от его секретной части.
+type APIKey struct {
+	ID      string
+	KeyHash string
+	Owner   string
+	Scopes  []string
+	// DailyQuota сколько запросов в сутки по UTC разрешено ключу, 0 без ограничения
+	DailyQuota int
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+// HasScope проверяет, что ключу выдано право
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
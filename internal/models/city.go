@@ -1,8 +1,11 @@
 package models
 
 type City struct {
+	// ID короткий идентификатор города, например для callback data кнопок Telegram
+	ID        int64
 	Name      string
 	Latitude  float64
 	Longitude float64
 	Country   string
+	Timezone  string
 }
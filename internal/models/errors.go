@@ -0,0 +1,69 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Ошибки предметной области. Адаптеры и usecase оборачивают их через %w,
+// а контроллеры по ним выбирают ответ клиенту.
+var (
+	// ErrNotFound запрошенный объект не существует
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidInput некорректные входные данные
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrUpstreamUnavailable внешний сервис недоступен или вернул ошибку
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+	// ErrUpstreamBadResponse внешний сервис вернул ответ, который не удалось разобрать
+	ErrUpstreamBadResponse = errors.New("upstream bad response")
+	// ErrRateLimited превышен лимит запросов
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnauthorized клиент не передал действительный ключ доступа
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrForbidden у клиента нет прав на операцию
+	ErrForbidden = errors.New("forbidden")
+	// ErrAlreadyExists объект с таким ключом уже существует
+	ErrAlreadyExists = errors.New("already exists")
+)
+
+// ErrUpstreamBudgetExhausted исчерпан суточный лимит запросов к внешнему сервису
+var ErrUpstreamBudgetExhausted = fmt.Errorf("%w: daily upstream budget exhausted", ErrUpstreamUnavailable)
+
+// PublicError описание ошибки для клиента. Одно на все API: HTTP отдает Status,
+// Title и Detail, GraphQL добавляет Code в extensions, gRPC выбирает код по Status.
+type PublicError struct {
+	Status int
+	Code   string
+	Title  string
+	Detail string
+}
+
+// publicErrors порядок важен: ошибка может оборачивать несколько доменных ошибок
+var publicErrors = []struct {
+	err error
+	PublicError
+}{
+	{ErrNotFound, PublicError{http.StatusNotFound, "NOT_FOUND", "Not Found", "The requested resource was not found."}},
+	{ErrInvalidInput, PublicError{http.StatusBadRequest, "BAD_REQUEST", "Bad Request", "The request parameters are invalid."}},
+	{ErrUnauthorized, PublicError{http.StatusUnauthorized, "UNAUTHENTICATED", "Unauthorized", "Valid credentials are required."}},
+	{ErrForbidden, PublicError{http.StatusForbidden, "FORBIDDEN", "Forbidden", "The API key is not allowed to perform this operation."}},
+	{ErrAlreadyExists, PublicError{http.StatusConflict, "CONFLICT", "Conflict", "The resource already exists."}},
+	{ErrRateLimited, PublicError{http.StatusTooManyRequests, "RATE_LIMITED", "Too Many Requests", "Rate limit exceeded, try again later."}},
+	{ErrUpstreamBadResponse, PublicError{http.StatusBadGateway, "BAD_GATEWAY", "Bad Gateway", "The weather provider returned an invalid response."}},
+	{ErrUpstreamUnavailable, PublicError{http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service Unavailable", "The weather provider is temporarily unavailable."}},
+}
+
+// InternalPublicError описание неизвестной ошибки: подробности клиенту не отдаются
+var InternalPublicError = PublicError{http.StatusInternalServerError, "INTERNAL_SERVER_ERROR", "Internal Server Error", "An unexpected error occurred."}
+
+// PublicErrorFor описывает ошибку предметной области для клиента.
+// Для неизвестных ошибок возвращает false.
+func PublicErrorFor(err error) (PublicError, bool) {
+	for _, kind := range publicErrors {
+		if errors.Is(err, kind.err) {
+			return kind.PublicError, true
+		}
+	}
+	return PublicError{}, false
+}
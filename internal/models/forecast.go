@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+type ForecastParams struct {
+	Lat  float64
+	Lon  float64
+	Days int
+}
+
+type HourlyForecast struct {
+	Time        time.Time `json:"time"`
+	Temperature float64   `json:"temperature"`
+	WeatherCode int       `json:"weathercode"`
+}
+
+type DailyForecast struct {
+	Date           time.Time `json:"date"`
+	TemperatureMax float64   `json:"temperature_max"`
+	TemperatureMin float64   `json:"temperature_min"`
+	Precipitation  float64   `json:"precipitation"`
+	WeatherCode    int       `json:"weathercode"`
+}
+
+type ForecastResult struct {
+	Timezone         string           `json:"timezone"`
+	UTCOffsetSeconds int              `json:"utc_offset_seconds"`
+	Hourly           []HourlyForecast `json:"hourly"`
+	Daily            []DailyForecast  `json:"daily"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "fmt"
+
+// Языки интерфейса бота
+const (
+	LanguageRU = "ru"
+	LanguageEN = "en"
+)
+
+// Единицы измерения температуры
+const (
+	UnitsCelsius    = "c"
+	UnitsFahrenheit = "f"
+)
+
+// ChatPreferences настройки чата в Telegram
+type ChatPreferences struct {
+	ChatID          int64
+	Language        string
+	Units           string
+	HomeCity        string
+	FavouriteCities []string
+}
+
+// DefaultChatPreferences возвращает настройки для чата, который их еще не менял
+func DefaultChatPreferences(chatID int64) *ChatPreferences {
+	return &ChatPreferences{
+		ChatID:   chatID,
+		Language: LanguageRU,
+		Units:    UnitsCelsius,
+	}
+}
+
+// FormatTemperature форматирует температуру в градусах Цельсия в выбранных единицах
+func FormatTemperature(celsius float64, units string) string {
+	if units == UnitsFahrenheit {
+		return fmt.Sprintf("%.1f°F", celsius*9/5+32)
+	}
+	return fmt.Sprintf("%.1f°C", celsius)
+}
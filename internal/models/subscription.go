@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// Subscription подписка чата на ежедневную сводку погоды
+type Subscription struct {
+	ChatID   int64
+	CityName string
+	// SendTime локальное время отправки в формате HH:MM
+	SendTime string
+	Timezone string
+	// LastSentOn локальная дата последней отправленной сводки
+	LastSentOn *time.Time
+	// FailedAttempts сколько раз подряд не удалось доставить сводку
+	FailedAttempts int
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type scheduledPriorityKey struct{}
+
+// WithScheduledPriority помечает запросы плановых задач, например рассылки сводок.
+// Для них держится запас суточного лимита внешних сервисов, недоступный остальным запросам.
+func WithScheduledPriority(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scheduledPriorityKey{}, true)
+}
+
+// IsScheduledPriority проверяет, что запрос пришел от плановой задачи
+func IsScheduledPriority(ctx context.Context) bool {
+	scheduled, _ := ctx.Value(scheduledPriorityKey{}).(bool)
+	return scheduled
+}
+
+type upstreamChargeKey struct{}
+
+// upstreamCharge списание с клиента за запросы к внешним сервисам
+type upstreamCharge struct {
+	charge func(ctx context.Context) error
+	calls  atomic.Int64
+}
+
+// WithUpstreamCharge задает, как списывать с клиента запросы к внешним сервисам.
+// Первый такой запрос оплачен самим запросом клиента, за каждый следующий
+// вызывается charge: пакет или GraphQL запрос может обойти кэш много раз.
+func WithUpstreamCharge(ctx context.Context, charge func(ctx context.Context) error) context.Context {
+	return context.WithValue(ctx, upstreamChargeKey{}, &upstreamCharge{charge: charge})
+}
+
+// ChargeUpstreamCall списывает с клиента запрос к внешнему сервису. Вызывается
+// перед каждым запросом, который не обслужен из кэша.
+func ChargeUpstreamCall(ctx context.Context) error {
+	charge, ok := ctx.Value(upstreamChargeKey{}).(*upstreamCharge)
+	if !ok || charge.calls.Add(1) == 1 {
+		return nil
+	}
+	return charge.charge(ctx)
+}
@@ -9,6 +9,10 @@ type CurrentWeather struct {
 	Temperature float64 `json:"temperature"`
 	WeatherCode int     `json:"weathercode"`
 	WeatherDesc string  `json:"weather_description"`
+	// Time время наблюдения по UTC в формате Open-Meteo "2006-01-02T15:04"
+	Time          string  `json:"time"`
+	WindSpeed     float64 `json:"windspeed"`
+	WindDirection float64 `json:"winddirection"`
 }
 
 type WeatherResult struct {
@@ -40,3 +44,32 @@ func GetWeatherDescription(code int) string { // функция GetWeatherDescri
 	}
 	return "Неизвестно" //  если нет - неизвестно
 }
+
+var WeatherCodeMapEN = map[int]string{ // описания погоды на английском для интерфейса бота
+	0:  "Clear sky",
+	1:  "Mainly clear",
+	2:  "Partly cloudy",
+	3:  "Overcast",
+	45: "Fog",
+	48: "Depositing rime fog",
+	51: "Light drizzle",
+	53: "Moderate drizzle",
+	55: "Dense drizzle",
+	61: "Slight rain",
+	63: "Moderate rain",
+	65: "Heavy rain",
+	71: "Slight snow",
+	73: "Moderate snow",
+	75: "Heavy snow",
+	95: "Thunderstorm",
+}
+
+func GetWeatherDescriptionLang(code int, language string) string { // описание погоды на языке language, по умолчанию на русском
+	if language != LanguageEN {
+		return GetWeatherDescription(code)
+	}
+	if desc, ok := WeatherCodeMapEN[code]; ok {
+		return desc
+	}
+	return "Unknown"
+}
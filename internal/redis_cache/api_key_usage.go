@@ -0,0 +1,38 @@
+package redis_cache
+
+import (
+	"context"
+	"time"
+	"weather-api/internal/adapters/redis"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+)
+
+// Проверка, что тип реализует интерфейс
+var _ repository.APIKeyUsageCounter = (*APIKeyUsageRedis)(nil)
+
+// apiKeyUsageTTL счетчик живет дольше суток, чтобы его можно было посмотреть на следующий день
+const apiKeyUsageTTL = 48 * time.Hour
+
+func apiKeyUsageKey(id string, day time.Time) string {
+	return "api_key_usage:" + id + ":" + day.UTC().Format(time.DateOnly)
+}
+
+// APIKeyUsageRedis считает запросы по ключам за сутки в Redis, чтобы квота
+// была общей для всех экземпляров сервиса
+type APIKeyUsageRedis struct {
+	redisClient *redis.Client
+}
+
+// NewAPIKeyUsageRedis создает счетчик использования ключей
+func NewAPIKeyUsageRedis(redisClient *redis.Client) *APIKeyUsageRedis {
+	return &APIKeyUsageRedis{redisClient: redisClient}
+}
+
+// IncrementUsage увеличивает счетчик ключа за день
+func (c *APIKeyUsageRedis) IncrementUsage(ctx context.Context, id string, day time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "APIKeyUsageRedis.IncrementUsage")
+	defer func() { tracing.End(span, err) }()
+
+	return c.redisClient.Incr(ctx, apiKeyUsageKey(id, day), apiKeyUsageTTL)
+}
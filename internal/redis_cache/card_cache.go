@@ -0,0 +1,56 @@
+package redis_cache
+
+import (
+	"context"
+	"weather-api/internal/adapters/redis"
+	"weather-api/internal/repository"
+	"weather-api/pkg/metrics"
+	"weather-api/pkg/tracing"
+)
+
+// Проверка, что тип реализует интерфейс
+var _ repository.CardCache = (*CardCacheRedis)(nil)
+
+func cardCacheKey(key string) string {
+	return "card:" + key
+}
+
+// CardCacheRedis хранит отрисованные карточки погоды в Redis
+type CardCacheRedis struct {
+	redisClient *redis.Client
+	metrics     *metrics.Metrics
+}
+
+// NewCardCacheRedis создает кэш карточек
+func NewCardCacheRedis(redisClient *redis.Client, metrics *metrics.Metrics) *CardCacheRedis {
+	return &CardCacheRedis{
+		redisClient: redisClient,
+		metrics:     metrics,
+	}
+}
+
+// Get возвращает карточку из кэша или ошибку, если ее нет
+func (c *CardCacheRedis) Get(ctx context.Context, key string) (_ []byte, err error) {
+	ctx, span := tracer.Start(ctx, "CardCacheRedis.Get")
+	defer func() { tracing.End(span, err) }()
+
+	cachedData, err := c.redisClient.Get(ctx, cardCacheKey(key))
+	if err != nil {
+		if c.metrics != nil {
+			c.metrics.CacheMisses.WithLabelValues("card").Inc()
+		}
+		return nil, err
+	}
+	if c.metrics != nil {
+		c.metrics.CacheHits.WithLabelValues("card").Inc()
+	}
+	return []byte(cachedData), nil
+}
+
+// Set сохраняет карточку с TTL клиента Redis
+func (c *CardCacheRedis) Set(ctx context.Context, key string, card []byte) (err error) {
+	ctx, span := tracer.Start(ctx, "CardCacheRedis.Set")
+	defer func() { tracing.End(span, err) }()
+
+	return c.redisClient.Set(ctx, cardCacheKey(key), card)
+}
@@ -3,14 +3,34 @@ package redis_cache
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 	"weather-api/internal/adapters/redis"
 	"weather-api/internal/models"
 	"weather-api/internal/repository"
 	"weather-api/pkg/metrics"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
 )
 
+var tracer = otel.Tracer("weather-api/internal/redis_cache")
+
+// Проверка, что тип реализует интерфейсы
+var _ repository.CityRepository = (*CityRepositoryRedis)(nil)
+var _ repository.CityBatchRepository = (*CityRepositoryRedis)(nil)
+var _ repository.CityCacheInvalidator = (*CityRepositoryRedis)(nil)
+var _ repository.CityCacheTTL = (*CityRepositoryRedis)(nil)
+
+// allCitiesCacheKey ключ кэша со списком всех городов. Версия в ключе меняется
+// вместе с составом полей models.City, чтобы не читать старые записи без новых полей.
+const allCitiesCacheKey = "cities:v2:all"
+
+func cityCacheKey(name string) string {
+	return fmt.Sprintf("city:v2:%s", name)
+}
+
 // CityRepositoryRedis - кэширующий прокси для репозитория городов
 type CityRepositoryRedis struct {
 	redisClient  *redis.Client
@@ -28,11 +48,14 @@ func NewCityRepositoryRedis(redisClient *redis.Client, postgresRepo repository.C
 }
 
 // GetCityByName получает город по имени с кэшированием
-func (r *CityRepositoryRedis) GetCityByName(ctx context.Context, name string) (*models.City, error) {
+func (r *CityRepositoryRedis) GetCityByName(ctx context.Context, name string) (_ *models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.GetCityByName")
+	defer func() { tracing.End(span, err) }()
+
 	start := time.Now()
 
 	// 1. Проверяем кэш
-	cacheKey := fmt.Sprintf("city:%s", name)
+	cacheKey := cityCacheKey(name)
 	cachedData, err := r.redisClient.Get(ctx, cacheKey)
 
 	// Если нашли в кэше и нет ошибки - возвращаем
@@ -86,11 +109,14 @@ func (r *CityRepositoryRedis) GetCityByName(ctx context.Context, name string) (*
 }
 
 // GetAllCities получает все города
-func (r *CityRepositoryRedis) GetAllCities(ctx context.Context) ([]models.City, error) {
+func (r *CityRepositoryRedis) GetAllCities(ctx context.Context) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.GetAllCities")
+	defer func() { tracing.End(span, err) }()
+
 	start := time.Now()
 
 	// Реализуем аналогичную логику кэширования для списка всех городов
-	cacheKey := "cities:all"
+	cacheKey := allCitiesCacheKey
 	cachedData, err := r.redisClient.Get(ctx, cacheKey)
 
 	if err == nil {
@@ -140,3 +166,134 @@ func (r *CityRepositoryRedis) GetAllCities(ctx context.Context) ([]models.City,
 
 	return cities, nil
 }
+
+// GetCitiesByNames получает несколько городов: найденные в кэше одним MGET,
+// остальные одним запросом к PostgreSQL
+func (r *CityRepositoryRedis) GetCitiesByNames(ctx context.Context, names []string) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.GetCitiesByNames")
+	defer func() { tracing.End(span, err) }()
+
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = cityCacheKey(name)
+	}
+	cached, err := r.redisClient.MGet(ctx, keys...)
+	if err != nil {
+		// Кэш только ускоряет запрос, без него все города берем из PostgreSQL
+		cached = nil
+	}
+
+	cities := make([]models.City, 0, len(names))
+	var missing []string
+	for i, name := range names {
+		var city models.City
+		if data, ok := cached[keys[i]]; ok && json.Unmarshal([]byte(data), &city) == nil {
+			cities = append(cities, city)
+			continue
+		}
+		missing = append(missing, name)
+	}
+
+	if r.metrics != nil {
+		r.metrics.CacheHits.WithLabelValues("city").Add(float64(len(cities)))
+		r.metrics.CacheMisses.WithLabelValues("city").Add(float64(len(missing)))
+	}
+	if len(missing) == 0 {
+		return cities, nil
+	}
+
+	dbStart := time.Now()
+	loaded, err := r.loadCities(ctx, missing)
+	dbDuration := time.Since(dbStart).Seconds()
+
+	if r.metrics != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		r.metrics.DatabaseRequestsTotal.WithLabelValues("get_cities_by_names", status).Inc()
+		r.metrics.DatabaseRequestDuration.WithLabelValues("get_cities_by_names").Observe(dbDuration)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, city := range loaded {
+		if cityJSON, err := json.Marshal(city); err == nil {
+			_ = r.redisClient.Set(ctx, cityCacheKey(city.Name), cityJSON)
+		}
+	}
+
+	return append(cities, loaded...), nil
+}
+
+// loadCities получает города из PostgreSQL одним запросом, если репозиторий это умеет
+func (r *CityRepositoryRedis) loadCities(ctx context.Context, names []string) ([]models.City, error) {
+	if batchRepo, ok := r.postgresRepo.(repository.CityBatchRepository); ok {
+		return batchRepo.GetCitiesByNames(ctx, names)
+	}
+
+	var cities []models.City
+	for _, name := range names {
+		city, err := r.postgresRepo.GetCityByName(ctx, name)
+		if errors.Is(err, models.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		cities = append(cities, *city)
+	}
+	return cities, nil
+}
+
+// CreateCity добавляет город и сбрасывает закэшированный список городов
+func (r *CityRepositoryRedis) CreateCity(ctx context.Context, city models.City) (err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.CreateCity")
+	defer func() { tracing.End(span, err) }()
+
+	dbStart := time.Now()
+	err = r.postgresRepo.CreateCity(ctx, city)
+	dbDuration := time.Since(dbStart).Seconds()
+
+	if r.metrics != nil {
+		status := "success"
+		if err != nil {
+			status = "error"
+		}
+		r.metrics.DatabaseRequestsTotal.WithLabelValues("create_city", status).Inc()
+		r.metrics.DatabaseRequestDuration.WithLabelValues("create_city").Observe(dbDuration)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return r.InvalidateCity(ctx, city.Name)
+}
+
+// InvalidateCity удаляет город и список городов из кэша
+func (r *CityRepositoryRedis) InvalidateCity(ctx context.Context, name string) (err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.InvalidateCity")
+	defer func() { tracing.End(span, err) }()
+
+	return r.redisClient.Del(ctx, cityCacheKey(name), allCitiesCacheKey)
+}
+
+// AllCitiesTTL возвращает оставшееся время жизни списка городов в кэше
+func (r *CityRepositoryRedis) AllCitiesTTL(ctx context.Context) (_ time.Duration, err error) {
+	ctx, span := tracer.Start(ctx, "CityRepositoryRedis.AllCitiesTTL")
+	defer func() { tracing.End(span, err) }()
+
+	ttl, err := r.redisClient.TTL(ctx, allCitiesCacheKey)
+	if err != nil {
+		return 0, err
+	}
+	// Redis возвращает отрицательные значения для отсутствующих ключей и ключей без срока
+	return max(ttl, 0), nil
+}
@@ -0,0 +1,91 @@
+package redis_cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"weather-api/internal/adapters/redis"
+	"weather-api/internal/dto"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+)
+
+// Проверка, что тип реализует интерфейс
+var _ repository.RateLimiter = (*RateLimiterRedis)(nil)
+
+// tokenBucketScript корзина токенов в хэше {tokens, ts}. Время берется из Redis,
+// чтобы часы всех экземпляров сервиса не влияли на результат.
+// Возвращает {разрешен, осталось токенов, мс до нового токена, мс до полной корзины}.
+var tokenBucketScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+	tokens = burst
+	ts = now
+end
+tokens = math.min(burst, tokens + math.max(0, now - ts) * rate / 1000)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	retry = math.ceil((1 - tokens) * 1000 / rate)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * 1000 / rate) + 1000)
+
+return {allowed, math.floor(tokens), retry, math.ceil((burst - tokens) * 1000 / rate)}
+`)
+
+func rateLimitKey(key string) string {
+	return "ratelimit:" + key
+}
+
+// RateLimiterRedis ограничивает частоту запросов общей для всех экземпляров корзиной в Redis
+type RateLimiterRedis struct {
+	redisClient *redis.Client
+}
+
+// NewRateLimiterRedis создает ограничитель частоты запросов
+func NewRateLimiterRedis(redisClient *redis.Client) *RateLimiterRedis {
+	return &RateLimiterRedis{redisClient: redisClient}
+}
+
+// Allow списывает один запрос из корзины key атомарно
+func (l *RateLimiterRedis) Allow(ctx context.Context, key string, limit dto.RateLimit) (_ *dto.RateLimitDecision, err error) {
+	ctx, span := tracer.Start(ctx, "RateLimiterRedis.Allow")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := l.redisClient.RunScript(ctx, tokenBucketScript, []string{rateLimitKey(key)}, limit.Rate, limit.Burst)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := result.([]any)
+	if !ok || len(values) != 4 {
+		return nil, fmt.Errorf("unexpected rate limit script result: %v", result)
+	}
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return nil, fmt.Errorf("unexpected rate limit script result: %v", result)
+		}
+	}
+
+	return &dto.RateLimitDecision{
+		Allowed:    numbers[0] == 1,
+		Limit:      limit.Burst,
+		Remaining:  int(numbers[1]),
+		RetryAfter: time.Duration(numbers[2]) * time.Millisecond,
+		Reset:      time.Duration(numbers[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,85 @@
+package redis_cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+	"weather-api/internal/adapters/redis"
+	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+)
+
+// Проверка, что тип реализует интерфейс
+var _ repository.UpstreamBudgetCounter = (*UpstreamBudgetRedis)(nil)
+
+// upstreamBudgetTTL счетчик живет дольше суток, чтобы его можно было посмотреть на следующий день
+const upstreamBudgetTTL = 48 * time.Hour
+
+// reserveScript увеличивает счетчик, только если он меньше лимита ARGV[1].
+// Возвращает {учтен ли запрос, значение счетчика}.
+var reserveScript = redis.NewScript(`
+local used = tonumber(redis.call('GET', KEYS[1]) or '0')
+if used >= tonumber(ARGV[1]) then
+	return {0, used}
+end
+used = redis.call('INCR', KEYS[1])
+if used == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return {1, used}
+`)
+
+func upstreamBudgetKey(provider string, day time.Time) string {
+	return "upstream_budget:" + provider + ":" + day.UTC().Format(time.DateOnly)
+}
+
+// UpstreamBudgetRedis считает запросы к внешним сервисам в Redis, чтобы лимит
+// был общим для всех экземпляров сервиса
+type UpstreamBudgetRedis struct {
+	redisClient *redis.Client
+}
+
+// NewUpstreamBudgetRedis создает счетчик запросов к внешним сервисам
+func NewUpstreamBudgetRedis(redisClient *redis.Client) *UpstreamBudgetRedis {
+	return &UpstreamBudgetRedis{redisClient: redisClient}
+}
+
+// ReserveUpstreamCall атомарно учитывает запрос, если лимит не исчерпан
+func (c *UpstreamBudgetRedis) ReserveUpstreamCall(ctx context.Context, provider string, day time.Time, limit int64) (_ bool, _ int64, err error) {
+	ctx, span := tracer.Start(ctx, "UpstreamBudgetRedis.ReserveUpstreamCall")
+	defer func() { tracing.End(span, err) }()
+
+	result, err := c.redisClient.RunScript(ctx, reserveScript, []string{upstreamBudgetKey(provider, day)},
+		limit, upstreamBudgetTTL.Milliseconds())
+	if err != nil {
+		return false, 0, err
+	}
+
+	values, ok := result.([]any)
+	if !ok || len(values) != 2 {
+		return false, 0, fmt.Errorf("unexpected upstream budget script result: %v", result)
+	}
+	reserved, ok1 := values[0].(int64)
+	used, ok2 := values[1].(int64)
+	if !ok1 || !ok2 {
+		return false, 0, fmt.Errorf("unexpected upstream budget script result: %v", result)
+	}
+	return reserved == 1, used, nil
+}
+
+// UpstreamCalls возвращает число запросов за день
+func (c *UpstreamBudgetRedis) UpstreamCalls(ctx context.Context, provider string, day time.Time) (_ int64, err error) {
+	ctx, span := tracer.Start(ctx, "UpstreamBudgetRedis.UpstreamCalls")
+	defer func() { tracing.End(span, err) }()
+
+	value, err := c.redisClient.Get(ctx, upstreamBudgetKey(provider, day))
+	if errors.Is(err, redis.ErrNil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"time"
+	"weather-api/internal/dto"
 	"weather-api/internal/models"
 )
 
@@ -9,9 +11,122 @@ import (
 type CityRepository interface {
 	GetCityByName(ctx context.Context, name string) (*models.City, error)
 	GetAllCities(ctx context.Context) ([]models.City, error)
+	CreateCity(ctx context.Context, city models.City) error
 }
 
 // WeatherRepository определяет методы для получения погоды
 type WeatherRepository interface {
 	WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error)
+	WeatherForecast(ctx context.Context, params models.ForecastParams) (*models.ForecastResult, error)
+}
+
+// SubscriptionRepository определяет методы для работы с подписками на сводку
+type SubscriptionRepository interface {
+	Upsert(ctx context.Context, subscription models.Subscription) error
+	Delete(ctx context.Context, chatID int64) error
+	// ClaimDue атомарно помечает отправленными все подписки, время которых наступило,
+	// и возвращает их. Одну подписку в один день получает только один вызывающий.
+	ClaimDue(ctx context.Context) ([]models.Subscription, error)
+	// Release снимает отметку об отправке и увеличивает счетчик неудачных попыток,
+	// чтобы сводка была отправлена повторно не раньше retryAt
+	Release(ctx context.Context, chatID int64, sentOn time.Time, retryAt time.Time) error
+	// Unclaim снимает отметку об отправке, не считая это неудачной попыткой
+	Unclaim(ctx context.Context, chatID int64, sentOn time.Time) error
+	// ResetAttempts обнуляет счетчик неудачных попыток
+	ResetAttempts(ctx context.Context, chatID int64) error
+	// Disable отключает подписку чата, который больше не принимает сообщения.
+	// Повторная подписка включает ее снова.
+	Disable(ctx context.Context, chatID int64) error
+}
+
+// PreferencesRepository определяет методы для работы с настройками чатов
+type PreferencesRepository interface {
+	// Get возвращает настройки чата или настройки по умолчанию, если их нет
+	Get(ctx context.Context, chatID int64) (*models.ChatPreferences, error)
+	Save(ctx context.Context, preferences models.ChatPreferences) error
+}
+
+// ChatRepository определяет методы для учета чатов, которые писали боту
+type ChatRepository interface {
+	Touch(ctx context.Context, chatID int64) error
+	Count(ctx context.Context) (int, error)
+	ListIDs(ctx context.Context) ([]int64, error)
+}
+
+// CityCacheInvalidator определяет метод для сброса кэша города
+type CityCacheInvalidator interface {
+	InvalidateCity(ctx context.Context, name string) error
+}
+
+// WeatherCacheInvalidator определяет метод для сброса кэша погоды по координатам
+type WeatherCacheInvalidator interface {
+	InvalidateLocation(ctx context.Context, lat, lon float64) error
+}
+
+// CardCache определяет методы для кэширования отрисованных карточек погоды
+type CardCache interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Set(ctx context.Context, key string, card []byte) error
+}
+
+// WeatherBatchCache определяет метод для пакетного чтения погоды из кэша
+type WeatherBatchCache interface {
+	// CachedWeatherToday возвращает погоду для каждой точки или nil, если ее нет в кэше
+	CachedWeatherToday(ctx context.Context, params []models.WeatherTodayParams) ([]*models.WeatherResult, error)
+}
+
+// WeatherCacheTTL определяет метод для получения оставшегося времени жизни записи кэша погоды
+type WeatherCacheTTL interface {
+	// WeatherTodayTTL возвращает 0, если записи нет или у нее нет срока жизни
+	WeatherTodayTTL(ctx context.Context, params models.WeatherTodayParams) (time.Duration, error)
+}
+
+// CityCacheTTL определяет метод для получения оставшегося времени жизни списка городов в кэше
+type CityCacheTTL interface {
+	// AllCitiesTTL возвращает 0, если записи нет или у нее нет срока жизни
+	AllCitiesTTL(ctx context.Context) (time.Duration, error)
+}
+
+// CityBatchRepository определяет метод для получения нескольких городов одним запросом
+type CityBatchRepository interface {
+	// GetCitiesByNames возвращает найденные города, отсутствующие пропускаются
+	GetCitiesByNames(ctx context.Context, names []string) ([]models.City, error)
+}
+
+// APIKeyRepository определяет методы для хранения ключей доступа к HTTP API
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey) error
+	// GetAPIKey возвращает ключ вместе с отозванными или models.ErrNotFound
+	GetAPIKey(ctx context.Context, id string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]models.APIKey, error)
+	// RevokeAPIKey отзывает ключ, повторный отзыв не меняет время отзыва
+	RevokeAPIKey(ctx context.Context, id string) error
+}
+
+// APIKeyUsageCounter определяет метод для учета запросов по ключу за сутки
+type APIKeyUsageCounter interface {
+	// IncrementUsage увеличивает счетчик ключа за день day и возвращает новое значение
+	IncrementUsage(ctx context.Context, id string, day time.Time) (int64, error)
+}
+
+// RateLimiter определяет метод для ограничения частоты запросов по корзине токенов
+type RateLimiter interface {
+	// Allow списывает один запрос из корзины key
+	Allow(ctx context.Context, key string, limit dto.RateLimit) (*dto.RateLimitDecision, error)
+}
+
+// UpstreamBudgetCounter определяет методы для учета запросов к внешним сервисам за сутки
+type UpstreamBudgetCounter interface {
+	// ReserveUpstreamCall учитывает запрос, только если за день их было меньше limit.
+	// Возвращает, учтен ли запрос, и сколько запросов сделано за день.
+	ReserveUpstreamCall(ctx context.Context, provider string, day time.Time, limit int64) (bool, int64, error)
+	// UpstreamCalls возвращает число запросов за день
+	UpstreamCalls(ctx context.Context, provider string, day time.Time) (int64, error)
+}
+
+// UpstreamBudget определяет метод для проверки режима экономии запросов к внешнему сервису
+type UpstreamBudget interface {
+	// StaleOnly сообщает, что лимит почти исчерпан и обычные запросы
+	// должны обслуживаться только из кэша, в том числе устаревшего
+	StaleOnly(ctx context.Context) bool
 }
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/pkg/metrics"
+)
+
+// chatTouchInterval как часто обновлять в базе время активности одного чата
+const chatTouchInterval = time.Hour
+
+var (
+	ErrInvalidCoordinates = fmt.Errorf("%w: latitude must be in [-90, 90] and longitude in [-180, 180]", models.ErrInvalidInput)
+)
+
+type AdminUseCaseOptions struct {
+	CityRepository    repository.CityRepository
+	WeatherRepository repository.WeatherRepository
+	ChatRepository    repository.ChatRepository
+	CityCache         repository.CityCacheInvalidator
+	WeatherCache      repository.WeatherCacheInvalidator
+	Metrics           *metrics.Metrics
+}
+
+// AdminUseCase операции для администраторов бота. Здесь же учитываются чаты,
+// писавшие боту: по ним считается статистика и идет рассылка.
+type AdminUseCase struct {
+	options AdminUseCaseOptions
+
+	// touched когда чат последний раз отмечался в базе этим экземпляром
+	touchedMu sync.Mutex
+	touched   map[int64]time.Time
+	lastPrune time.Time
+	// now текущее время, в тестах подменяется
+	now func() time.Time
+}
+
+func NewAdminUseCase(options AdminUseCaseOptions) *AdminUseCase {
+	if options.CityRepository == nil {
+		panic("city repository must not be nil")
+	}
+	if options.WeatherRepository == nil {
+		panic("weather repository must not be nil")
+	}
+	if options.ChatRepository == nil {
+		panic("chat repository must not be nil")
+	}
+	if options.CityCache == nil {
+		panic("city cache must not be nil")
+	}
+	if options.WeatherCache == nil {
+		panic("weather cache must not be nil")
+	}
+	return &AdminUseCase{
+		options:   options,
+		touched:   make(map[int64]time.Time),
+		lastPrune: time.Now(),
+		now:       time.Now,
+	}
+}
+
+// TrackChat отмечает активность чата. В базу пишется первое сообщение чата
+// и дальше не чаще раза в chatTouchInterval, а не каждое сообщение.
+func (usecase *AdminUseCase) TrackChat(ctx context.Context, chatID int64) error {
+	now := usecase.now()
+
+	usecase.touchedMu.Lock()
+	usecase.pruneTouched(now)
+	touchedAt, ok := usecase.touched[chatID]
+	if ok && now.Sub(touchedAt) < chatTouchInterval {
+		usecase.touchedMu.Unlock()
+		return nil
+	}
+	usecase.touched[chatID] = now
+	usecase.touchedMu.Unlock()
+
+	if err := usecase.options.ChatRepository.Touch(ctx, chatID); err != nil {
+		// Следующее сообщение чата попробует записать снова
+		usecase.touchedMu.Lock()
+		delete(usecase.touched, chatID)
+		usecase.touchedMu.Unlock()
+		return fmt.Errorf("chat repository failed: %w", err)
+	}
+	return nil
+}
+
+// pruneTouched удаляет устаревшие отметки, чтобы карта не росла бесконечно.
+// Вызывается под touchedMu.
+func (usecase *AdminUseCase) pruneTouched(now time.Time) {
+	if now.Sub(usecase.lastPrune) < chatTouchInterval {
+		return
+	}
+	for chatID, touchedAt := range usecase.touched {
+		if now.Sub(touchedAt) >= chatTouchInterval {
+			delete(usecase.touched, chatID)
+		}
+	}
+	usecase.lastPrune = now
+}
+
+func (usecase *AdminUseCase) Stats(ctx context.Context) (*dto.AdminStats, error) {
+	users, err := usecase.options.ChatRepository.Count(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("chat repository failed: %w", err)
+	}
+
+	stats := &dto.AdminStats{Users: users}
+	if usecase.options.Metrics != nil {
+		snapshot := usecase.options.Metrics.Snapshot()
+		stats.HttpRequests = snapshot.HttpRequests
+		stats.TelegramUpdates = snapshot.TelegramUpdates
+		stats.CacheHits = snapshot.CacheHits
+		stats.CacheMisses = snapshot.CacheMisses
+		stats.CacheHitRatio = snapshot.CacheHitRatio()
+	}
+
+	return stats, nil
+}
+
+// AddCity добавляет город. Часовой пояс определяется по координатам через API погоды.
+func (usecase *AdminUseCase) AddCity(ctx context.Context, city models.City) (*models.City, error) {
+	if !validCoordinates(city.Latitude, city.Longitude) {
+		return nil, ErrInvalidCoordinates
+	}
+
+	forecast, err := usecase.options.WeatherRepository.WeatherForecast(ctx, models.ForecastParams{
+		Lat:  city.Latitude,
+		Lon:  city.Longitude,
+		Days: 1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("weather repository failed: %w", err)
+	}
+	city.Timezone = forecast.Timezone
+
+	if err := usecase.options.CityRepository.CreateCity(ctx, city); err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+
+	return &city, nil
+}
+
+// FlushCityCache сбрасывает кэш города и погоды по его координатам
+func (usecase *AdminUseCase) FlushCityCache(ctx context.Context, cityName string) error {
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return fmt.Errorf("city repository failed: %w", err)
+	}
+
+	if err := usecase.options.WeatherCache.InvalidateLocation(ctx, city.Latitude, city.Longitude); err != nil {
+		return fmt.Errorf("weather cache failed: %w", err)
+	}
+	if err := usecase.options.CityCache.InvalidateCity(ctx, city.Name); err != nil {
+		return fmt.Errorf("city cache failed: %w", err)
+	}
+
+	return nil
+}
+
+// BroadcastRecipients возвращает все чаты для рассылки
+func (usecase *AdminUseCase) BroadcastRecipients(ctx context.Context) ([]int64, error) {
+	ids, err := usecase.options.ChatRepository.ListIDs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("chat repository failed: %w", err)
+	}
+	return ids, nil
+}
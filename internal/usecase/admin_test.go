@@ -0,0 +1,95 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"weather-api/internal/models"
+)
+
+// fakeChatRepository считает записи об активности чатов
+type fakeChatRepository struct {
+	touches []int64
+	err     error
+}
+
+func (f *fakeChatRepository) Touch(ctx context.Context, chatID int64) error {
+	f.touches = append(f.touches, chatID)
+	return f.err
+}
+
+func (f *fakeChatRepository) Count(ctx context.Context) (int, error) {
+	return 0, nil
+}
+
+func (f *fakeChatRepository) ListIDs(ctx context.Context) ([]int64, error) {
+	return nil, nil
+}
+
+type fakeWeatherRepository struct{}
+
+func (fakeWeatherRepository) WeatherToday(ctx context.Context, params models.WeatherTodayParams) (*models.WeatherResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (fakeWeatherRepository) WeatherForecast(ctx context.Context, params models.ForecastParams) (*models.ForecastResult, error) {
+	return nil, errors.New("not implemented")
+}
+
+type fakeCacheInvalidator struct{}
+
+func (fakeCacheInvalidator) InvalidateCity(ctx context.Context, name string) error {
+	return nil
+}
+
+func (fakeCacheInvalidator) InvalidateLocation(ctx context.Context, lat, lon float64) error {
+	return nil
+}
+
+// TestTrackChat проверяет, что активность чата пишется в базу при первом
+// сообщении и потом не чаще раза в chatTouchInterval
+func TestTrackChat(t *testing.T) {
+	chats := &fakeChatRepository{}
+	usecase := NewAdminUseCase(AdminUseCaseOptions{
+		CityRepository:    &fakeCityRepository{},
+		WeatherRepository: fakeWeatherRepository{},
+		ChatRepository:    chats,
+		CityCache:         fakeCacheInvalidator{},
+		WeatherCache:      fakeCacheInvalidator{},
+	})
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	usecase.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		chatID  int64
+		err     error
+		want    []int64
+	}{
+		{name: "first message", chatID: 1, want: []int64{1}},
+		{name: "repeated message", advance: time.Minute, chatID: 1, want: []int64{1}},
+		{name: "other chat", chatID: 2, want: []int64{1, 2}},
+		{name: "after interval", advance: chatTouchInterval, chatID: 1, want: []int64{1, 2, 1}},
+		{name: "failed write", chatID: 3, err: errors.New("db is down"), want: []int64{1, 2, 1, 3}},
+		{name: "retry after failure", chatID: 3, want: []int64{1, 2, 1, 3, 3}},
+	}
+	for _, s := range steps {
+		now = now.Add(s.advance)
+		chats.err = s.err
+		err := usecase.TrackChat(ctx, s.chatID)
+		if (err != nil) != (s.err != nil) {
+			t.Fatalf("%s: error = %v, want %v", s.name, err, s.err)
+		}
+		if len(chats.touches) != len(s.want) {
+			t.Fatalf("%s: touches = %v, want %v", s.name, chats.touches, s.want)
+		}
+		for i := range s.want {
+			if chats.touches[i] != s.want[i] {
+				t.Fatalf("%s: touches = %v, want %v", s.name, chats.touches, s.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// alertForecastDays на сколько дней вперед ищем опасную погоду
+const alertForecastDays = 2
+
+// Пороги предупреждений
+const (
+	heavyPrecipitationMM       = 20.0
+	severePrecipitationMM      = 50.0
+	heatTemperature            = 30.0
+	severeHeatTemperature      = 35.0
+	frostTemperature           = -20.0
+	severeFrostTemperature     = -30.0
+	severeThunderstormCodeFrom = 96 // гроза с градом
+)
+
+// GetAlerts возвращает предупреждения об опасной погоде по координатам.
+// Open-Meteo не отдает официальные предупреждения, поэтому они вычисляются по прогнозу.
+func (usecase *WeatherUseCase) GetAlerts(ctx context.Context, lat, lon float64) (_ []dto.WeatherAlert, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetAlerts", trace.WithAttributes(
+		attribute.Float64("weather.lat", lat),
+		attribute.Float64("weather.lon", lon),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	forecast, err := usecase.GetForecast(ctx, dto.GetForecastParams{Lat: lat, Lon: lon, Days: alertForecastDays})
+	if err != nil {
+		return nil, err
+	}
+	return alertsFromForecast(forecast), nil
+}
+
+// alertsFromForecast ищет грозы в почасовом прогнозе, а осадки, жару и мороз в дневном
+func alertsFromForecast(forecast *dto.ForecastResult) []dto.WeatherAlert {
+	alerts := []dto.WeatherAlert{}
+
+	// Соседние грозовые часы объединяем в одно предупреждение
+	var storm *dto.WeatherAlert
+	for _, hour := range forecast.Hourly {
+		if hour.WeatherCode < 95 {
+			storm = nil
+			continue
+		}
+		severity := dto.AlertSeverityModerate
+		if hour.WeatherCode >= severeThunderstormCodeFrom {
+			severity = dto.AlertSeveritySevere
+		}
+		if storm != nil && storm.End.Equal(hour.Time) {
+			storm.End = hour.Time.Add(time.Hour)
+			if severity == dto.AlertSeveritySevere {
+				storm.Severity = severity
+			}
+			continue
+		}
+		alerts = append(alerts, dto.WeatherAlert{
+			Event:       dto.AlertThunderstorm,
+			Severity:    severity,
+			Start:       hour.Time,
+			End:         hour.Time.Add(time.Hour),
+			Description: hour.WeatherDesc,
+		})
+		storm = &alerts[len(alerts)-1]
+	}
+
+	for _, day := range forecast.Daily {
+		start, end := day.Date, day.Date.AddDate(0, 0, 1)
+		if day.Precipitation >= heavyPrecipitationMM {
+			alerts = append(alerts, dto.WeatherAlert{
+				Event:       dto.AlertHeavyPrecipitation,
+				Severity:    severityFor(day.Precipitation >= severePrecipitationMM),
+				Start:       start,
+				End:         end,
+				Description: fmt.Sprintf("Сильные осадки: %.0f мм", day.Precipitation),
+			})
+		}
+		if day.TemperatureMax >= heatTemperature {
+			alerts = append(alerts, dto.WeatherAlert{
+				Event:       dto.AlertHeat,
+				Severity:    severityFor(day.TemperatureMax >= severeHeatTemperature),
+				Start:       start,
+				End:         end,
+				Description: fmt.Sprintf("Жара до %.0f°C", day.TemperatureMax),
+			})
+		}
+		if day.TemperatureMin <= frostTemperature {
+			alerts = append(alerts, dto.WeatherAlert{
+				Event:       dto.AlertFrost,
+				Severity:    severityFor(day.TemperatureMin <= severeFrostTemperature),
+				Start:       start,
+				End:         end,
+				Description: fmt.Sprintf("Мороз до %.0f°C", day.TemperatureMin),
+			})
+		}
+	}
+
+	return alerts
+}
+
+func severityFor(severe bool) string {
+	if severe {
+		return dto.AlertSeveritySevere
+	}
+	return dto.AlertSeverityModerate
+}
@@ -0,0 +1,218 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+)
+
+var (
+	ErrInvalidAPIKey = fmt.Errorf("%w: invalid or revoked api key", models.ErrUnauthorized)
+	ErrMissingScope  = fmt.Errorf("%w: api key lacks required scope", models.ErrForbidden)
+	ErrQuotaExceeded = fmt.Errorf("%w: daily api key quota exceeded", models.ErrRateLimited)
+)
+
+const (
+	// apiKeyPrefix отличает ключи сервиса в логах и сканерах секретов
+	apiKeyPrefix = "wk_"
+	// defaultAPIKeyCacheTTL сколько проверенный ключ хранится в памяти по умолчанию
+	defaultAPIKeyCacheTTL = time.Minute
+	// maxAPIKeyOwnerLength ограничение колонки owner
+	maxAPIKeyOwnerLength = 100
+)
+
+type APIKeyUseCaseOptions struct {
+	APIKeyRepository repository.APIKeyRepository
+	UsageCounter     repository.APIKeyUsageCounter
+	// CacheTTL сколько ключ хранится в памяти после чтения из базы, по умолчанию минута.
+	// Отозванный на другом экземпляре ключ продолжает работать здесь не дольше этого времени.
+	CacheTTL time.Duration
+}
+
+// APIKeyUseCase выпуск, отзыв и проверка ключей доступа к HTTP API
+type APIKeyUseCase struct {
+	options APIKeyUseCaseOptions
+
+	mu    sync.Mutex
+	cache map[string]cachedAPIKey
+}
+
+type cachedAPIKey struct {
+	key       *models.APIKey
+	expiresAt time.Time
+}
+
+func NewAPIKeyUseCase(options APIKeyUseCaseOptions) *APIKeyUseCase {
+	if options.APIKeyRepository == nil {
+		panic("api key repository must not be nil")
+	}
+	if options.UsageCounter == nil {
+		panic("api key usage counter must not be nil")
+	}
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = defaultAPIKeyCacheTTL
+	}
+	return &APIKeyUseCase{
+		options: options,
+		cache:   make(map[string]cachedAPIKey),
+	}
+}
+
+// Issue выпускает ключ. Ключ целиком возвращается только здесь, в базе остается его хэш.
+func (usecase *APIKeyUseCase) Issue(ctx context.Context, request dto.IssueAPIKeyRequest) (*models.APIKey, string, error) {
+	owner := strings.TrimSpace(request.Owner)
+	if owner == "" || len(owner) > maxAPIKeyOwnerLength {
+		return nil, "", fmt.Errorf("%w: owner must be 1-%d characters", models.ErrInvalidInput, maxAPIKeyOwnerLength)
+	}
+	if len(request.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: at least one scope is required", models.ErrInvalidInput)
+	}
+	for _, scope := range request.Scopes {
+		if !slices.Contains(models.Scopes, scope) {
+			return nil, "", fmt.Errorf("%w: unknown scope %q", models.ErrInvalidInput, scope)
+		}
+	}
+	if request.DailyQuota < 0 {
+		return nil, "", fmt.Errorf("%w: daily quota must not be negative", models.ErrInvalidInput)
+	}
+
+	id, err := randomString(8, hex.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomString(24, base64.RawURLEncoding.EncodeToString)
+	if err != nil {
+		return nil, "", err
+	}
+
+	key := models.APIKey{
+		ID:         id,
+		KeyHash:    hashSecret(secret),
+		Owner:      owner,
+		Scopes:     request.Scopes,
+		DailyQuota: request.DailyQuota,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if err := usecase.options.APIKeyRepository.CreateAPIKey(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("api key repository failed: %w", err)
+	}
+
+	return &key, apiKeyPrefix + id + "_" + secret, nil
+}
+
+// List возвращает все ключи, включая отозванные
+func (usecase *APIKeyUseCase) List(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := usecase.options.APIKeyRepository.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("api key repository failed: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke отзывает ключ
+func (usecase *APIKeyUseCase) Revoke(ctx context.Context, id string) error {
+	if err := usecase.options.APIKeyRepository.RevokeAPIKey(ctx, id); err != nil {
+		return fmt.Errorf("api key repository failed: %w", err)
+	}
+
+	usecase.mu.Lock()
+	delete(usecase.cache, id)
+	usecase.mu.Unlock()
+	return nil
+}
+
+// Authenticate находит действующий ключ по строке из запроса
+func (usecase *APIKeyUseCase) Authenticate(ctx context.Context, raw string) (*models.APIKey, error) {
+	// Формат wk_<id>_<secret>: id в hex, поэтому первое "_" после префикса отделяет секрет
+	id, secret, ok := strings.Cut(strings.TrimPrefix(raw, apiKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(raw, apiKeyPrefix) || id == "" || secret == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := usecase.getAPIKey(ctx, id)
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.KeyHash)) != 1 || key.RevokedAt != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// getAPIKey читает ключ из памяти или из базы. Ненайденные ключи не кэшируются,
+// чтобы перебор идентификаторов не занимал память.
+func (usecase *APIKeyUseCase) getAPIKey(ctx context.Context, id string) (*models.APIKey, error) {
+	usecase.mu.Lock()
+	cached, ok := usecase.cache[id]
+	usecase.mu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.key, nil
+	}
+
+	key, err := usecase.options.APIKeyRepository.GetAPIKey(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("api key repository failed: %w", err)
+	}
+
+	usecase.mu.Lock()
+	usecase.cache[id] = cachedAPIKey{key: key, expiresAt: time.Now().Add(usecase.options.CacheTTL)}
+	usecase.mu.Unlock()
+	return key, nil
+}
+
+// Authorize проверяет право и учитывает запрос в суточной квоте ключа.
+// Использование возвращается и при ErrQuotaExceeded, чтобы клиенту можно было
+// сообщить, когда квота обновится. Если счетчик недоступен, запрос пропускается.
+func (usecase *APIKeyUseCase) Authorize(ctx context.Context, key *models.APIKey, scope string) (*dto.APIKeyUsage, error) {
+	if !key.HasScope(scope) {
+		return nil, ErrMissingScope
+	}
+
+	now := time.Now().UTC()
+	usage := &dto.APIKeyUsage{
+		Limit:   key.DailyQuota,
+		ResetAt: time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC),
+	}
+
+	used, err := usecase.options.UsageCounter.IncrementUsage(ctx, key.ID, now)
+	if err != nil {
+		slog.WarnContext(ctx, "api key usage counter failed", "key_id", key.ID, "error", err)
+		return usage, nil
+	}
+	usage.Used = used
+
+	if key.DailyQuota > 0 && used > int64(key.DailyQuota) {
+		return usage, ErrQuotaExceeded
+	}
+	return usage, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomString(size int, encode func([]byte) string) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("random read failed: %w", err)
+	}
+	return encode(buf), nil
+}
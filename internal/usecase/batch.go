@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"weather-api/internal/dto"
+	"weather-api/internal/models"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// MaxBatchItems ограничивает размер пакетного запроса погоды
+	MaxBatchItems = 50
+	// defaultBatchWorkers число параллельных обработчиков пакета по умолчанию
+	defaultBatchWorkers = 8
+)
+
+var (
+	ErrEmptyBatch       = fmt.Errorf("%w: batch must contain at least one item", models.ErrInvalidInput)
+	ErrBatchTooLarge    = fmt.Errorf("%w: batch must contain no more than %d items", models.ErrInvalidInput, MaxBatchItems)
+	ErrInvalidBatchItem = fmt.Errorf("%w: item must contain either city or both lat and lon", models.ErrInvalidInput)
+)
+
+// GetWeatherBatch получает погоду для нескольких точек. Ошибка одной точки не
+// прерывает пакет и возвращается в ее результате. Сначала погода читается из
+// кэша одним запросом, затем недостающие точки запрашиваются параллельно.
+func (usecase *WeatherUseCase) GetWeatherBatch(ctx context.Context, items []dto.WeatherBatchItem) (_ []dto.WeatherBatchResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetWeatherBatch", trace.WithAttributes(
+		attribute.Int("batch.size", len(items)),
+	))
+	defer func() { tracing.End(span, err) }()
+
+	if len(items) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	if len(items) > MaxBatchItems {
+		return nil, ErrBatchTooLarge
+	}
+
+	results := make([]dto.WeatherBatchResult, len(items))
+	locations := make([]dto.Location, len(items))
+
+	// 1. Определяем координаты, города ищем параллельно
+	usecase.forEach(len(items), func(i int) {
+		results[i].Item = items[i]
+		location, err := usecase.batchLocation(ctx, items[i])
+		if err != nil {
+			results[i].Err = err
+			return
+		}
+		locations[i] = *location
+	})
+
+	// 2. Читаем из кэша все точки одним запросом
+	pending := make([]int, 0, len(items))
+	for i := range results {
+		if results[i].Err == nil {
+			pending = append(pending, i)
+		}
+	}
+	pending = usecase.fillFromCache(ctx, results, locations, pending)
+
+	// 3. Остальное запрашиваем через репозиторий, он же сохранит ответы в кэш
+	usecase.forEach(len(pending), func(n int) {
+		i := pending[n]
+		results[i].Report, results[i].Err = usecase.weatherReport(ctx, locations[i])
+	})
+
+	return results, nil
+}
+
+// batchLocation проверяет точку пакета и определяет ее координаты
+func (usecase *WeatherUseCase) batchLocation(ctx context.Context, item dto.WeatherBatchItem) (*dto.Location, error) {
+	hasCoordinates := item.Lat != nil && item.Lon != nil
+	if (item.City == "") == !hasCoordinates || (item.Lat == nil) != (item.Lon == nil) {
+		return nil, ErrInvalidBatchItem
+	}
+
+	if hasCoordinates {
+		if !validCoordinates(*item.Lat, *item.Lon) {
+			return nil, ErrInvalidCoordinates
+		}
+		return &dto.Location{Latitude: *item.Lat, Longitude: *item.Lon}, nil
+	}
+
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, item.City)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	return &dto.Location{
+		Name:      city.Name,
+		Country:   city.Country,
+		Latitude:  city.Latitude,
+		Longitude: city.Longitude,
+		Timezone:  city.Timezone,
+	}, nil
+}
+
+// fillFromCache заполняет результаты из кэша и возвращает индексы, которых в кэше нет
+func (usecase *WeatherUseCase) fillFromCache(ctx context.Context, results []dto.WeatherBatchResult, locations []dto.Location, pending []int) []int {
+	if usecase.options.WeatherBatchCache == nil || len(pending) == 0 {
+		return pending
+	}
+
+	params := make([]models.WeatherTodayParams, len(pending))
+	for n, i := range pending {
+		params[n] = models.WeatherTodayParams{Lat: locations[i].Latitude, Lon: locations[i].Longitude}
+	}
+	cached, err := usecase.options.WeatherBatchCache.CachedWeatherToday(ctx, params)
+	if err != nil {
+		// Кэш только ускоряет пакет, без него точки запросятся по одной
+		slog.WarnContext(ctx, "weather batch cache failed", "error", err)
+		return pending
+	}
+
+	missing := pending[:0]
+	for n, i := range pending {
+		if cached[n] == nil {
+			missing = append(missing, i)
+			continue
+		}
+		results[i].Report = toWeatherReport(locations[i], cached[n])
+	}
+	return missing
+}
+
+// forEach вызывает fn для индексов [0, n) не более чем в BatchWorkers горутинах
+func (usecase *WeatherUseCase) forEach(n int, fn func(i int)) {
+	workers := usecase.options.BatchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
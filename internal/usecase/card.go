@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+	"weather-api/internal/weather_card"
+)
+
+// cardHours сколько ближайших часов показывать на графике карточки
+const cardHours = 24
+
+type CardUseCaseOptions struct {
+	WeatherRepository repository.WeatherRepository
+	CityRepository    repository.CityRepository
+	CardCache         repository.CardCache
+}
+
+// CardUseCase отрисовывает карточки погоды в PNG
+type CardUseCase struct {
+	options CardUseCaseOptions
+}
+
+func NewCardUseCase(options CardUseCaseOptions) *CardUseCase {
+	if options.WeatherRepository == nil {
+		panic("weather repository must not be nil")
+	}
+	if options.CityRepository == nil {
+		panic("city repository must not be nil")
+	}
+	if options.CardCache == nil {
+		panic("card cache must not be nil")
+	}
+	return &CardUseCase{options: options}
+}
+
+// GetCityCard возвращает карточку погоды города
+func (usecase *CardUseCase) GetCityCard(ctx context.Context, cityName, language, units string) ([]byte, error) {
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	return usecase.GetLocationCard(ctx, city.Latitude, city.Longitude, city.Name, language, units)
+}
+
+// GetLocationCard возвращает карточку погоды по координатам с заголовком title.
+// Карточка кэшируется по содержимому записей кэша погоды, поэтому
+// обновляется вместе с ними.
+func (usecase *CardUseCase) GetLocationCard(ctx context.Context, lat, lon float64, title, language, units string) ([]byte, error) {
+	if !validCoordinates(lat, lon) {
+		return nil, ErrInvalidCoordinates
+	}
+
+	weather, err := usecase.options.WeatherRepository.WeatherToday(ctx, models.WeatherTodayParams{Lat: lat, Lon: lon})
+	if err != nil {
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
+		return nil, fmt.Errorf("weather repository failed: %w", err)
+	}
+	forecast, err := usecase.options.WeatherRepository.WeatherForecast(ctx, models.ForecastParams{Lat: lat, Lon: lon, Days: 2})
+	if err != nil {
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
+		return nil, fmt.Errorf("weather repository failed: %w", err)
+	}
+
+	// Время на карточке округляем до часа, чтобы карточка жила в кэше
+	location := time.FixedZone(forecast.Timezone, forecast.UTCOffsetSeconds)
+	localTime := time.Now().In(location).Truncate(time.Hour)
+
+	cacheKey, err := cardKey(weather, forecast, localTime, title, language, units)
+	if err != nil {
+		return nil, err
+	}
+	if card, err := usecase.options.CardCache.Get(ctx, cacheKey); err == nil {
+		return card, nil
+	}
+
+	card, err := weather_card.Render(buildCard(weather, forecast, localTime, title, language, units))
+	if err != nil {
+		return nil, fmt.Errorf("render card: %w", err)
+	}
+	if err := usecase.options.CardCache.Set(ctx, cacheKey, card); err != nil {
+		slog.WarnContext(ctx, "failed to cache weather card", "error", err)
+	}
+
+	return card, nil
+}
+
+// cardKey строит ключ кэша карточки из данных погоды и параметров отрисовки
+func cardKey(weather *models.WeatherResult, forecast *models.ForecastResult, localTime time.Time, title, language, units string) (string, error) {
+	payload, err := json.Marshal(struct {
+		Weather   *models.WeatherResult  `json:"weather"`
+		Forecast  *models.ForecastResult `json:"forecast"`
+		LocalTime time.Time              `json:"local_time"`
+		Title     string                 `json:"title"`
+		Language  string                 `json:"language"`
+		Units     string                 `json:"units"`
+	}{weather, forecast, localTime, title, language, units})
+	if err != nil {
+		return "", fmt.Errorf("json.Marshal(...): %w", err)
+	}
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func buildCard(weather *models.WeatherResult, forecast *models.ForecastResult, localTime time.Time, title, language, units string) weather_card.Card {
+	card := weather_card.Card{
+		Title:       title,
+		LocalTime:   localTime,
+		Temperature: models.FormatTemperature(weather.CurrentWeather.Temperature, units),
+		Description: models.GetWeatherDescriptionLang(weather.CurrentWeather.WeatherCode, language),
+		WeatherCode: weather.CurrentWeather.WeatherCode,
+	}
+
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, hour := range forecast.Hourly {
+		if hour.Time.Before(localTime) {
+			continue
+		}
+		card.Hourly = append(card.Hourly, hour.Temperature)
+		minValue = math.Min(minValue, hour.Temperature)
+		maxValue = math.Max(maxValue, hour.Temperature)
+		if len(card.Hourly) == cardHours {
+			break
+		}
+	}
+	if len(card.Hourly) > 0 {
+		card.HourlyMin = models.FormatTemperature(minValue, units)
+		card.HourlyMax = models.FormatTemperature(maxValue, units)
+	}
+
+	return card
+}
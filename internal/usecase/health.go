@@ -0,0 +1,195 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+	"weather-api/internal/dto"
+)
+
+const (
+	// defaultHealthCacheTTL сколько по умолчанию хранится результат проверки
+	defaultHealthCacheTTL = 5 * time.Second
+	// defaultHealthTimeout сколько по умолчанию ждать одну проверку
+	defaultHealthTimeout = 2 * time.Second
+)
+
+// HealthChecker проверяет одну зависимость
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// HealthCheckFunc позволяет использовать функцию как HealthChecker
+type HealthCheckFunc func(ctx context.Context) error
+
+func (f HealthCheckFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+// HealthCheck проверка в реестре
+type HealthCheck struct {
+	Name    string
+	Checker HealthChecker
+	// Critical сбой проверки делает сервис неготовым. Сбой некритичной проверки
+	// только помечает сервис degraded: например, без провайдера погоды сервис
+	// еще отдает данные из кэша, и выводить его из балансировки бесполезно.
+	Critical bool
+	// CacheTTL сколько хранить результат, по умолчанию HealthUseCaseOptions.CacheTTL
+	CacheTTL time.Duration
+}
+
+type HealthUseCaseOptions struct {
+	// CacheTTL сколько хранить результат проверки, чтобы частые запросы
+	// балансировщика не нагружали зависимости, по умолчанию 5 секунд
+	CacheTTL time.Duration
+	// Timeout сколько ждать одну проверку, по умолчанию 2 секунды
+	Timeout time.Duration
+	// Stopping закрывается в начале остановки приложения, после этого сервис не готов
+	Stopping <-chan struct{}
+}
+
+// HealthUseCase реестр проверок зависимостей для /livez и /readyz
+type HealthUseCase struct {
+	options HealthUseCaseOptions
+
+	mu     sync.RWMutex
+	checks []*healthEntry
+}
+
+type healthEntry struct {
+	check HealthCheck
+
+	// mu держит тот, кто выполняет проверку, остальные ждут его результат
+	mu     sync.Mutex
+	result dto.HealthCheckResult
+	failed bool
+}
+
+func NewHealthUseCase(options HealthUseCaseOptions) *HealthUseCase {
+	if options.CacheTTL <= 0 {
+		options.CacheTTL = defaultHealthCacheTTL
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultHealthTimeout
+	}
+	return &HealthUseCase{options: options}
+}
+
+// Register добавляет проверку в реестр
+func (usecase *HealthUseCase) Register(check HealthCheck) {
+	if check.Name == "" {
+		panic("health check name must not be empty")
+	}
+	if check.Checker == nil {
+		panic("health checker must not be nil")
+	}
+	if check.CacheTTL <= 0 {
+		check.CacheTTL = usecase.options.CacheTTL
+	}
+
+	usecase.mu.Lock()
+	defer usecase.mu.Unlock()
+	for _, entry := range usecase.checks {
+		if entry.check.Name == check.Name {
+			panic(fmt.Sprintf("health check %q is already registered", check.Name))
+		}
+	}
+	usecase.checks = append(usecase.checks, &healthEntry{check: check})
+}
+
+// Liveness сообщает, что процесс жив. Зависимости не проверяются: их сбой
+// не лечится перезапуском процесса.
+func (usecase *HealthUseCase) Liveness() *dto.HealthReport {
+	return &dto.HealthReport{Status: dto.HealthStatusOK}
+}
+
+// Readiness выполняет проверки параллельно, используя свежие результаты из кэша
+func (usecase *HealthUseCase) Readiness(ctx context.Context) *dto.HealthReport {
+	usecase.mu.RLock()
+	checks := usecase.checks
+	usecase.mu.RUnlock()
+
+	results := make([]dto.HealthCheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, entry := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = usecase.run(ctx, entry)
+		}()
+	}
+	wg.Wait()
+
+	report := &dto.HealthReport{
+		Status: dto.HealthStatusOK,
+		Checks: make(map[string]dto.HealthCheckResult, len(checks)),
+	}
+	for i, entry := range checks {
+		result := results[i]
+		report.Checks[entry.check.Name] = result
+		switch {
+		case result.Status == dto.HealthStatusOK:
+		case result.Critical:
+			report.Status = dto.HealthStatusFailing
+		case report.Status == dto.HealthStatusOK:
+			report.Status = dto.HealthStatusDegraded
+		}
+	}
+
+	if usecase.stopping() {
+		report.Status = dto.HealthStatusFailing
+		report.ShuttingDown = true
+	}
+	return report
+}
+
+func (usecase *HealthUseCase) stopping() bool {
+	if usecase.options.Stopping == nil {
+		return false
+	}
+	select {
+	case <-usecase.options.Stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// run возвращает результат проверки из кэша или выполняет ее
+func (usecase *HealthUseCase) run(ctx context.Context, entry *healthEntry) dto.HealthCheckResult {
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if !entry.result.CheckedAt.IsZero() && time.Since(entry.result.CheckedAt) < entry.check.CacheTTL {
+		return entry.result
+	}
+
+	// Отключение клиента не должно прерывать проверку, результат нужен и другим
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), usecase.options.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := entry.check.Checker.Check(ctx)
+	entry.result = dto.HealthCheckResult{
+		Status:    dto.HealthStatusOK,
+		Critical:  entry.check.Critical,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt: start,
+	}
+	if err != nil {
+		entry.result.Status = dto.HealthStatusFailing
+	}
+
+	// В лог пишем только смену состояния, чтобы частые проверки его не засоряли
+	if failed := err != nil; failed != entry.failed {
+		entry.failed = failed
+		if failed {
+			slog.Warn("health check failed", "check", entry.check.Name, "critical", entry.check.Critical, "error", err)
+		} else {
+			slog.Info("health check recovered", "check", entry.check.Name)
+		}
+	}
+	return entry.result
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+)
+
+// maxFavouriteCities ограничивает избранное, чтобы главное меню оставалось компактным
+const maxFavouriteCities = 8
+
+var (
+	ErrInvalidLanguage   = fmt.Errorf("%w: unsupported language", models.ErrInvalidInput)
+	ErrInvalidUnits      = fmt.Errorf("%w: unsupported units", models.ErrInvalidInput)
+	ErrTooManyFavourites = fmt.Errorf("%w: no more than %d favourite cities allowed", models.ErrInvalidInput, maxFavouriteCities)
+)
+
+type PreferencesUseCaseOptions struct {
+	PreferencesRepository repository.PreferencesRepository
+	CityRepository        repository.CityRepository
+}
+
+type PreferencesUseCase struct {
+	options PreferencesUseCaseOptions
+}
+
+func NewPreferencesUseCase(options PreferencesUseCaseOptions) *PreferencesUseCase {
+	if options.PreferencesRepository == nil {
+		panic("preferences repository must not be nil")
+	}
+	if options.CityRepository == nil {
+		panic("city repository must not be nil")
+	}
+	return &PreferencesUseCase{options: options}
+}
+
+func (usecase *PreferencesUseCase) GetPreferences(ctx context.Context, chatID int64) (*models.ChatPreferences, error) {
+	preferences, err := usecase.options.PreferencesRepository.Get(ctx, chatID)
+	if err != nil {
+		return nil, fmt.Errorf("preferences repository failed: %w", err)
+	}
+	return preferences, nil
+}
+
+func (usecase *PreferencesUseCase) SetLanguage(ctx context.Context, chatID int64, language string) (*models.ChatPreferences, error) {
+	if language != models.LanguageRU && language != models.LanguageEN {
+		return nil, ErrInvalidLanguage
+	}
+	return usecase.update(ctx, chatID, func(preferences *models.ChatPreferences) error {
+		preferences.Language = language
+		return nil
+	})
+}
+
+func (usecase *PreferencesUseCase) SetUnits(ctx context.Context, chatID int64, units string) (*models.ChatPreferences, error) {
+	if units != models.UnitsCelsius && units != models.UnitsFahrenheit {
+		return nil, ErrInvalidUnits
+	}
+	return usecase.update(ctx, chatID, func(preferences *models.ChatPreferences) error {
+		preferences.Units = units
+		return nil
+	})
+}
+
+// SetHomeCity задает домашний город чата, пустое имя сбрасывает его
+func (usecase *PreferencesUseCase) SetHomeCity(ctx context.Context, chatID int64, cityName string) (*models.ChatPreferences, error) {
+	if cityName != "" {
+		city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+		if err != nil {
+			return nil, fmt.Errorf("city repository failed: %w", err)
+		}
+		cityName = city.Name
+	}
+	return usecase.update(ctx, chatID, func(preferences *models.ChatPreferences) error {
+		preferences.HomeCity = cityName
+		return nil
+	})
+}
+
+// ToggleFavourite добавляет город в избранное или убирает его оттуда
+func (usecase *PreferencesUseCase) ToggleFavourite(ctx context.Context, chatID int64, cityName string) (*models.ChatPreferences, error) {
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	return usecase.update(ctx, chatID, func(preferences *models.ChatPreferences) error {
+		if i := slices.Index(preferences.FavouriteCities, city.Name); i >= 0 {
+			preferences.FavouriteCities = slices.Delete(preferences.FavouriteCities, i, i+1)
+			return nil
+		}
+		if len(preferences.FavouriteCities) >= maxFavouriteCities {
+			return ErrTooManyFavourites
+		}
+		preferences.FavouriteCities = append(preferences.FavouriteCities, city.Name)
+		return nil
+	})
+}
+
+// update загружает настройки, применяет изменение и сохраняет результат
+func (usecase *PreferencesUseCase) update(ctx context.Context, chatID int64, apply func(*models.ChatPreferences) error) (*models.ChatPreferences, error) {
+	preferences, err := usecase.GetPreferences(ctx, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if err := apply(preferences); err != nil {
+		return nil, err
+	}
+	if err := usecase.options.PreferencesRepository.Save(ctx, *preferences); err != nil {
+		return nil, fmt.Errorf("preferences repository failed: %w", err)
+	}
+	return preferences, nil
+}
@@ -0,0 +1,164 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+	"weather-api/internal/models"
+	"weather-api/internal/repository"
+)
+
+var (
+	ErrInvalidSendTime = fmt.Errorf("%w: send time must be in HH:MM format", models.ErrInvalidInput)
+	// ErrDigestAttemptsExhausted попытки доставить сегодняшнюю сводку исчерпаны
+	ErrDigestAttemptsExhausted = errors.New("digest delivery attempts exhausted")
+)
+
+const (
+	// defaultDigestMaxAttempts сколько раз за день пытаться доставить сводку
+	defaultDigestMaxAttempts = 5
+	// defaultDigestRetryBackoff пауза перед первым повтором, дальше она удваивается
+	defaultDigestRetryBackoff = time.Minute
+)
+
+type SubscriptionUseCaseOptions struct {
+	SubscriptionRepository repository.SubscriptionRepository
+	CityRepository         repository.CityRepository
+	// MaxAttempts сколько раз за день пытаться доставить сводку, по умолчанию 5
+	MaxAttempts int
+	// RetryBackoff пауза перед первым повтором, дальше она удваивается, по умолчанию минута
+	RetryBackoff time.Duration
+}
+
+type SubscriptionUseCase struct {
+	options SubscriptionUseCaseOptions
+	// now текущее время, в тестах подменяется
+	now func() time.Time
+}
+
+func NewSubscriptionUseCase(options SubscriptionUseCaseOptions) *SubscriptionUseCase {
+	if options.SubscriptionRepository == nil {
+		panic("subscription repository must not be nil")
+	}
+	if options.CityRepository == nil {
+		panic("city repository must not be nil")
+	}
+	if options.MaxAttempts <= 0 {
+		options.MaxAttempts = defaultDigestMaxAttempts
+	}
+	if options.RetryBackoff <= 0 {
+		options.RetryBackoff = defaultDigestRetryBackoff
+	}
+	return &SubscriptionUseCase{options: options, now: time.Now}
+}
+
+// Subscribe подписывает чат на ежедневную сводку по городу в локальное время sendTime (HH:MM)
+func (usecase *SubscriptionUseCase) Subscribe(ctx context.Context, chatID int64, cityName, sendTime string) (*models.Subscription, error) {
+	clock, err := time.Parse("15:04", sendTime)
+	if err != nil {
+		return nil, ErrInvalidSendTime
+	}
+
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+
+	location, err := time.LoadLocation(city.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("time.LoadLocation(%q): %w", city.Timezone, err)
+	}
+
+	subscription := models.Subscription{
+		ChatID:   chatID,
+		CityName: city.Name,
+		SendTime: clock.Format("15:04"),
+		Timezone: city.Timezone,
+	}
+
+	// Если время отправки сегодня уже прошло, первая сводка придет завтра
+	now := usecase.now().In(location)
+	if now.Format("15:04") >= subscription.SendTime {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		subscription.LastSentOn = &today
+	}
+
+	if err := usecase.options.SubscriptionRepository.Upsert(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("subscription repository failed: %w", err)
+	}
+
+	return &subscription, nil
+}
+
+func (usecase *SubscriptionUseCase) Unsubscribe(ctx context.Context, chatID int64) error {
+	if err := usecase.options.SubscriptionRepository.Delete(ctx, chatID); err != nil {
+		return fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue возвращает подписки, сводку по которым нужно отправить сейчас.
+// Возвращенные подписки уже помечены отправленными.
+func (usecase *SubscriptionUseCase) ClaimDue(ctx context.Context) ([]models.Subscription, error) {
+	subscriptions, err := usecase.options.SubscriptionRepository.ClaimDue(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// Release возвращает подписку в очередь, если сводку не удалось доставить. Каждый
+// следующий повтор откладывается вдвое дольше. Когда попытки исчерпаны, сегодняшняя
+// сводка пропускается и возвращается ErrDigestAttemptsExhausted.
+func (usecase *SubscriptionUseCase) Release(ctx context.Context, subscription models.Subscription) error {
+	if subscription.LastSentOn == nil {
+		return nil
+	}
+
+	if subscription.FailedAttempts+1 >= usecase.options.MaxAttempts {
+		if err := usecase.options.SubscriptionRepository.ResetAttempts(ctx, subscription.ChatID); err != nil {
+			return fmt.Errorf("subscription repository failed: %w", err)
+		}
+		return ErrDigestAttemptsExhausted
+	}
+
+	retryAt := usecase.now().Add(usecase.options.RetryBackoff << subscription.FailedAttempts)
+	err := usecase.options.SubscriptionRepository.Release(ctx, subscription.ChatID, *subscription.LastSentOn, retryAt)
+	if err != nil {
+		return fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return nil
+}
+
+// Unclaim возвращает подписку в очередь без учета попытки, например если
+// сводку не успели отправить до остановки сервиса
+func (usecase *SubscriptionUseCase) Unclaim(ctx context.Context, subscription models.Subscription) error {
+	if subscription.LastSentOn == nil {
+		return nil
+	}
+	err := usecase.options.SubscriptionRepository.Unclaim(ctx, subscription.ChatID, *subscription.LastSentOn)
+	if err != nil {
+		return fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return nil
+}
+
+// Delivered отмечает успешную доставку: счетчик неудачных попыток начинается заново
+func (usecase *SubscriptionUseCase) Delivered(ctx context.Context, subscription models.Subscription) error {
+	if subscription.FailedAttempts == 0 {
+		return nil
+	}
+	if err := usecase.options.SubscriptionRepository.ResetAttempts(ctx, subscription.ChatID); err != nil {
+		return fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return nil
+}
+
+// Disable отключает подписку чата, который больше не принимает сообщения бота
+func (usecase *SubscriptionUseCase) Disable(ctx context.Context, chatID int64) error {
+	if err := usecase.options.SubscriptionRepository.Disable(ctx, chatID); err != nil {
+		return fmt.Errorf("subscription repository failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+	"weather-api/internal/models"
+)
+
+// fakeSubscriptionRepository запоминает вызовы репозитория подписок
+type fakeSubscriptionRepository struct {
+	upserted []models.Subscription
+	released []releaseCall
+	unclaims []time.Time
+	resets   []int64
+	disabled []int64
+}
+
+type releaseCall struct {
+	chatID  int64
+	sentOn  time.Time
+	retryAt time.Time
+}
+
+func (f *fakeSubscriptionRepository) Upsert(ctx context.Context, subscription models.Subscription) error {
+	f.upserted = append(f.upserted, subscription)
+	return nil
+}
+
+func (f *fakeSubscriptionRepository) Delete(ctx context.Context, chatID int64) error {
+	return nil
+}
+
+func (f *fakeSubscriptionRepository) ClaimDue(ctx context.Context) ([]models.Subscription, error) {
+	return nil, nil
+}
+
+func (f *fakeSubscriptionRepository) Release(ctx context.Context, chatID int64, sentOn time.Time, retryAt time.Time) error {
+	f.released = append(f.released, releaseCall{chatID: chatID, sentOn: sentOn, retryAt: retryAt})
+	return nil
+}
+
+func (f *fakeSubscriptionRepository) Unclaim(ctx context.Context, chatID int64, sentOn time.Time) error {
+	f.unclaims = append(f.unclaims, sentOn)
+	return nil
+}
+
+func (f *fakeSubscriptionRepository) ResetAttempts(ctx context.Context, chatID int64) error {
+	f.resets = append(f.resets, chatID)
+	return nil
+}
+
+func (f *fakeSubscriptionRepository) Disable(ctx context.Context, chatID int64) error {
+	f.disabled = append(f.disabled, chatID)
+	return nil
+}
+
+// fakeCityRepository города с заданными часовыми поясами
+type fakeCityRepository struct {
+	cities map[string]models.City
+}
+
+func (f *fakeCityRepository) GetCityByName(ctx context.Context, name string) (*models.City, error) {
+	city, ok := f.cities[name]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return &city, nil
+}
+
+func (f *fakeCityRepository) GetAllCities(ctx context.Context) ([]models.City, error) {
+	return nil, nil
+}
+
+func (f *fakeCityRepository) CreateCity(ctx context.Context, city models.City) error {
+	return nil
+}
+
+func newTestSubscriptionUseCase(now time.Time) (*SubscriptionUseCase, *fakeSubscriptionRepository) {
+	subscriptions := &fakeSubscriptionRepository{}
+	usecase := NewSubscriptionUseCase(SubscriptionUseCaseOptions{
+		SubscriptionRepository: subscriptions,
+		CityRepository: &fakeCityRepository{cities: map[string]models.City{
+			"Tokyo":    {Name: "Tokyo", Timezone: "Asia/Tokyo"},
+			"New York": {Name: "New York", Timezone: "America/New_York"},
+		}},
+		MaxAttempts:  3,
+		RetryBackoff: time.Minute,
+	})
+	usecase.now = func() time.Time { return now }
+	return usecase, subscriptions
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &d
+}
+
+// TestSubscribeFirstDigest проверяет, что сегодняшняя сводка пропускается, если время
+// отправки в часовом поясе города уже прошло, и дата берется по местному календарю
+func TestSubscribeFirstDigest(t *testing.T) {
+	tests := []struct {
+		name     string
+		now      time.Time
+		city     string
+		sendTime string
+		want     *time.Time
+	}{
+		// 23:30 UTC 19 октября это 08:30 20 октября в Токио
+		{name: "passed on next local day", now: time.Date(2026, 10, 19, 23, 30, 0, 0, time.UTC), city: "Tokyo", sendTime: "08:00", want: date(2026, 10, 20)},
+		{name: "not yet in local time", now: time.Date(2026, 10, 19, 23, 30, 0, 0, time.UTC), city: "Tokyo", sendTime: "09:00", want: nil},
+		{name: "exactly send time", now: time.Date(2026, 10, 19, 23, 0, 0, 0, time.UTC), city: "Tokyo", sendTime: "08:00", want: date(2026, 10, 20)},
+		// 02:00 UTC 20 октября это 22:00 19 октября в Нью-Йорке
+		{name: "passed on previous local day", now: time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC), city: "New York", sendTime: "21:00", want: date(2026, 10, 19)},
+		{name: "due later on previous local day", now: time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC), city: "New York", sendTime: "23:00", want: nil},
+		{name: "short time is normalized", now: time.Date(2026, 10, 20, 2, 0, 0, 0, time.UTC), city: "New York", sendTime: "7:05", want: date(2026, 10, 19)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase, repo := newTestSubscriptionUseCase(tt.now)
+
+			subscription, err := usecase.Subscribe(context.Background(), 1, tt.city, tt.sendTime)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repo.upserted) != 1 {
+				t.Fatalf("upserted %d subscriptions, want 1", len(repo.upserted))
+			}
+			got := subscription.LastSentOn
+			if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+				t.Errorf("LastSentOn = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSubscribeInvalid проверяет ошибки подписки
+func TestSubscribeInvalid(t *testing.T) {
+	usecase, repo := newTestSubscriptionUseCase(time.Now())
+
+	if _, err := usecase.Subscribe(context.Background(), 1, "Tokyo", "25:00"); !errors.Is(err, ErrInvalidSendTime) {
+		t.Errorf("error = %v, want %v", err, ErrInvalidSendTime)
+	}
+	if _, err := usecase.Subscribe(context.Background(), 1, "Atlantis", "08:00"); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("error = %v, want %v", err, models.ErrNotFound)
+	}
+	if len(repo.upserted) != 0 {
+		t.Errorf("upserted %v after errors", repo.upserted)
+	}
+}
+
+// TestReleaseBackoff проверяет удвоение паузы между повторами и отказ от
+// сегодняшней сводки после последней попытки
+func TestReleaseBackoff(t *testing.T) {
+	now := time.Date(2026, 10, 19, 6, 0, 0, 0, time.UTC)
+	sentOn := date(2026, 10, 19)
+
+	tests := []struct {
+		name        string
+		failed      int
+		wantRetryIn time.Duration
+		wantErr     error
+	}{
+		{name: "first failure", failed: 0, wantRetryIn: time.Minute},
+		{name: "second failure", failed: 1, wantRetryIn: 2 * time.Minute},
+		{name: "attempts exhausted", failed: 2, wantErr: ErrDigestAttemptsExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usecase, repo := newTestSubscriptionUseCase(now)
+
+			err := usecase.Release(context.Background(), models.Subscription{ChatID: 7, LastSentOn: sentOn, FailedAttempts: tt.failed})
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.released) != 0 {
+					t.Errorf("released %v after last attempt", repo.released)
+				}
+				if len(repo.resets) != 1 || repo.resets[0] != 7 {
+					t.Errorf("resets = %v, want [7]", repo.resets)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(repo.released) != 1 {
+				t.Fatalf("released %d times, want 1", len(repo.released))
+			}
+			got := repo.released[0]
+			// Дата снимается ровно та, что поставил ClaimDue по часовому поясу города
+			if got.chatID != 7 || !got.sentOn.Equal(*sentOn) {
+				t.Errorf("released chat %d on %v, want chat 7 on %v", got.chatID, got.sentOn, *sentOn)
+			}
+			if want := now.Add(tt.wantRetryIn); !got.retryAt.Equal(want) {
+				t.Errorf("retryAt = %v, want %v", got.retryAt, want)
+			}
+		})
+	}
+}
+
+// TestDeliveryStateTransitions проверяет остальные переходы состояния доставки
+func TestDeliveryStateTransitions(t *testing.T) {
+	ctx := context.Background()
+	sentOn := date(2026, 10, 19)
+
+	t.Run("unclaim keeps attempts", func(t *testing.T) {
+		usecase, repo := newTestSubscriptionUseCase(time.Now())
+		if err := usecase.Unclaim(ctx, models.Subscription{ChatID: 7, LastSentOn: sentOn, FailedAttempts: 2}); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.unclaims) != 1 || !repo.unclaims[0].Equal(*sentOn) || len(repo.released) != 0 {
+			t.Errorf("unclaims = %v, released = %v", repo.unclaims, repo.released)
+		}
+	})
+
+	t.Run("not claimed subscription is ignored", func(t *testing.T) {
+		usecase, repo := newTestSubscriptionUseCase(time.Now())
+		if err := usecase.Release(ctx, models.Subscription{ChatID: 7}); err != nil {
+			t.Fatal(err)
+		}
+		if err := usecase.Unclaim(ctx, models.Subscription{ChatID: 7}); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.released) != 0 || len(repo.unclaims) != 0 {
+			t.Errorf("released = %v, unclaims = %v", repo.released, repo.unclaims)
+		}
+	})
+
+	t.Run("delivery after failures resets attempts", func(t *testing.T) {
+		usecase, repo := newTestSubscriptionUseCase(time.Now())
+		if err := usecase.Delivered(ctx, models.Subscription{ChatID: 7, FailedAttempts: 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := usecase.Delivered(ctx, models.Subscription{ChatID: 8}); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.resets) != 1 || repo.resets[0] != 7 {
+			t.Errorf("resets = %v, want [7]", repo.resets)
+		}
+	})
+
+	t.Run("unavailable chat is disabled", func(t *testing.T) {
+		usecase, repo := newTestSubscriptionUseCase(time.Now())
+		if err := usecase.Disable(ctx, 7); err != nil {
+			t.Fatal(err)
+		}
+		if len(repo.disabled) != 1 || repo.disabled[0] != 7 {
+			t.Errorf("disabled = %v, want [7]", repo.disabled)
+		}
+	})
+}
@@ -2,16 +2,43 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"math"
+	"time"
 	"weather-api/internal/dto"
 	"weather-api/internal/models"
 	"weather-api/internal/repository"
+	"weather-api/pkg/tracing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxForecastDays максимальная длина прогноза Open-Meteo в днях
+const maxForecastDays = 16
+
+var tracer = otel.Tracer("weather-api/internal/usecase")
+
+var (
+	ErrInvalidForecastDays = fmt.Errorf("%w: forecast days must be in [1, %d]", models.ErrInvalidInput, maxForecastDays)
 )
 
 type WeatherUseCaseOptions struct {
 	WeatherRepository repository.WeatherRepository
 	CityRepository    repository.CityRepository
+	// CityBatchRepository необязательный репозиторий для получения нескольких городов одним запросом
+	CityBatchRepository repository.CityBatchRepository
+	// WeatherBatchCache необязательный кэш для пакетного чтения погоды в GetWeatherBatch
+	WeatherBatchCache repository.WeatherBatchCache
+	// BatchWorkers сколько точек пакета обрабатывается параллельно, по умолчанию 8
+	BatchWorkers int
+	// WeatherCacheTTL и CityCacheTTL необязательны, по ним клиентам сообщается,
+	// сколько еще ответ можно хранить
+	WeatherCacheTTL repository.WeatherCacheTTL
+	CityCacheTTL    repository.CityCacheTTL
 }
 
 type WeatherUseCase struct {
@@ -28,14 +55,21 @@ func NewWeatherUseCase(options WeatherUseCaseOptions) *WeatherUseCase {
 	return &WeatherUseCase{options: options}
 }
 
-func (usecase *WeatherUseCase) GetWeatherToday(ctx context.Context, params dto.GetWeatherTodayParams) (*dto.WeatherResult, error) {
+func (usecase *WeatherUseCase) GetWeatherToday(ctx context.Context, params dto.GetWeatherTodayParams) (_ *dto.WeatherResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetWeatherToday")
+	defer func() { tracing.End(span, err) }()
+
+	if !validCoordinates(params.Lat, params.Lon) {
+		return nil, ErrInvalidCoordinates
+	}
+
 	// Запрашиваем погоду через репозиторий
 	result, err := usecase.options.WeatherRepository.WeatherToday(ctx, models.WeatherTodayParams{
 		Lat: params.Lat,
 		Lon: params.Lon,
 	})
 	if err != nil {
-		slog.Error("weather repository failed", "err", err)
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
 		return nil, fmt.Errorf("weather repository failed: %w", err)
 	}
 
@@ -51,7 +85,10 @@ func (usecase *WeatherUseCase) GetWeatherToday(ctx context.Context, params dto.G
 	return weatherResult, nil
 }
 
-func (usecase *WeatherUseCase) GetWeatherByCity(ctx context.Context, cityName string) (*dto.WeatherResult, error) {
+func (usecase *WeatherUseCase) GetWeatherByCity(ctx context.Context, cityName string) (_ *dto.WeatherResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetWeatherByCity", trace.WithAttributes(attribute.String("city", cityName)))
+	defer func() { tracing.End(span, err) }()
+
 	// Получаем город через репозиторий (с кэшированием)
 	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
 	if err != nil {
@@ -64,7 +101,7 @@ func (usecase *WeatherUseCase) GetWeatherByCity(ctx context.Context, cityName st
 		Lon: city.Longitude,
 	})
 	if err != nil {
-		slog.Error("weather repository failed", "err", err)
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
 		return nil, fmt.Errorf("weather repository failed: %w", err)
 	}
 
@@ -80,6 +117,252 @@ func (usecase *WeatherUseCase) GetWeatherByCity(ctx context.Context, cityName st
 	return weatherResult, nil
 }
 
-func (usecase *WeatherUseCase) GetAllCities(ctx context.Context) ([]models.City, error) {
+// GetWeatherReport возвращает текущую погоду по координатам вместе с временем наблюдения
+func (usecase *WeatherUseCase) GetWeatherReport(ctx context.Context, params dto.GetWeatherTodayParams) (_ *dto.WeatherReport, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetWeatherReport")
+	defer func() { tracing.End(span, err) }()
+
+	if !validCoordinates(params.Lat, params.Lon) {
+		return nil, ErrInvalidCoordinates
+	}
+
+	return usecase.weatherReport(ctx, dto.Location{Latitude: params.Lat, Longitude: params.Lon})
+}
+
+// GetWeatherReportByCity возвращает текущую погоду в городе вместе с данными о городе
+func (usecase *WeatherUseCase) GetWeatherReportByCity(ctx context.Context, cityName string) (_ *dto.WeatherReport, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetWeatherReportByCity", trace.WithAttributes(attribute.String("city", cityName)))
+	defer func() { tracing.End(span, err) }()
+
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+
+	return usecase.weatherReport(ctx, dto.Location{
+		Name:      city.Name,
+		Country:   city.Country,
+		Latitude:  city.Latitude,
+		Longitude: city.Longitude,
+		Timezone:  city.Timezone,
+	})
+}
+
+func (usecase *WeatherUseCase) weatherReport(ctx context.Context, location dto.Location) (*dto.WeatherReport, error) {
+	result, err := usecase.options.WeatherRepository.WeatherToday(ctx, models.WeatherTodayParams{
+		Lat: location.Latitude,
+		Lon: location.Longitude,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
+		return nil, fmt.Errorf("weather repository failed: %w", err)
+	}
+
+	report := toWeatherReport(location, result)
+	if usecase.options.WeatherCacheTTL != nil {
+		ttl, err := usecase.options.WeatherCacheTTL.WeatherTodayTTL(ctx, models.WeatherTodayParams{
+			Lat: location.Latitude,
+			Lon: location.Longitude,
+		})
+		if err != nil {
+			slog.WarnContext(ctx, "weather cache ttl failed", "error", err)
+		}
+		report.ExpiresIn = ttl
+	}
+
+	return report, nil
+}
+
+// toWeatherReport собирает отчет о погоде из ответа репозитория
+func toWeatherReport(location dto.Location, result *models.WeatherResult) *dto.WeatherReport {
+	current := result.CurrentWeather
+	report := &dto.WeatherReport{
+		Location:      location,
+		Temperature:   current.Temperature,
+		WindSpeed:     current.WindSpeed,
+		WindDirection: current.WindDirection,
+		WeatherCode:   current.WeatherCode,
+		WeatherDesc:   models.GetWeatherDescription(current.WeatherCode),
+	}
+	// В старых записях кэша времени наблюдения нет, тогда оставляем его пустым
+	if observedAt, err := time.ParseInLocation("2006-01-02T15:04", current.Time, time.UTC); err == nil {
+		report.ObservedAt = observedAt
+	}
+
+	return report
+}
+
+func (usecase *WeatherUseCase) GetAllCities(ctx context.Context) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetAllCities")
+	defer func() { tracing.End(span, err) }()
+
 	return usecase.options.CityRepository.GetAllCities(ctx)
 }
+
+// GetCitiesByNames возвращает найденные города из списка имен, отсутствующие пропускаются
+func (usecase *WeatherUseCase) GetCitiesByNames(ctx context.Context, names []string) (_ []models.City, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetCitiesByNames")
+	defer func() { tracing.End(span, err) }()
+
+	if usecase.options.CityBatchRepository != nil {
+		cities, err := usecase.options.CityBatchRepository.GetCitiesByNames(ctx, names)
+		if err != nil {
+			return nil, fmt.Errorf("city repository failed: %w", err)
+		}
+		return cities, nil
+	}
+
+	var cities []models.City
+	for _, name := range names {
+		city, err := usecase.options.CityRepository.GetCityByName(ctx, name)
+		if errors.Is(err, models.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("city repository failed: %w", err)
+		}
+		cities = append(cities, *city)
+	}
+	return cities, nil
+}
+
+// GetCityByID возвращает город по короткому идентификатору. Городов в справочнике
+// немного, поэтому поиск идет по закэшированному списку всех городов.
+func (usecase *WeatherUseCase) GetCityByID(ctx context.Context, id int64) (_ *models.City, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetCityByID")
+	defer func() { tracing.End(span, err) }()
+
+	cities, err := usecase.options.CityRepository.GetAllCities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	for _, city := range cities {
+		if city.ID == id {
+			return &city, nil
+		}
+	}
+	return nil, fmt.Errorf("city #%d: %w", id, models.ErrNotFound)
+}
+
+// CitiesExpiresIn возвращает, сколько еще действителен список городов, или 0, если неизвестно
+func (usecase *WeatherUseCase) CitiesExpiresIn(ctx context.Context) time.Duration {
+	if usecase.options.CityCacheTTL == nil {
+		return 0
+	}
+	ttl, err := usecase.options.CityCacheTTL.AllCitiesTTL(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "city cache ttl failed", "error", err)
+		return 0
+	}
+	return ttl
+}
+
+func (usecase *WeatherUseCase) GetForecast(ctx context.Context, params dto.GetForecastParams) (_ *dto.ForecastResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetForecast")
+	defer func() { tracing.End(span, err) }()
+
+	if !validCoordinates(params.Lat, params.Lon) {
+		return nil, ErrInvalidCoordinates
+	}
+	if params.Days < 1 || params.Days > maxForecastDays {
+		return nil, ErrInvalidForecastDays
+	}
+
+	result, err := usecase.options.WeatherRepository.WeatherForecast(ctx, models.ForecastParams{
+		Lat:  params.Lat,
+		Lon:  params.Lon,
+		Days: params.Days,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "weather repository failed", "err", err)
+		return nil, fmt.Errorf("weather repository failed: %w", err)
+	}
+
+	return toForecastResult(result), nil
+}
+
+func (usecase *WeatherUseCase) GetForecastByCity(ctx context.Context, cityName string, days int) (_ *dto.ForecastResult, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetForecastByCity", trace.WithAttributes(attribute.String("city", cityName)))
+	defer func() { tracing.End(span, err) }()
+
+	city, err := usecase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+
+	return usecase.GetForecast(ctx, dto.GetForecastParams{
+		Lat:  city.Latitude,
+		Lon:  city.Longitude,
+		Days: days,
+	})
+}
+
+// toForecastResult преобразует прогноз репозитория в DTO с описаниями погоды
+func toForecastResult(result *models.ForecastResult) *dto.ForecastResult {
+	forecast := &dto.ForecastResult{
+		Timezone: result.Timezone,
+		Hourly:   make([]dto.HourlyForecast, 0, len(result.Hourly)),
+		Daily:    make([]dto.DailyForecast, 0, len(result.Daily)),
+	}
+	for _, hour := range result.Hourly {
+		forecast.Hourly = append(forecast.Hourly, dto.HourlyForecast{
+			Time:        hour.Time,
+			Temperature: hour.Temperature,
+			WeatherCode: hour.WeatherCode,
+			WeatherDesc: models.GetWeatherDescription(hour.WeatherCode),
+		})
+	}
+	for _, day := range result.Daily {
+		forecast.Daily = append(forecast.Daily, dto.DailyForecast{
+			Date:           day.Date,
+			TemperatureMax: day.TemperatureMax,
+			TemperatureMin: day.TemperatureMin,
+			Precipitation:  day.Precipitation,
+			WeatherCode:    day.WeatherCode,
+			WeatherDesc:    models.GetWeatherDescription(day.WeatherCode),
+		})
+	}
+	return forecast
+}
+
+// GetNearestCity возвращает ближайший к координатам город из списка известных
+func (usecase *WeatherUseCase) GetNearestCity(ctx context.Context, lat, lon float64) (_ *models.City, err error) {
+	ctx, span := tracer.Start(ctx, "WeatherUseCase.GetNearestCity")
+	defer func() { tracing.End(span, err) }()
+
+	cities, err := usecase.options.CityRepository.GetAllCities(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	if len(cities) == 0 {
+		return nil, fmt.Errorf("no cities available: %w", models.ErrNotFound)
+	}
+
+	nearest := cities[0]
+	minDistance := distanceKm(lat, lon, nearest.Latitude, nearest.Longitude)
+	for _, city := range cities[1:] {
+		if d := distanceKm(lat, lon, city.Latitude, city.Longitude); d < minDistance {
+			nearest, minDistance = city, d
+		}
+	}
+
+	return &nearest, nil
+}
+
+// validCoordinates проверяет диапазоны широты и долготы
+func validCoordinates(lat, lon float64) bool {
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// distanceKm считает расстояние между точками по формуле гаверсинусов
+func distanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+	"weather-api/internal/dto"
+)
+
+const (
+	// defaultWatchInterval как часто наблюдатель перечитывает погоду по умолчанию
+	defaultWatchInterval = 30 * time.Second
+	// defaultWatchHistory сколько последних изменений хранится для продолжения потока
+	defaultWatchHistory = 16
+	// defaultWatchHistoryGrace сколько хранить историю точки после ухода последнего подписчика
+	defaultWatchHistoryGrace = time.Minute
+	// defaultAlertsInterval как часто пересчитывать предупреждения по умолчанию
+	defaultAlertsInterval = 15 * time.Minute
+)
+
+type WeatherWatcherOptions struct {
+	WeatherUseCase *WeatherUseCase
+	// Interval как часто перечитывать погоду, по умолчанию 30 секунд. Погода
+	// берется через кэш, поэтому к провайдеру запрос уходит только после истечения записи.
+	Interval time.Duration
+	// History сколько последних изменений хранить для Last-Event-ID, по умолчанию 16
+	History int
+	// HistoryGrace сколько хранить историю точки после ухода последнего подписчика,
+	// чтобы переподключившийся клиент продолжил поток по Last-Event-ID. По умолчанию минута.
+	HistoryGrace time.Duration
+	// AlertsInterval как часто пересчитывать предупреждения по прогнозу, по умолчанию 15 минут
+	AlertsInterval time.Duration
+}
+
+// WeatherWatcher следит за погодой в точках, на которые есть подписчики.
+// На точку работает одна горутина, сколько бы ни было подписчиков.
+type WeatherWatcher struct {
+	options WeatherWatcherOptions
+
+	mu      sync.Mutex
+	watches map[watchKey]*watch
+}
+
+type watchKey struct {
+	lat, lon float64
+}
+
+// watch наблюдение за одной точкой. Без подписчиков опрос остановлен (cancel равен nil),
+// а история хранится до срабатывания expire.
+type watch struct {
+	location    dto.Location
+	subscribers map[*WeatherSubscription]struct{}
+	history     []dto.WeatherUpdate
+	cancel      context.CancelFunc
+	expire      *time.Timer
+}
+
+// WeatherSubscription подписка на изменения погоды в точке
+type WeatherSubscription struct {
+	watcher *WeatherWatcher
+	key     watchKey
+	updates chan dto.WeatherUpdate
+	once    sync.Once
+}
+
+func NewWeatherWatcher(options WeatherWatcherOptions) *WeatherWatcher {
+	if options.WeatherUseCase == nil {
+		panic("weather usecase must not be nil")
+	}
+	if options.Interval <= 0 {
+		options.Interval = defaultWatchInterval
+	}
+	if options.History <= 0 {
+		options.History = defaultWatchHistory
+	}
+	if options.HistoryGrace <= 0 {
+		options.HistoryGrace = defaultWatchHistoryGrace
+	}
+	if options.AlertsInterval <= 0 {
+		options.AlertsInterval = defaultAlertsInterval
+	}
+	return &WeatherWatcher{
+		options: options,
+		watches: make(map[watchKey]*watch),
+	}
+}
+
+// SubscribeCity подписывает на погоду в городе из справочника
+func (w *WeatherWatcher) SubscribeCity(ctx context.Context, cityName string, lastEventID int64) (*WeatherSubscription, error) {
+	city, err := w.options.WeatherUseCase.options.CityRepository.GetCityByName(ctx, cityName)
+	if err != nil {
+		return nil, fmt.Errorf("city repository failed: %w", err)
+	}
+	return w.subscribe(dto.Location{
+		Name:      city.Name,
+		Country:   city.Country,
+		Latitude:  city.Latitude,
+		Longitude: city.Longitude,
+		Timezone:  city.Timezone,
+	}, lastEventID), nil
+}
+
+// Subscribe подписывает на погоду по координатам
+func (w *WeatherWatcher) Subscribe(lat, lon float64, lastEventID int64) (*WeatherSubscription, error) {
+	if !validCoordinates(lat, lon) {
+		return nil, ErrInvalidCoordinates
+	}
+	return w.subscribe(dto.Location{Latitude: lat, Longitude: lon}, lastEventID), nil
+}
+
+// subscribe добавляет подписчика и сразу отдает ему известные изменения:
+// после lastEventID, а без него только последнее
+func (w *WeatherWatcher) subscribe(location dto.Location, lastEventID int64) *WeatherSubscription {
+	key := watchKey{location.Latitude, location.Longitude}
+	subscription := &WeatherSubscription{
+		watcher: w,
+		key:     key,
+		updates: make(chan dto.WeatherUpdate, w.options.History),
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	current, ok := w.watches[key]
+	if !ok {
+		current = &watch{
+			location:    location,
+			subscribers: make(map[*WeatherSubscription]struct{}),
+		}
+		w.watches[key] = current
+	}
+	if current.cancel == nil {
+		// Первый подписчик или переподключение в течение HistoryGrace: история сохранена
+		if current.expire != nil {
+			current.expire.Stop()
+			current.expire = nil
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		current.cancel = cancel
+		go w.run(ctx, key, current)
+	}
+	current.subscribers[subscription] = struct{}{}
+
+	replay := current.history
+	if lastEventID == 0 && len(replay) > 0 {
+		replay = replay[len(replay)-1:]
+	}
+	for _, update := range replay {
+		if update.ID > lastEventID {
+			subscription.send(update)
+		}
+	}
+	return subscription
+}
+
+// run перечитывает погоду, пока у точки есть подписчики
+func (w *WeatherWatcher) run(ctx context.Context, key watchKey, current *watch) {
+	ticker := time.NewTicker(w.options.Interval)
+	defer ticker.Stop()
+
+	var alerts []dto.WeatherAlert
+	var alertsCheckedAt time.Time
+	for {
+		// Предупреждения считаются по прогнозу, поэтому обновляем их реже погоды
+		if time.Since(alertsCheckedAt) >= w.options.AlertsInterval {
+			fresh, err := w.options.WeatherUseCase.GetAlerts(ctx, key.lat, key.lon)
+			if err != nil && ctx.Err() == nil {
+				slog.WarnContext(ctx, "weather watcher alerts failed", "lat", key.lat, "lon", key.lon, "error", err)
+			}
+			if err == nil {
+				alerts, alertsCheckedAt = fresh, time.Now()
+			}
+		}
+
+		report, err := w.options.WeatherUseCase.weatherReport(ctx, current.location)
+		if err != nil && ctx.Err() == nil {
+			slog.WarnContext(ctx, "weather watcher refresh failed", "lat", key.lat, "lon", key.lon, "error", err)
+		}
+		if err == nil && ctx.Err() == nil {
+			w.publish(current, report, alerts)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publish рассылает отчет подписчикам, если погода или предупреждения изменились
+func (w *WeatherWatcher) publish(current *watch, report *dto.WeatherReport, alerts []dto.WeatherAlert) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	id := time.Now().UnixMilli()
+	if n := len(current.history); n > 0 {
+		last := current.history[n-1]
+		if sameWeather(last.Report, report) && sameAlerts(last.Alerts, alerts) {
+			return
+		}
+		id = max(id, last.ID+1)
+	}
+
+	update := dto.WeatherUpdate{ID: id, Report: report, Alerts: alerts}
+	current.history = append(current.history, update)
+	if len(current.history) > w.options.History {
+		current.history = current.history[1:]
+	}
+	for subscription := range current.subscribers {
+		subscription.send(update)
+	}
+}
+
+// sameWeather сравнивает отчеты без учета срока жизни кэша
+func sameWeather(a, b *dto.WeatherReport) bool {
+	return a.ObservedAt.Equal(b.ObservedAt) &&
+		a.Temperature == b.Temperature &&
+		a.WindSpeed == b.WindSpeed &&
+		a.WindDirection == b.WindDirection &&
+		a.WeatherCode == b.WeatherCode
+}
+
+func sameAlerts(a, b []dto.WeatherAlert) bool {
+	return slices.EqualFunc(a, b, dto.WeatherAlert.Equal)
+}
+
+// Updates канал изменений погоды. Канал закрывается после Close.
+func (s *WeatherSubscription) Updates() <-chan dto.WeatherUpdate {
+	return s.updates
+}
+
+// Close отменяет подписку. Опрос точки останавливается вместе с последним подписчиком,
+// а история изменений хранится еще HistoryGrace, чтобы клиент мог переподключиться с Last-Event-ID.
+func (s *WeatherSubscription) Close() {
+	s.once.Do(func() {
+		w := s.watcher
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if current, ok := w.watches[s.key]; ok {
+			delete(current.subscribers, s)
+			if len(current.subscribers) == 0 {
+				current.cancel()
+				current.cancel = nil
+				current.expire = time.AfterFunc(w.options.HistoryGrace, func() { w.expire(s.key, current) })
+			}
+		}
+		close(s.updates)
+	})
+}
+
+// expire удаляет историю точки, если за HistoryGrace никто не подписался снова
+func (w *WeatherWatcher) expire(key watchKey, current *watch) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watches[key] == current && current.cancel == nil {
+		delete(w.watches, key)
+	}
+}
+
+// send отправляет изменение без блокировки. Медленный подписчик теряет
+// самое старое изменение: каждое изменение содержит погоду целиком.
+// Вызывается под мьютексом наблюдателя.
+func (s *WeatherSubscription) send(update dto.WeatherUpdate) {
+	for {
+		select {
+		case s.updates <- update:
+			return
+		default:
+		}
+		select {
+		case <-s.updates:
+		default:
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package weather_card рисует PNG карточки погоды средствами чистого Go
+package weather_card
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"time"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// Размеры карточки в пикселях
+const (
+	Width  = 600
+	Height = 320
+)
+
+// Card данные для карточки погоды. Строки уже отформатированы на нужном языке.
+type Card struct {
+	Title       string
+	LocalTime   time.Time
+	Temperature string
+	Description string
+	WeatherCode int
+	// Hourly температуры на ближайшие часы для графика, единицы не важны
+	Hourly []float64
+	// HourlyMin и HourlyMax подписи минимума и максимума графика
+	HourlyMin string
+	HourlyMax string
+}
+
+var (
+	regularFont *opentype.Font
+	boldFont    *opentype.Font
+)
+
+func init() {
+	var err error
+	if regularFont, err = opentype.Parse(goregular.TTF); err != nil {
+		panic(fmt.Sprintf("parse regular font: %v", err))
+	}
+	if boldFont, err = opentype.Parse(gobold.TTF); err != nil {
+		panic(fmt.Sprintf("parse bold font: %v", err))
+	}
+}
+
+// Render рисует карточку и возвращает ее в формате PNG
+func Render(card Card) ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, Width, Height))
+	palette := paletteFor(card.WeatherCode)
+
+	drawBackground(img, palette.top, palette.bottom)
+	drawIcon(img, iconFor(card.WeatherCode), 470, 95, 60)
+
+	faces, err := newFaces()
+	if err != nil {
+		return nil, err
+	}
+	defer faces.Close()
+
+	white := color.RGBA{255, 255, 255, 255}
+	muted := color.RGBA{230, 236, 245, 255}
+
+	drawText(img, faces.title, card.Title, 32, 56, white)
+	drawText(img, faces.small, card.LocalTime.Format("02.01.2006 15:04"), 32, 86, muted)
+	drawText(img, faces.temperature, card.Temperature, 32, 168, white)
+	drawText(img, faces.body, card.Description, 32, 202, muted)
+
+	drawSparkline(img, card.Hourly, image.Rect(32, 230, Width-32, Height-28), white)
+	if len(card.Hourly) > 1 {
+		drawText(img, faces.small, card.HourlyMax, 32, 226, muted)
+		drawText(img, faces.small, card.HourlyMin, 32, Height-8, muted)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("png.Encode(...): %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+type faces struct {
+	title       font.Face
+	temperature font.Face
+	body        font.Face
+	small       font.Face
+}
+
+// newFaces создает начертания на каждый вызов: font.Face нельзя использовать конкурентно
+func newFaces() (*faces, error) {
+	newFace := func(f *opentype.Font, size float64) (font.Face, error) {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return nil, fmt.Errorf("opentype.NewFace(...): %w", err)
+		}
+		return face, nil
+	}
+
+	var result faces
+	var err error
+	if result.title, err = newFace(boldFont, 30); err != nil {
+		return nil, err
+	}
+	if result.temperature, err = newFace(boldFont, 64); err != nil {
+		return nil, err
+	}
+	if result.body, err = newFace(regularFont, 22); err != nil {
+		return nil, err
+	}
+	if result.small, err = newFace(regularFont, 16); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (f *faces) Close() {
+	f.title.Close()
+	f.temperature.Close()
+	f.body.Close()
+	f.small.Close()
+}
+
+func drawText(img draw.Image, face font.Face, text string, x, y int, c color.Color) {
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.NewUniform(c),
+		Face: face,
+		Dot:  fixed.P(x, y),
+	}
+	drawer.DrawString(text)
+}
+
+// drawBackground заливает карточку вертикальным градиентом
+func drawBackground(img *image.RGBA, top, bottom color.RGBA) {
+	for y := 0; y < Height; y++ {
+		t := float64(y) / float64(Height-1)
+		row := color.RGBA{
+			R: lerp(top.R, bottom.R, t),
+			G: lerp(top.G, bottom.G, t),
+			B: lerp(top.B, bottom.B, t),
+			A: 255,
+		}
+		draw.Draw(img, image.Rect(0, y, Width, y+1), image.NewUniform(row), image.Point{}, draw.Src)
+	}
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t)
+}
+
+// drawSparkline рисует график температур, растянутый на прямоугольник rect
+func drawSparkline(img *image.RGBA, values []float64, rect image.Rectangle, c color.RGBA) {
+	if len(values) < 2 {
+		return
+	}
+
+	minValue, maxValue := values[0], values[0]
+	for _, v := range values {
+		minValue = math.Min(minValue, v)
+		maxValue = math.Max(maxValue, v)
+	}
+	spread := maxValue - minValue
+	if spread == 0 {
+		spread = 1
+	}
+
+	// Подписи занимают левую часть, график рисуем правее
+	left := rect.Min.X + 70
+	stepX := float64(rect.Max.X-left) / float64(len(values)-1)
+	point := func(i int) (float64, float64) {
+		x := float64(left) + stepX*float64(i)
+		y := float64(rect.Max.Y) - (values[i]-minValue)/spread*float64(rect.Dy())
+		return x, y
+	}
+
+	for i := 1; i < len(values); i++ {
+		x0, y0 := point(i - 1)
+		x1, y1 := point(i)
+		drawLine(img, x0, y0, x1, y1, 3, c)
+	}
+}
@@ -0,0 +1,158 @@
+package weather_card
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// icon тип пиктограммы погоды
+type icon int
+
+const (
+	iconClear icon = iota
+	iconPartlyCloudy
+	iconCloudy
+	iconFog
+	iconRain
+	iconSnow
+	iconThunderstorm
+)
+
+// iconFor сопоставляет код погоды WMO и пиктограмму
+func iconFor(code int) icon {
+	switch {
+	case code <= 1:
+		return iconClear
+	case code == 2:
+		return iconPartlyCloudy
+	case code == 3:
+		return iconCloudy
+	case code == 45 || code == 48:
+		return iconFog
+	case code >= 51 && code <= 67, code >= 80 && code <= 82:
+		return iconRain
+	case code >= 71 && code <= 77, code == 85 || code == 86:
+		return iconSnow
+	case code >= 95:
+		return iconThunderstorm
+	}
+	return iconCloudy
+}
+
+type cardPalette struct {
+	top    color.RGBA
+	bottom color.RGBA
+}
+
+// paletteFor подбирает цвета фона под погоду
+func paletteFor(code int) cardPalette {
+	switch iconFor(code) {
+	case iconClear, iconPartlyCloudy:
+		return cardPalette{top: color.RGBA{52, 136, 219, 255}, bottom: color.RGBA{120, 190, 240, 255}}
+	case iconRain, iconThunderstorm:
+		return cardPalette{top: color.RGBA{44, 62, 80, 255}, bottom: color.RGBA{86, 110, 135, 255}}
+	case iconSnow:
+		return cardPalette{top: color.RGBA{96, 125, 160, 255}, bottom: color.RGBA{170, 195, 220, 255}}
+	}
+	return cardPalette{top: color.RGBA{92, 108, 128, 255}, bottom: color.RGBA{140, 156, 176, 255}}
+}
+
+var (
+	sunColor       = color.RGBA{255, 200, 40, 255}
+	cloudColor     = color.RGBA{240, 244, 248, 255}
+	darkCloudColor = color.RGBA{190, 200, 212, 255}
+	rainColor      = color.RGBA{120, 190, 255, 255}
+	snowColor      = color.RGBA{255, 255, 255, 255}
+	boltColor      = color.RGBA{255, 220, 60, 255}
+)
+
+// drawIcon рисует пиктограмму с центром (cx, cy) и радиусом r
+func drawIcon(img *image.RGBA, kind icon, cx, cy, r float64) {
+	switch kind {
+	case iconClear:
+		drawSun(img, cx, cy, r*0.45)
+	case iconPartlyCloudy:
+		drawSun(img, cx+r*0.25, cy-r*0.25, r*0.35)
+		drawCloud(img, cx-r*0.1, cy+r*0.15, r*0.7, cloudColor)
+	case iconCloudy:
+		drawCloud(img, cx, cy, r*0.85, cloudColor)
+	case iconFog:
+		for i := 0; i < 4; i++ {
+			y := cy - r*0.45 + float64(i)*r*0.3
+			drawLine(img, cx-r*0.8, y, cx+r*0.8, y, 6, cloudColor)
+		}
+	case iconRain:
+		drawCloud(img, cx, cy-r*0.2, r*0.8, darkCloudColor)
+		for i := -1; i <= 1; i++ {
+			x := cx + float64(i)*r*0.35
+			drawLine(img, x, cy+r*0.35, x-r*0.12, cy+r*0.75, 4, rainColor)
+		}
+	case iconSnow:
+		drawCloud(img, cx, cy-r*0.2, r*0.8, cloudColor)
+		for i := -1; i <= 1; i++ {
+			fillCircle(img, cx+float64(i)*r*0.35, cy+r*0.55, r*0.08, snowColor)
+			fillCircle(img, cx+float64(i)*r*0.35+r*0.17, cy+r*0.8, r*0.08, snowColor)
+		}
+	case iconThunderstorm:
+		drawCloud(img, cx, cy-r*0.2, r*0.8, darkCloudColor)
+		drawLine(img, cx+r*0.1, cy+r*0.2, cx-r*0.15, cy+r*0.55, 6, boltColor)
+		drawLine(img, cx-r*0.15, cy+r*0.55, cx+r*0.1, cy+r*0.55, 6, boltColor)
+		drawLine(img, cx+r*0.1, cy+r*0.55, cx-r*0.1, cy+r*0.9, 6, boltColor)
+	}
+}
+
+func drawSun(img *image.RGBA, cx, cy, r float64) {
+	for i := 0; i < 8; i++ {
+		angle := float64(i) * math.Pi / 4
+		x0, y0 := cx+math.Cos(angle)*r*1.3, cy+math.Sin(angle)*r*1.3
+		x1, y1 := cx+math.Cos(angle)*r*1.7, cy+math.Sin(angle)*r*1.7
+		drawLine(img, x0, y0, x1, y1, 4, sunColor)
+	}
+	fillCircle(img, cx, cy, r, sunColor)
+}
+
+// drawCloud рисует облако шириной около 2*w из нескольких кругов
+func drawCloud(img *image.RGBA, cx, cy, w float64, c color.RGBA) {
+	fillCircle(img, cx-w*0.45, cy+w*0.1, w*0.35, c)
+	fillCircle(img, cx, cy-w*0.1, w*0.5, c)
+	fillCircle(img, cx+w*0.5, cy+w*0.1, w*0.35, c)
+	fillRect(img, cx-w*0.45, cy+w*0.1, cx+w*0.5, cy+w*0.45, c)
+}
+
+func fillCircle(img *image.RGBA, cx, cy, r float64, c color.RGBA) {
+	bounds := img.Bounds()
+	for y := int(cy - r); y <= int(cy+r); y++ {
+		for x := int(cx - r); x <= int(cx+r); x++ {
+			if !image.Pt(x, y).In(bounds) {
+				continue
+			}
+			dx, dy := float64(x)-cx, float64(y)-cy
+			if dx*dx+dy*dy <= r*r {
+				img.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+func fillRect(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	rect := image.Rect(int(x0), int(y0), int(x1), int(y1)).Intersect(img.Bounds())
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// drawLine рисует отрезок заданной толщины, ставя круги вдоль него
+func drawLine(img *image.RGBA, x0, y0, x1, y1, thickness float64, c color.RGBA) {
+	length := math.Hypot(x1-x0, y1-y0)
+	steps := int(math.Ceil(length))
+	if steps == 0 {
+		steps = 1
+	}
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		fillCircle(img, x0+(x1-x0)*t, y0+(y1-y0)*t, thickness/2, c)
+	}
+}
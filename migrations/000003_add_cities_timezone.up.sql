@@ -0,0 +1,10 @@
+ALTER TABLE cities ADD COLUMN timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+UPDATE cities SET timezone = 'Europe/Moscow' WHERE name IN ('Moscow', 'Saint Petersburg', 'Kazan');
+UPDATE cities SET timezone = 'Asia/Novosibirsk' WHERE name = 'Novosibirsk';
+UPDATE cities SET timezone = 'Asia/Yekaterinburg' WHERE name = 'Yekaterinburg';
+UPDATE cities SET timezone = 'America/New_York' WHERE name = 'New York';
+UPDATE cities SET timezone = 'Europe/London' WHERE name = 'London';
+UPDATE cities SET timezone = 'Asia/Tokyo' WHERE name = 'Tokyo';
+UPDATE cities SET timezone = 'Australia/Sydney' WHERE name = 'Sydney';
+UPDATE cities SET timezone = 'Africa/Johannesburg' WHERE name = 'Cape Town';
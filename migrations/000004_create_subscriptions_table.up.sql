@@ -0,0 +1,7 @@
+CREATE TABLE subscriptions (
+    chat_id BIGINT PRIMARY KEY,
+    city_name VARCHAR(100) NOT NULL REFERENCES cities (name) ON DELETE CASCADE,
+    send_time TIME NOT NULL,
+    last_sent_on DATE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
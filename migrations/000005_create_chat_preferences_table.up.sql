@@ -0,0 +1,8 @@
+CREATE TABLE chat_preferences (
+    chat_id BIGINT PRIMARY KEY,
+    language VARCHAR(2) NOT NULL DEFAULT 'ru',
+    units VARCHAR(1) NOT NULL DEFAULT 'c',
+    home_city VARCHAR(100) REFERENCES cities (name) ON DELETE SET NULL,
+    favourite_cities TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
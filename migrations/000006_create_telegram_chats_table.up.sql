@@ -0,0 +1,5 @@
+CREATE TABLE telegram_chats (
+    chat_id BIGINT PRIMARY KEY,
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
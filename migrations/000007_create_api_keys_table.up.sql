@@ -0,0 +1,9 @@
+CREATE TABLE api_keys (
+    id VARCHAR(32) PRIMARY KEY,
+    key_hash CHAR(64) NOT NULL,
+    owner VARCHAR(100) NOT NULL,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    daily_quota INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
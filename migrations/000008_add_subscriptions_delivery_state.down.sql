@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions
+    DROP COLUMN failed_attempts,
+    DROP COLUMN retry_at,
+    DROP COLUMN disabled_at;
@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions
+    ADD COLUMN failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN retry_at TIMESTAMPTZ,
+    ADD COLUMN disabled_at TIMESTAMPTZ;
@@ -0,0 +1,185 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout сколько по умолчанию длится остановка приложения
+const defaultShutdownTimeout = 30 * time.Second
+
+type Options struct {
+	// ShutdownTimeout общий срок остановки, по умолчанию 30 секунд. Что не успело
+	// остановиться, бросается, и ресурсы закрываются все равно.
+	ShutdownTimeout time.Duration
+	// Signals сигналы остановки, по умолчанию SIGINT и SIGTERM
+	Signals []os.Signal
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Lifecycle запускает фоновые задачи приложения и останавливает его по сигналу
+// или при ошибке задачи. Остановка идет в таком порядке:
+//  1. хуки OnStop в порядке регистрации, например остановка HTTP сервера и бота;
+//  2. отмена контекста фоновых задач и ожидание их завершения;
+//  3. хуки OnClose в обратном порядке регистрации, например закрытие Redis и PostgreSQL.
+type Lifecycle struct {
+	options Options
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	// stopping закрывается в начале остановки
+	stopping chan struct{}
+	// failed сигнализирует об ошибке фоновой задачи
+	failed chan struct{}
+
+	mu      sync.Mutex
+	stops   []hook
+	closes  []hook
+	workers sync.WaitGroup
+	errs    []error
+}
+
+func New(options Options) *Lifecycle {
+	if options.ShutdownTimeout <= 0 {
+		options.ShutdownTimeout = defaultShutdownTimeout
+	}
+	if len(options.Signals) == 0 {
+		options.Signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Lifecycle{
+		options:  options,
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+		failed:   make(chan struct{}, 1),
+	}
+}
+
+// Stopping закрывается, когда приложение начинает останавливаться
+func (l *Lifecycle) Stopping() <-chan struct{} {
+	return l.stopping
+}
+
+// Go запускает фоновую задачу. Ее контекст отменяется после хуков OnStop.
+// Ошибка задачи останавливает приложение, nil просто завершает задачу.
+func (l *Lifecycle) Go(name string, run func(ctx context.Context) error) {
+	l.workers.Add(1)
+	go func() {
+		defer l.workers.Done()
+		if err := run(l.ctx); err != nil {
+			slog.Error("background task failed", "task", name, "error", err)
+			l.addError(fmt.Errorf("%s: %w", name, err))
+			select {
+			case l.failed <- struct{}{}:
+			default:
+			}
+		}
+	}()
+}
+
+// OnStop регистрирует остановку компонента, принимающего запросы
+func (l *Lifecycle) OnStop(name string, stop func(ctx context.Context) error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.stops = append(l.stops, hook{name: name, fn: stop})
+}
+
+// OnClose регистрирует закрытие ресурса после завершения фоновых задач
+func (l *Lifecycle) OnClose(name string, close func() error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.closes = append(l.closes, hook{name: name, fn: func(context.Context) error { return close() }})
+}
+
+// Run ждет сигнала остановки или ошибки фоновой задачи и останавливает приложение.
+// Возвращает ошибки задач и остановки.
+func (l *Lifecycle) Run() error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, l.options.Signals...)
+	defer signal.Stop(signals)
+
+	select {
+	case sig := <-signals:
+		slog.Info("shutdown signal received", "signal", sig.String())
+	case <-l.failed:
+		slog.Error("shutting down after background task failure")
+	}
+
+	// Повторный сигнал прерывает остановку
+	go func() {
+		if sig, ok := <-signals; ok {
+			slog.Error("second shutdown signal received, exiting", "signal", sig.String())
+			os.Exit(1)
+		}
+	}()
+
+	l.shutdown()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return errors.Join(l.errs...)
+}
+
+func (l *Lifecycle) shutdown() {
+	start := time.Now()
+	close(l.stopping)
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.options.ShutdownTimeout)
+	defer cancel()
+
+	l.mu.Lock()
+	stops, closes := l.stops, l.closes
+	l.mu.Unlock()
+
+	for _, stop := range stops {
+		l.run(ctx, stop)
+	}
+
+	l.cancel()
+	done := make(chan struct{})
+	go func() {
+		l.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		l.addError(fmt.Errorf("background tasks did not finish in %s", l.options.ShutdownTimeout))
+	}
+
+	// Ресурсы закрываются даже после истечения срока, чтобы не оставлять соединения
+	for i := len(closes) - 1; i >= 0; i-- {
+		l.run(context.Background(), closes[i])
+	}
+
+	slog.Info("shutdown complete", "duration", time.Since(start))
+}
+
+func (l *Lifecycle) run(ctx context.Context, h hook) {
+	start := time.Now()
+	if err := h.fn(ctx); err != nil {
+		l.addError(fmt.Errorf("%s: %w", h.name, err))
+		slog.Error("shutdown step failed", "step", h.name, "error", err)
+		return
+	}
+	slog.Info("shutdown step done", "step", h.name, "duration", time.Since(start))
+}
+
+func (l *Lifecycle) addError(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errs = append(l.errs, err)
+}
@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"weather-api/pkg/tracing"
+)
+
+type attrsKey struct{}
+
+// With возвращает контекст, записи с которым получат переданные атрибуты,
+// например идентификатор запроса или чата. Аргументы как у slog.Logger.With.
+// Атрибуты попадают только в записи, сделанные через *Context методы slog.
+func With(ctx context.Context, args ...any) context.Context {
+	if len(args) == 0 {
+		return ctx
+	}
+	var record slog.Record
+	record.Add(args...)
+
+	parent := attrsFromContext(ctx)
+	attrs := make([]slog.Attr, 0, len(parent)+record.NumAttrs())
+	attrs = append(attrs, parent...)
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	return context.WithValue(ctx, attrsKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler добавляет к записям атрибуты из контекста и идентификаторы трассы
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs := attrsFromContext(ctx); len(attrs) > 0 {
+		record.AddAttrs(attrs...)
+	}
+	if traceID, spanID, ok := tracing.IDs(ctx); ok {
+		record.AddAttrs(slog.String("trace_id", traceID), slog.String("span_id", spanID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
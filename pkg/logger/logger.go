@@ -5,11 +5,20 @@ import (
 	"os"
 )
 
+// Форматы записей лога
+const (
+	// FormatText key=value, удобно читать в терминале
+	FormatText = "text"
+	// FormatJSON одна JSON запись на строку для сборщиков логов
+	FormatJSON = "json"
+)
+
 type Logger struct {
 	*slog.Logger
 }
 
-func NewLogger(level string) *Logger {
+// NewLogger создает логгер, который пишет в stdout в формате text (по умолчанию) или json
+func NewLogger(level, format string) *Logger {
 
 	var slogLevel slog.Level
 	switch level {
@@ -25,8 +34,15 @@ func NewLogger(level string) *Logger {
 		slogLevel = slog.LevelInfo
 	}
 
-	handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slogLevel})
-	logger := slog.New(handler)
+	handlerOptions := &slog.HandlerOptions{Level: slogLevel}
+	var handler slog.Handler
+	switch format {
+	case FormatJSON:
+		handler = slog.NewJSONHandler(os.Stdout, handlerOptions)
+	default:
+		handler = slog.NewTextHandler(os.Stdout, handlerOptions)
+	}
+	logger := slog.New(contextHandler{handler})
 	return &Logger{Logger: logger}
 }
 
@@ -3,6 +3,7 @@ package metrics
 import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	clientmodel "github.com/prometheus/client_model/go"
 )
 
 // Metrics содержит все метрики приложения
@@ -15,6 +16,15 @@ type Metrics struct {
 	CacheMisses             *prometheus.CounterVec
 	DatabaseRequestsTotal   *prometheus.CounterVec
 	DatabaseRequestDuration *prometheus.HistogramVec
+	TelegramUpdatesTotal    *prometheus.CounterVec
+	GrpcRequestsTotal       *prometheus.CounterVec
+	GrpcRequestDuration     *prometheus.HistogramVec
+	WebSocketConnections    prometheus.Gauge
+	WebSocketDisconnects    *prometheus.CounterVec
+	UpstreamBudgetUsed      *prometheus.GaugeVec
+	UpstreamBudgetLimit     *prometheus.GaugeVec
+	UpstreamStaleOnly       *prometheus.GaugeVec
+	UpstreamBudgetDenied    *prometheus.CounterVec
 }
 
 // NewMetrics создает и регистрирует метрики Prometheus
@@ -26,7 +36,7 @@ func NewMetrics() *Metrics {
 				Name: "weather_api_http_requests_total",
 				Help: "Общее количество HTTP запросов",
 			},
-			[]string{"method", "endpoint", "status"},
+			[]string{"method", "endpoint", "status", "key_id"},
 		),
 		HttpRequestDuration: promauto.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -86,7 +96,123 @@ func NewMetrics() *Metrics {
 			},
 			[]string{"operation"},
 		),
+
+		// Метрики Telegram бота
+		TelegramUpdatesTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_api_telegram_updates_total",
+				Help: "Общее количество обработанных обновлений Telegram",
+			},
+			[]string{"type"},
+		),
+
+		// Метрики gRPC запросов
+		GrpcRequestsTotal: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_api_grpc_requests_total",
+				Help: "Общее количество gRPC запросов",
+			},
+			[]string{"method", "code"},
+		),
+		GrpcRequestDuration: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "weather_api_grpc_request_duration_seconds",
+				Help:    "Длительность gRPC запросов в секундах",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"method"},
+		),
+
+		// Метрики WebSocket соединений
+		WebSocketConnections: promauto.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "weather_api_websocket_connections",
+				Help: "Количество открытых WebSocket соединений",
+			},
+		),
+		WebSocketDisconnects: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_api_websocket_disconnects_total",
+				Help: "Количество закрытых WebSocket соединений по причине закрытия",
+			},
+			[]string{"reason"},
+		),
+
+		// Метрики суточного лимита запросов к провайдерам погоды
+		UpstreamBudgetUsed: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_api_upstream_budget_used",
+				Help: "Количество запросов к провайдеру за текущие сутки по UTC",
+			},
+			[]string{"provider"},
+		),
+		UpstreamBudgetLimit: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_api_upstream_budget_limit",
+				Help: "Суточный лимит запросов к провайдеру",
+			},
+			[]string{"provider"},
+		),
+		UpstreamStaleOnly: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "weather_api_upstream_stale_only",
+				Help: "1, если лимит почти исчерпан и погода отдается только из кэша",
+			},
+			[]string{"provider"},
+		),
+		UpstreamBudgetDenied: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "weather_api_upstream_budget_denied_total",
+				Help: "Количество запросов, не отправленных провайдеру из-за исчерпанного лимита",
+			},
+			[]string{"provider", "priority"},
+		),
 	}
 
 	return m
 }
+
+// Snapshot текущие значения счетчиков, просуммированные по всем меткам
+type Snapshot struct {
+	HttpRequests    float64
+	TelegramUpdates float64
+	CacheHits       float64
+	CacheMisses     float64
+}
+
+// CacheHitRatio доля попаданий в кэш от всех обращений
+func (s Snapshot) CacheHitRatio() float64 {
+	total := s.CacheHits + s.CacheMisses
+	if total == 0 {
+		return 0
+	}
+	return s.CacheHits / total
+}
+
+// Snapshot считывает текущие значения счетчиков
+func (m *Metrics) Snapshot() Snapshot {
+	return Snapshot{
+		HttpRequests:    sumCounterVec(m.HttpRequestsTotal),
+		TelegramUpdates: sumCounterVec(m.TelegramUpdatesTotal),
+		CacheHits:       sumCounterVec(m.CacheHits),
+		CacheMisses:     sumCounterVec(m.CacheMisses),
+	}
+}
+
+// sumCounterVec суммирует значения счетчика по всем комбинациям меток
+func sumCounterVec(vec *prometheus.CounterVec) float64 {
+	ch := make(chan prometheus.Metric)
+	go func() {
+		vec.Collect(ch)
+		close(ch)
+	}()
+
+	var total float64
+	for metric := range ch {
+		var value clientmodel.Metric
+		if err := metric.Write(&value); err == nil && value.Counter != nil {
+			total += value.Counter.GetValue()
+		}
+	}
+	return total
+}
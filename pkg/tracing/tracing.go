@@ -0,0 +1,118 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры трасс
+const (
+	ExporterNone = "none"
+	// ExporterOTLP отправляет трассы коллектору по OTLP/gRPC
+	ExporterOTLP = "otlp"
+	// ExporterStdout печатает трассы в stderr, удобно в тестах и локально.
+	// В stdout пишутся логи, поэтому трассы туда не попадают.
+	ExporterStdout = "stdout"
+)
+
+type Options struct {
+	// Exporter none (по умолчанию), otlp или stdout
+	Exporter string
+	// Endpoint адрес коллектора OTLP, например otel-collector:4317
+	Endpoint string
+	// Insecure отправлять в коллектор без TLS
+	Insecure bool
+	// SampleRatio доля новых трасс от 0 до 1, которые записываются. 0 отключает запись
+	// новых трасс, nil записывает все. Если вызывающий сервис уже принял решение, используется оно.
+	SampleRatio *float64
+	ServiceName string
+}
+
+// Setup настраивает глобальные TracerProvider и распространение W3C Trace Context.
+// Возвращает функцию, которая отправляет оставшиеся трассы и останавливает экспорт.
+// Без экспортера трассы не записываются, но контекст трассы клиента передается дальше.
+func Setup(ctx context.Context, options Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch options.Exporter {
+	case "", ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		clientOptions := []otlptracegrpc.Option{}
+		if options.Endpoint != "" {
+			clientOptions = append(clientOptions, otlptracegrpc.WithEndpoint(options.Endpoint))
+		}
+		if options.Insecure {
+			clientOptions = append(clientOptions, otlptracegrpc.WithInsecure())
+		}
+		exporter, err = otlptracegrpc.New(ctx, clientOptions...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stderr))
+	default:
+		return nil, fmt.Errorf("unknown trace exporter: %q", options.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s trace exporter: %w", options.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(options.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create trace resource: %w", err)
+	}
+
+	sampler := sdktrace.AlwaysSample()
+	if ratio := options.SampleRatio; ratio != nil {
+		switch {
+		case *ratio <= 0:
+			sampler = sdktrace.NeverSample()
+		case *ratio < 1:
+			sampler = sdktrace.TraceIDRatioBased(*ratio)
+		}
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// End записывает ошибку в span и завершает его. Вызывается через defer
+// с именованным результатом err.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// IDs возвращает идентификаторы трассы и span из контекста, если трасса записывается
+func IDs(ctx context.Context) (traceID, spanID string, ok bool) {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.IsValid() {
+		return "", "", false
+	}
+	return spanContext.TraceID().String(), spanContext.SpanID().String(), true
+}